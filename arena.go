@@ -0,0 +1,95 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"sync"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// seriesArenaChunkSize is the number of labels or samples a seriesArena allocates at once.
+const seriesArenaChunkSize = 4096
+
+// seriesArenaPool reuses the seriesArenas of the exports.
+var seriesArenaPool = sync.Pool{
+	New: func() any { return new(seriesArena) },
+}
+
+// seriesArena allocates the labels and samples of the series of an export from large chunks, instead of a slice
+// per series. The chunks are reused by the next export once the arena is released, so the series must not be
+// used after the export built its messages. A nil arena allocates every slice on its own.
+type seriesArena struct {
+	labelChunks  arenaChunks[prompb.Label]
+	sampleChunks arenaChunks[prompb.Sample]
+}
+
+// getSeriesArena returns a pooled seriesArena. The arena must be released after use.
+func getSeriesArena() *seriesArena {
+	return seriesArenaPool.Get().(*seriesArena)
+}
+
+// release clears the arena and returns it to the pool.
+func (a *seriesArena) release() {
+	a.labelChunks.reset()
+	a.sampleChunks.reset()
+	seriesArenaPool.Put(a)
+}
+
+// labels returns a slice of n labels.
+func (a *seriesArena) labels(n int) []prompb.Label {
+	if a == nil {
+		return make([]prompb.Label, n)
+	}
+	return a.labelChunks.alloc(n)
+}
+
+// samples returns a slice of n samples.
+func (a *seriesArena) samples(n int) []prompb.Sample {
+	if a == nil {
+		return make([]prompb.Sample, n)
+	}
+	return a.sampleChunks.alloc(n)
+}
+
+// arenaChunks allocates slices from chunks of seriesArenaChunkSize elements, filled one after the other.
+type arenaChunks[T any] struct {
+	chunks  [][]T
+	current int
+}
+
+// alloc returns a zeroed slice of n elements. Its capacity is n, so appending to it does not overwrite the
+// next slices of the chunk.
+func (c *arenaChunks[T]) alloc(n int) []T {
+	for ; c.current < len(c.chunks); c.current++ {
+		chunk := c.chunks[c.current]
+		if start := len(chunk); cap(chunk)-start >= n {
+			c.chunks[c.current] = chunk[:start+n]
+			return chunk[start : start+n : start+n]
+		}
+	}
+	chunk := make([]T, n, max(n, seriesArenaChunkSize))
+	c.chunks = append(c.chunks, chunk)
+	return chunk[:n:n]
+}
+
+// reset clears the chunks, so they do not keep the label values alive, and makes them available again.
+func (c *arenaChunks[T]) reset() {
+	for i, chunk := range c.chunks {
+		clear(chunk)
+		c.chunks[i] = chunk[:0]
+	}
+	c.current = 0
+}
@@ -0,0 +1,70 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSeriesArena(t *testing.T) {
+	arena := new(seriesArena)
+
+	first := arena.labels(2)
+	second := arena.labels(3)
+	assert.Len(t, first, 2)
+	assert.Equal(t, 2, cap(first), "appending must not overwrite the next slice")
+	first[1] = prompb.Label{Name: "a", Value: "1"}
+	assert.Equal(t, prompb.Label{}, second[0])
+	assert.Len(t, arena.labelChunks.chunks, 1)
+
+	large := arena.labels(seriesArenaChunkSize + 1)
+	assert.Len(t, large, seriesArenaChunkSize+1)
+	assert.Len(t, arena.labelChunks.chunks, 2)
+
+	arena.labelChunks.reset()
+	reused := arena.labels(2)
+	assert.Equal(t, []prompb.Label{{}, {}}, reused, "the chunks are cleared")
+	assert.Same(t, &first[0], &reused[0], "the chunks are reused")
+	assert.Len(t, arena.labelChunks.chunks, 2)
+}
+
+func TestNilSeriesArena(t *testing.T) {
+	var arena *seriesArena
+	assert.Len(t, arena.labels(2), 2)
+	assert.Len(t, arena.samples(1), 1)
+}
+
+func TestLabelBuilderSeriesArena(t *testing.T) {
+	arena := getSeriesArena()
+	defer arena.release()
+	b := getLabelBuilder(map[string]string{"service.name": "test"}, false, arena)
+	defer b.release()
+
+	b.setDataPoint("requests", attribute.NewSet(attribute.String("method", "GET")))
+	ts := b.series(5, time.UnixMilli(1000), nil)
+	assert.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: "requests"},
+		{Name: "method", Value: "GET"},
+		{Name: "service_name", Value: "test"},
+	}, ts.Labels)
+	assert.Equal(t, []prompb.Sample{{Value: 5, Timestamp: 1000}}, ts.Samples)
+	assert.Len(t, arena.labelChunks.chunks, 1)
+	assert.Len(t, arena.sampleChunks.chunks, 1)
+}
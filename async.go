@@ -0,0 +1,151 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrQueueFull occurs in async mode when a message is dropped because the send queue is full.
+	ErrQueueFull = fmt.Errorf("send queue is full")
+
+	// ErrQueueClosed occurs in async mode when a message is exported after the exporter was shut down.
+	ErrQueueClosed = fmt.Errorf("send queue is closed")
+)
+
+// SendError describes a message that could not be sent to Logz.io in async mode.
+type SendError struct {
+	// Err is the error that caused the message to be dropped.
+	Err error
+	// Series is the number of timeseries in the message.
+	Series int
+	// Bytes is the size of the compressed message.
+	Bytes int
+	// Time is when the message was queued.
+	Time time.Time
+}
+
+// Error returns the message of the underlying error.
+func (e SendError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e SendError) Unwrap() error {
+	return e.Err
+}
+
+// queuedMessage is a compressed message waiting to be sent by the async worker. A message with a non-nil
+// flushed channel carries no data and is closed by the worker once all messages queued before it were sent.
+type queuedMessage struct {
+	message   []byte
+	series    int
+	exemplars int
+	queued    time.Time
+	flushed   chan struct{}
+}
+
+// startWorker creates the send queue and starts the worker that sends the queued messages.
+func (e *Exporter) startWorker() {
+	e.queue = make(chan queuedMessage, e.config.AsyncQueueSize)
+	e.workerDone = make(chan struct{})
+
+	go func() {
+		defer close(e.workerDone)
+		for msg := range e.queue {
+			if msg.flushed != nil {
+				close(msg.flushed)
+				continue
+			}
+			if err := e.deliver(context.Background(), msg.message, msg.series, msg.exemplars, msg.queued); err != nil {
+				e.handleSendError(SendError{Err: err, Series: msg.series, Bytes: len(msg.message), Time: msg.queued})
+			}
+		}
+	}()
+}
+
+// enqueue queues a compressed message for the worker without blocking. Messages that do not fit in the
+// queue are dropped and reported to the SendErrorHandler.
+func (e *Exporter) enqueue(message []byte, series, exemplars int) error {
+	msg := queuedMessage{message: message, series: series, exemplars: exemplars, queued: time.Now()}
+
+	e.queueMu.RLock()
+	defer e.queueMu.RUnlock()
+	if e.queueClosed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case e.queue <- msg:
+		return nil
+	default:
+		sendErr := SendError{Err: ErrQueueFull, Series: series, Bytes: len(message), Time: msg.queued}
+		e.handleSendError(sendErr)
+		return sendErr
+	}
+}
+
+// flushQueue blocks until all messages queued before the call were handled by the worker, or ctx is done.
+func (e *Exporter) flushQueue(ctx context.Context) error {
+	flushed := make(chan struct{})
+
+	e.queueMu.RLock()
+	if e.queueClosed {
+		e.queueMu.RUnlock()
+		return ctx.Err()
+	}
+	select {
+	case e.queue <- queuedMessage{flushed: flushed}:
+		e.queueMu.RUnlock()
+	case <-ctx.Done():
+		e.queueMu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stopWorker closes the send queue and waits for the worker to send the messages left in it, or for ctx
+// to be done.
+func (e *Exporter) stopWorker(ctx context.Context) error {
+	e.queueMu.Lock()
+	if !e.queueClosed {
+		e.queueClosed = true
+		close(e.queue)
+	}
+	e.queueMu.Unlock()
+
+	select {
+	case <-e.workerDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handleSendError passes a send error to the configured SendErrorHandler.
+func (e *Exporter) handleSendError(err SendError) {
+	if e.config.SendErrorHandler != nil {
+		e.config.SendErrorHandler(err)
+	}
+}
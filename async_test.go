@@ -0,0 +1,87 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAsyncExport tests that async exports are sent by the worker and that send errors are passed to the
+// SendErrorHandler with the message metadata.
+func TestAsyncExport(t *testing.T) {
+	var mu sync.Mutex
+	var requests int
+	var sendErrors []SendError
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		AsyncQueueSize:        10,
+		SendErrorHandler: func(err SendError) {
+			mu.Lock()
+			sendErrors = append(sendErrors, err)
+			mu.Unlock()
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+	require.NoError(t, exporter.ForceFlush(context.Background()))
+
+	mu.Lock()
+	assert.Equal(t, 1, requests)
+	require.Len(t, sendErrors, 1)
+	assert.Equal(t, 1, sendErrors[0].Series)
+	assert.Positive(t, sendErrors[0].Bytes)
+	assert.False(t, sendErrors[0].Time.IsZero())
+	mu.Unlock()
+
+	require.NoError(t, exporter.Shutdown(context.Background()))
+	assert.ErrorIs(t, exporter.Export(context.Background(), getSumMetric(5)), ErrQueueClosed)
+}
+
+// TestAsyncExportQueueFull tests that messages that do not fit in the queue are reported as dropped.
+func TestAsyncExportQueueFull(t *testing.T) {
+	var sendErrors []SendError
+	exporter := Exporter{config: Config{
+		AsyncQueueSize: 1,
+		SendErrorHandler: func(err SendError) {
+			sendErrors = append(sendErrors, err)
+		},
+	}}
+	// The worker is not started, so the queue is never drained.
+	exporter.queue = make(chan queuedMessage, exporter.config.AsyncQueueSize)
+
+	require.NoError(t, exporter.enqueue([]byte("first"), 1, 0))
+	err := exporter.enqueue([]byte("second"), 2, 0)
+	assert.ErrorIs(t, err, ErrQueueFull)
+	require.Len(t, sendErrors, 1)
+	assert.Equal(t, 2, sendErrors[0].Series)
+}
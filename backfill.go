@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// backfillBatchSize is the maximum number of samples sent per backfill request
+const backfillBatchSize = 2000
+
+// Sample is a value of a series at a point in time.
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// BackfillSeries holds historical samples of a series to send with Backfill.
+type BackfillSeries struct {
+	MetricName string
+	Labels     map[string]string
+	Samples    []Sample
+	// Resource is the resource that recorded the samples. When set, the series get the same global labels as the
+	// series Export sends for the resource. Otherwise, only the ExternalLabels are added, with their references
+	// to environment variables expanded.
+	Resource *resource.Resource
+}
+
+// Backfill sends historical samples to Logz.io, e.g. to fill the gaps of an outage from data recorded
+// elsewhere. The metric names are validated, the ExternalLabels are added as Export adds them, the samples of
+// every series are sorted by time, and the samples are sent in batches of up to backfillBatchSize samples.
+// Backfilled samples bypass the SDK reader, so they are not affected by the temporality or aggregation settings.
+func (e *Exporter) Backfill(ctx context.Context, series []BackfillSeries) error {
+	for _, s := range series {
+		if !IsValidMetricName(s.MetricName) {
+			return fmt.Errorf("invalid backfill metric name %q", s.MetricName)
+		}
+	}
+
+	var result *multierror.Error
+	var batch []prompb.TimeSeries
+	var batchSamples int
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.sendTimeSeries(ctx, batch); err != nil {
+			result = multierror.Append(result, err)
+		}
+		batch, batchSamples = nil, 0
+	}
+
+	for _, s := range series {
+		labels := maps.Clone(s.Labels)
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		maps.Copy(labels, e.backfillGlobalLabels(s.Resource))
+		labelSet := createLabelSet(addMetricName(s.MetricName, labels), e.config.UTF8Names)
+
+		samples := make([]prompb.Sample, len(s.Samples))
+		for i, sample := range s.Samples {
+			samples[i] = prompb.Sample{Value: sample.Value, Timestamp: sample.Time.UnixMilli()}
+		}
+		slices.SortStableFunc(samples, func(a, b prompb.Sample) int {
+			return cmp.Compare(a.Timestamp, b.Timestamp)
+		})
+
+		for len(samples) > 0 {
+			if err := ctx.Err(); err != nil {
+				return multierror.Append(result, err).ErrorOrNil()
+			}
+			n := min(len(samples), backfillBatchSize-batchSamples)
+			batch = append(batch, prompb.TimeSeries{Labels: labelSet, Samples: samples[:n]})
+			batchSamples += n
+			samples = samples[n:]
+			if batchSamples >= backfillBatchSize {
+				flush()
+			}
+		}
+	}
+	flush()
+
+	return result.ErrorOrNil()
+}
+
+// backfillGlobalLabels returns the global labels of backfilled series recorded by res, which may be nil. The
+// references of the ExternalLabels are expanded as for the series of Export, so that the backfilled samples
+// belong to the same series.
+func (e *Exporter) backfillGlobalLabels(res *resource.Resource) map[string]string {
+	if res != nil {
+		return e.cachedGlobalLabels(res)
+	}
+	labels := make(map[string]string, len(e.config.ExternalLabels))
+	for name, value := range e.config.ExternalLabels {
+		labels[name] = expandLabelTemplate(value, resource.Empty())
+	}
+	return labels
+}
@@ -0,0 +1,143 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestBackfill(t *testing.T) {
+	var requests []*prompb.WriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		uncompressed, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		wr := &prompb.WriteRequest{}
+		require.NoError(t, wr.Unmarshal(uncompressed))
+		requests = append(requests, wr)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		ExternalLabels:        map[string]string{"env": "prod"},
+	})
+	require.NoError(t, err)
+
+	start := time.Unix(1700000000, 0)
+	samples := make([]Sample, backfillBatchSize+10)
+	for i := range samples {
+		// Add the samples in reverse order to verify that they are sorted.
+		samples[i] = Sample{Time: start.Add(time.Duration(len(samples)-i) * time.Minute), Value: float64(i)}
+	}
+
+	err = exporter.Backfill(context.Background(), []BackfillSeries{
+		{MetricName: "jobs_total", Labels: map[string]string{"job": "batch"}, Samples: samples},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2)
+	var total int
+	var last int64
+	for _, wr := range requests {
+		for _, ts := range wr.Timeseries {
+			assert.Equal(t, []prompb.Label{
+				{Name: "__name__", Value: "jobs_total"},
+				{Name: "env", Value: "prod"},
+				{Name: "job", Value: "batch"},
+			}, ts.Labels)
+			for _, sample := range ts.Samples {
+				assert.Greater(t, sample.Timestamp, last)
+				last = sample.Timestamp
+				total++
+			}
+		}
+	}
+	assert.Equal(t, len(samples), total)
+}
+
+func TestBackfillInvalidMetricName(t *testing.T) {
+	exporter := Exporter{config: validConfig}
+	err := exporter.Backfill(context.Background(), []BackfillSeries{{MetricName: "invalid-name"}})
+	assert.Error(t, err)
+}
+
+func TestBackfillExpandsExternalLabels(t *testing.T) {
+	t.Setenv("LOGZIO_BACKFILL_TEST_REGION", "eu-west-1")
+	var labels [][]prompb.Label
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		uncompressed, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		wr := &prompb.WriteRequest{}
+		require.NoError(t, wr.Unmarshal(uncompressed))
+		for _, ts := range wr.Timeseries {
+			labels = append(labels, ts.Labels)
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	copyResourceAttributes := false
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		ExternalLabels: map[string]string{
+			"region":  "${LOGZIO_BACKFILL_TEST_REGION}",
+			"service": "${service.name:-unknown}",
+		},
+		CopyResourceAttributes: &copyResourceAttributes,
+	})
+	require.NoError(t, err)
+
+	res := resource.NewSchemaless(attribute.String("service.name", "checkout"))
+	samples := []Sample{{Time: time.Unix(1700000000, 0), Value: 1}}
+	err = exporter.Backfill(context.Background(), []BackfillSeries{
+		{MetricName: "jobs_total", Samples: samples, Resource: res},
+		{MetricName: "jobs_total", Samples: samples},
+	})
+	require.NoError(t, err)
+
+	// The backfilled series of the resource get the same global labels as the series Export sends for it.
+	want := []prompb.Label{{Name: "__name__", Value: "jobs_total"}}
+	for name, value := range exporter.cachedGlobalLabels(res) {
+		want = append(want, prompb.Label{Name: name, Value: value})
+	}
+	require.Len(t, labels, 2)
+	assert.ElementsMatch(t, want, labels[0])
+	assert.Contains(t, labels[0], prompb.Label{Name: "service", Value: "checkout"})
+	assert.Contains(t, labels[0], prompb.Label{Name: "region", Value: "eu-west-1"})
+	assert.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: "jobs_total"},
+		{Name: "region", Value: "eu-west-1"},
+		{Name: "service", Value: "unknown"},
+	}, labels[1])
+}
@@ -0,0 +1,57 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBatchID(t *testing.T) {
+	id := newBatchID(time.UnixMilli(1469918176385))
+	assert.Len(t, id, 26)
+	assert.True(t, strings.HasPrefix(id, "01ARYZ6S41"), id)
+	for _, c := range id {
+		assert.Contains(t, crockfordBase32, string(c))
+	}
+
+	assert.NotEqual(t, id, newBatchID(time.UnixMilli(1469918176385)))
+	assert.Less(t, id, newBatchID(time.UnixMilli(1469918176386)))
+}
+
+func TestBatchIDHeader(t *testing.T) {
+	var batchID string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		batchID = req.Header.Get("X-Batch-Id")
+		rw.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	exporter := Exporter{config: Config{LogzioMetricsListener: server.URL, BatchIDHeader: "X-Batch-Id"}}
+	req, err := exporter.buildRequest(context.Background(), []byte{}, RemoteWrite1)
+	require.NoError(t, err)
+
+	_, err = exporter.sendRequest(req)
+	require.Error(t, err)
+	assert.Len(t, batchID, 26)
+	assert.Contains(t, err.Error(), batchID)
+}
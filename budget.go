@@ -0,0 +1,134 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// budgetIntervalJitter is the fraction of the interval of a budget by which an export may come early and still start
+// a new interval, since periodic readers do not fire exactly one interval apart.
+const budgetIntervalJitter = 0.1
+
+// Budget limits the number of samples of the series matching its labels, e.g. the series of a team sharing the
+// account, that are exported per interval.
+type Budget struct {
+	// Name identifies the budget in the BudgetDrops.
+	Name string
+	// Labels select the series of the budget: a series matches when it has all the labels with the same values.
+	// The label names are the exported names, e.g. "service_name" for the "service.name" attribute.
+	Labels map[string]string
+	// MaxSamples is the number of samples of the matching series exported per interval. The series exported
+	// once the budget is spent are dropped until the next interval.
+	MaxSamples int
+	// Interval is the period of the budget. It defaults to the PushInterval. An export up to a tenth of the
+	// interval early still starts a new interval.
+	Interval time.Duration
+}
+
+// matches returns true when the labels contain all the labels of the budget.
+func (b Budget) matches(labels []prompb.Label) bool {
+	matched := 0
+	for _, label := range labels {
+		if value, ok := b.Labels[label.Name]; ok {
+			if value != label.Value {
+				return false
+			}
+			matched++
+		}
+	}
+	return matched == len(b.Labels)
+}
+
+// budgetTracker tracks the samples spent of each budget in the current interval.
+type budgetTracker struct {
+	mu      sync.Mutex
+	windows map[string]budgetWindow
+	drops   map[string]uint64
+}
+
+// budgetWindow is the samples spent of a budget since the start of its interval.
+type budgetWindow struct {
+	start time.Time
+	spent int
+}
+
+// BudgetDrops returns the number of series dropped by each budget, by budget name.
+func (e *Exporter) BudgetDrops() map[string]uint64 {
+	e.budgets.mu.Lock()
+	defer e.budgets.mu.Unlock()
+
+	drops := make(map[string]uint64, len(e.budgets.drops))
+	for name, count := range e.budgets.drops {
+		drops[name] = count
+	}
+	return drops
+}
+
+// enforceBudgets drops the series of the Budgets whose samples for the interval are spent. A series is counted
+// against the first budget it matches.
+func (e *Exporter) enforceBudgets(timeseries []prompb.TimeSeries, now time.Time) []prompb.TimeSeries {
+	if len(e.config.Budgets) == 0 {
+		return timeseries
+	}
+
+	e.budgets.mu.Lock()
+	defer e.budgets.mu.Unlock()
+	if e.budgets.windows == nil {
+		e.budgets.windows = map[string]budgetWindow{}
+		e.budgets.drops = map[string]uint64{}
+	}
+
+	kept := timeseries[:0]
+	for _, ts := range timeseries {
+		budget, ok := e.matchBudget(ts.Labels)
+		if !ok {
+			kept = append(kept, ts)
+			continue
+		}
+
+		window := e.budgets.windows[budget.Name]
+		interval := budget.Interval
+		if interval == 0 {
+			interval = e.config.PushInterval
+		}
+		if now.Sub(window.start) >= interval-time.Duration(float64(interval)*budgetIntervalJitter) {
+			window = budgetWindow{start: now}
+		}
+		// A native histogram counts as a single sample.
+		samples := len(ts.Samples) + len(ts.Histograms)
+		if window.spent+samples > budget.MaxSamples {
+			e.budgets.drops[budget.Name]++
+		} else {
+			window.spent += samples
+			kept = append(kept, ts)
+		}
+		e.budgets.windows[budget.Name] = window
+	}
+	return kept
+}
+
+// matchBudget returns the first budget matching the labels.
+func (e *Exporter) matchBudget(labels []prompb.Label) (Budget, bool) {
+	for _, budget := range e.config.Budgets {
+		if budget.matches(labels) {
+			return budget, true
+		}
+	}
+	return Budget{}, false
+}
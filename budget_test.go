@@ -0,0 +1,85 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgetMatches(t *testing.T) {
+	budget := Budget{Labels: map[string]string{"team": "payments", "env": "prod"}}
+
+	assert.True(t, budget.matches([]prompb.Label{{Name: "env", Value: "prod"}, {Name: "job", Value: "api"}, {Name: "team", Value: "payments"}}))
+	assert.False(t, budget.matches([]prompb.Label{{Name: "env", Value: "dev"}, {Name: "team", Value: "payments"}}))
+	assert.False(t, budget.matches([]prompb.Label{{Name: "team", Value: "payments"}}))
+}
+
+func TestEnforceBudgets(t *testing.T) {
+	exporter := Exporter{config: Config{
+		PushInterval: 10 * time.Second,
+		Budgets: []Budget{
+			{Name: "payments", Labels: map[string]string{"team": "payments"}, MaxSamples: 2},
+			{Name: "search", Labels: map[string]string{"team": "search"}, MaxSamples: 1, Interval: time.Minute},
+		},
+	}}
+	newTimeSeries := func(team string) prompb.TimeSeries {
+		return prompb.TimeSeries{
+			Labels:  []prompb.Label{{Name: "team", Value: team}},
+			Samples: []prompb.Sample{{Value: 1}},
+		}
+	}
+	teams := func(timeseries []prompb.TimeSeries) []string {
+		var got []string
+		for _, ts := range timeseries {
+			got = append(got, ts.Labels[0].Value)
+		}
+		return got
+	}
+	now := time.Unix(1000, 0)
+
+	got := exporter.enforceBudgets([]prompb.TimeSeries{
+		newTimeSeries("payments"), newTimeSeries("search"), newTimeSeries("payments"),
+		newTimeSeries("payments"), newTimeSeries("search"), newTimeSeries("core"),
+	}, now)
+	assert.Equal(t, []string{"payments", "search", "payments", "core"}, teams(got))
+	assert.Equal(t, map[string]uint64{"payments": 1, "search": 1}, exporter.BudgetDrops())
+
+	// The payments budget is renewed after the push interval, the search budget after a minute.
+	got = exporter.enforceBudgets([]prompb.TimeSeries{newTimeSeries("payments"), newTimeSeries("search")}, now.Add(10*time.Second))
+	assert.Equal(t, []string{"payments"}, teams(got))
+	got = exporter.enforceBudgets([]prompb.TimeSeries{newTimeSeries("search")}, now.Add(time.Minute))
+	assert.Equal(t, []string{"search"}, teams(got))
+	assert.Equal(t, map[string]uint64{"payments": 1, "search": 2}, exporter.BudgetDrops())
+}
+
+// TestEnforceBudgetsEarlyExport tests that an export slightly less than an interval after the previous one, as
+// periodic readers fire, starts a new interval.
+func TestEnforceBudgetsEarlyExport(t *testing.T) {
+	exporter := Exporter{config: Config{
+		Budgets: []Budget{{Name: "payments", Labels: map[string]string{"team": "payments"}, MaxSamples: 1, Interval: 10 * time.Second}},
+	}}
+	series := []prompb.TimeSeries{{Labels: []prompb.Label{{Name: "team", Value: "payments"}}, Samples: []prompb.Sample{{Value: 1}}}}
+	now := time.Unix(1000, 0)
+
+	assert.Len(t, exporter.enforceBudgets(slices.Clone(series), now), 1)
+	assert.Len(t, exporter.enforceBudgets(slices.Clone(series), now.Add(5*time.Second)), 0, "within the interval")
+	assert.Len(t, exporter.enforceBudgets(slices.Clone(series), now.Add(10*time.Second-5*time.Millisecond)), 1)
+	assert.Equal(t, map[string]uint64{"payments": 1}, exporter.BudgetDrops())
+}
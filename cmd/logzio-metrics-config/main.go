@@ -0,0 +1,629 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command logzio-metrics-config validates exporter configuration files, e.g. in the CI pipelines of services that
+// configure the exporter from a file.
+//
+// Usage:
+//
+//	logzio-metrics-config validate <file>
+//
+// The file is YAML or JSON. It is validated with Config.Validate, and the effective configuration, including
+// defaults, is printed with the token and header values masked. The exit code is 1 when the file is invalid and 2
+// on usage errors.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"gopkg.in/yaml.v3"
+
+	metricsExporter "github.com/logzio/go-metrics-sdk"
+)
+
+const usage = "usage: logzio-metrics-config validate <file>"
+
+// fileConfig is the file representation of the metricsExporter.Config fields that can be set without code.
+type fileConfig struct {
+	LogzioMetricsListener     string            `yaml:"logzio_metrics_listener,omitempty"`
+	FixListenerPort           bool              `yaml:"fix_listener_port,omitempty"`
+	ListenerQueryParams       map[string]string `yaml:"listener_query_params,omitempty"`
+	LogzioMetricsToken        string            `yaml:"logzio_metrics_token,omitempty"`
+	RemoteTimeout             time.Duration     `yaml:"remote_timeout,omitempty"`
+	MaxRequestBodyBytes       int               `yaml:"max_request_body_bytes,omitempty"`
+	Compression               string            `yaml:"compression,omitempty"`
+	RemoteWriteProtocol       string            `yaml:"remote_write_protocol,omitempty"`
+	Headers                   map[string]string `yaml:"headers,omitempty"`
+	BatchIDHeader             string            `yaml:"batch_id_header,omitempty"`
+	DialNetwork               string            `yaml:"dial_network,omitempty"`
+	DialFallbackDelay         time.Duration     `yaml:"dial_fallback_delay,omitempty"`
+	RedirectPolicy            string            `yaml:"redirect_policy,omitempty"`
+	Retry                     fileRetryConfig   `yaml:"retry,omitempty"`
+	PushInterval              time.Duration     `yaml:"push_interval,omitempty"`
+	MinPushInterval           time.Duration     `yaml:"min_push_interval,omitempty"`
+	PushJitter                time.Duration     `yaml:"push_jitter,omitempty"`
+	MinSendInterval           time.Duration     `yaml:"min_send_interval,omitempty"`
+	ExportTimeBudget          float64           `yaml:"export_time_budget,omitempty"`
+	SplitSlowExports          bool              `yaml:"split_slow_exports,omitempty"`
+	SendWindows               []fileSendWindow  `yaml:"send_windows,omitempty"`
+	AsyncQueueSize            int               `yaml:"async_queue_size,omitempty"`
+	Spool                     fileSpoolConfig   `yaml:"spool,omitempty"`
+	FallbackAfter             time.Duration     `yaml:"fallback_after,omitempty"`
+	JobMode                   bool              `yaml:"job_mode,omitempty"`
+	ExportOnStart             bool              `yaml:"export_on_start,omitempty"`
+	EphemeralJob              bool              `yaml:"ephemeral_job,omitempty"`
+	EphemeralAttribute        string            `yaml:"ephemeral_attribute,omitempty"`
+	SlowExportThreshold       time.Duration     `yaml:"slow_export_threshold,omitempty"`
+	Quantiles                 []float64         `yaml:"quantiles,omitempty"`
+	HistogramBoundaries       []float64         `yaml:"histogram_boundaries,omitempty"`
+	MaxHistogramBuckets       int               `yaml:"max_histogram_buckets,omitempty"`
+	HistogramQuantiles        string            `yaml:"histogram_quantiles,omitempty"`
+	ExponentialHistograms     string            `yaml:"exponential_histograms,omitempty"`
+	ExternalLabels            map[string]string `yaml:"external_labels,omitempty"`
+	LabelNamespace            string            `yaml:"label_namespace,omitempty"`
+	UTF8Names                 bool              `yaml:"utf8_names,omitempty"`
+	MaxLabelsPerSeries        int               `yaml:"max_labels_per_series,omitempty"`
+	MaxLabelValueLength       int               `yaml:"max_label_value_length,omitempty"`
+	LabelLimitPolicy          string            `yaml:"label_limit_policy,omitempty"`
+	LabelTrimOrder            []string          `yaml:"label_trim_order,omitempty"`
+	AddMetricSuffixes         bool              `yaml:"add_metric_suffixes,omitempty"`
+	InferUnits                bool              `yaml:"infer_units,omitempty"`
+	Temporality               map[string]string `yaml:"temporality,omitempty"`
+	SendMetadata              bool              `yaml:"send_metadata,omitempty"`
+	MetricTypeOverrides       map[string]string `yaml:"metric_type_overrides,omitempty"`
+	MetricDescriptions        map[string]string `yaml:"metric_descriptions,omitempty"`
+	EmitScopeInfo             bool              `yaml:"emit_scope_info,omitempty"`
+	MaxScopeAttributeBytes    int               `yaml:"max_scope_attribute_bytes,omitempty"`
+	OversizedScopePolicy      string            `yaml:"oversized_scope_policy,omitempty"`
+	EmitBuildInfo             bool              `yaml:"emit_build_info,omitempty"`
+	ExemplarTraceIDLabel      string            `yaml:"exemplar_trace_id_label,omitempty"`
+	ExemplarSpanIDLabel       string            `yaml:"exemplar_span_id_label,omitempty"`
+	ExemplarPolicy            string            `yaml:"exemplar_policy,omitempty"`
+	LowMemory                 bool              `yaml:"low_memory,omitempty"`
+	Strict                    bool              `yaml:"strict,omitempty"`
+	MaxTrackedSeries          int               `yaml:"max_tracked_series,omitempty"`
+	Budgets                   []fileBudget      `yaml:"budgets,omitempty"`
+	Rollups                   []fileRollup      `yaml:"rollups,omitempty"`
+	Filter                    fileFilterConfig  `yaml:"filter,omitempty"`
+	DuplicateMetricTypePolicy string            `yaml:"duplicate_metric_type_policy,omitempty"`
+	ZeroTimestampPolicy       string            `yaml:"zero_timestamp_policy,omitempty"`
+	NonMonotonicSumSuffix     string            `yaml:"non_monotonic_sum_suffix,omitempty"`
+	UpDownCounterGaugeNames   bool              `yaml:"up_down_counter_gauge_names,omitempty"`
+	CopyResourceAttributes    *bool             `yaml:"copy_resource_attributes,omitempty"`
+	AddInstanceLabel          bool              `yaml:"add_instance_label,omitempty"`
+	AddEnvLabel               bool              `yaml:"add_env_label,omitempty"`
+	Instance                  string            `yaml:"instance,omitempty"`
+}
+
+// fileRetryConfig is the file representation of metricsExporter.RetryConfig.
+type fileRetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts,omitempty"`
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty"`
+	MaxBackoff     time.Duration `yaml:"max_backoff,omitempty"`
+	Jitter         time.Duration `yaml:"jitter,omitempty"`
+}
+
+// fileSendWindow is the file representation of metricsExporter.SendWindow.
+type fileSendWindow struct {
+	Start time.Duration `yaml:"start"`
+	End   time.Duration `yaml:"end"`
+}
+
+// fileSpoolConfig is the file representation of metricsExporter.SpoolConfig.
+type fileSpoolConfig struct {
+	Dir       string        `yaml:"dir,omitempty"`
+	MaxBytes  int64         `yaml:"max_bytes,omitempty"`
+	Retention time.Duration `yaml:"retention,omitempty"`
+}
+
+// fileBudget is the file representation of metricsExporter.Budget.
+type fileBudget struct {
+	Name       string            `yaml:"name"`
+	Labels     map[string]string `yaml:"labels"`
+	MaxSamples int               `yaml:"max_samples"`
+	Interval   time.Duration     `yaml:"interval,omitempty"`
+}
+
+// fileRollup is the file representation of metricsExporter.Rollup.
+type fileRollup struct {
+	Metric  string   `yaml:"metric"`
+	Name    string   `yaml:"name"`
+	Without []string `yaml:"without"`
+}
+
+// fileFilterConfig is the file representation of metricsExporter.FilterConfig.
+type fileFilterConfig struct {
+	KeepMetrics []string `yaml:"keep_metrics,omitempty"`
+	DropMetrics []string `yaml:"drop_metrics,omitempty"`
+	KeepLabels  []string `yaml:"keep_labels,omitempty"`
+	DropLabels  []string `yaml:"drop_labels,omitempty"`
+}
+
+var compressions = map[string]metricsExporter.Compression{
+	"snappy": metricsExporter.CompressionSnappy,
+	"gzip":   metricsExporter.CompressionGzip,
+	"zstd":   metricsExporter.CompressionZstd,
+}
+
+var remoteWriteProtocols = map[string]metricsExporter.RemoteWriteProtocol{
+	"v1": metricsExporter.RemoteWrite1,
+	"v2": metricsExporter.RemoteWrite2,
+}
+
+var redirectPolicies = map[string]metricsExporter.RedirectPolicy{
+	"follow": metricsExporter.RedirectFollow,
+	"fail":   metricsExporter.RedirectFail,
+}
+
+var histogramQuantilesModes = map[string]metricsExporter.HistogramQuantilesMode{
+	"disabled":     metricsExporter.HistogramQuantilesDisabled,
+	"with_buckets": metricsExporter.HistogramQuantilesWithBuckets,
+	"only":         metricsExporter.HistogramQuantilesOnly,
+}
+
+var exponentialHistogramModes = map[string]metricsExporter.ExponentialHistogramMode{
+	"buckets": metricsExporter.ExponentialHistogramBuckets,
+	"native":  metricsExporter.ExponentialHistogramNative,
+}
+
+var labelLimitPolicies = map[string]metricsExporter.LabelLimitPolicy{
+	"truncate":    metricsExporter.LabelLimitTruncate,
+	"drop_label":  metricsExporter.LabelLimitDropLabel,
+	"drop_series": metricsExporter.LabelLimitDropSeries,
+}
+
+var labelSources = map[string]metricsExporter.LabelSource{
+	"data_point": metricsExporter.LabelSourceDataPoint,
+	"scope":      metricsExporter.LabelSourceScope,
+	"resource":   metricsExporter.LabelSourceResource,
+}
+
+var instrumentKinds = map[string]metric.InstrumentKind{
+	"counter":                    metric.InstrumentKindCounter,
+	"up_down_counter":            metric.InstrumentKindUpDownCounter,
+	"histogram":                  metric.InstrumentKindHistogram,
+	"gauge":                      metric.InstrumentKindGauge,
+	"observable_counter":         metric.InstrumentKindObservableCounter,
+	"observable_up_down_counter": metric.InstrumentKindObservableUpDownCounter,
+	"observable_gauge":           metric.InstrumentKindObservableGauge,
+}
+
+var temporalities = map[string]metricdata.Temporality{
+	"cumulative": metricdata.CumulativeTemporality,
+	"delta":      metricdata.DeltaTemporality,
+}
+
+var metricTypeOverrides = map[string]metricsExporter.MetricTypeOverride{
+	"counter": metricsExporter.MetricTypeCounter,
+	"gauge":   metricsExporter.MetricTypeGauge,
+}
+
+var oversizedScopePolicies = map[string]metricsExporter.OversizedScopePolicy{
+	"drop_attributes": metricsExporter.OversizedScopeDropAttributes,
+	"drop":            metricsExporter.OversizedScopeDrop,
+}
+
+var exemplarPolicies = map[string]metricsExporter.ExemplarPolicy{
+	"auto":    metricsExporter.ExemplarsAuto,
+	"include": metricsExporter.ExemplarsInclude,
+	"drop":    metricsExporter.ExemplarsDrop,
+}
+
+var duplicateMetricTypePolicies = map[string]metricsExporter.DuplicateMetricTypePolicy{
+	"suffix": metricsExporter.DuplicateMetricTypeSuffix,
+	"drop":   metricsExporter.DuplicateMetricTypeDrop,
+	"error":  metricsExporter.DuplicateMetricTypeError,
+}
+
+var zeroTimestampPolicies = map[string]metricsExporter.ZeroTimestampPolicy{
+	"export_time": metricsExporter.ZeroTimestampExportTime,
+	"drop":        metricsExporter.ZeroTimestampDrop,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command with args and returns its exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 2 || args[0] != "validate" {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+
+	config, err := loadConfig(args[1])
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", args[1], err)
+		return 1
+	}
+	if err := config.Validate(); err != nil {
+		fmt.Fprintf(stderr, "%s: invalid configuration: %v\n", args[1], err)
+		return 1
+	}
+
+	effective, err := yaml.Marshal(toFileConfig(config.Redacted()))
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", args[1], err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "%s", effective)
+	return 0
+}
+
+// loadConfig reads the YAML or JSON file at path into a Config. Unknown fields are rejected, so that misspelled
+// options do not silently fall back to their defaults.
+func loadConfig(path string) (metricsExporter.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+
+	var file fileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return metricsExporter.Config{}, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+	return fromFileConfig(file)
+}
+
+// fromFileConfig returns the Config of a file, resolving the names of the policies and modes.
+func fromFileConfig(file fileConfig) (metricsExporter.Config, error) {
+	compression, err := lookup("compression", file.Compression, compressions)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+	remoteWriteProtocol, err := lookup("remote_write_protocol", file.RemoteWriteProtocol, remoteWriteProtocols)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+	redirectPolicy, err := lookup("redirect_policy", file.RedirectPolicy, redirectPolicies)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+	histogramQuantiles, err := lookup("histogram_quantiles", file.HistogramQuantiles, histogramQuantilesModes)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+	exponentialHistograms, err := lookup("exponential_histograms", file.ExponentialHistograms, exponentialHistogramModes)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+	labelLimitPolicy, err := lookup("label_limit_policy", file.LabelLimitPolicy, labelLimitPolicies)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+	oversizedScopePolicy, err := lookup("oversized_scope_policy", file.OversizedScopePolicy, oversizedScopePolicies)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+	exemplarPolicy, err := lookup("exemplar_policy", file.ExemplarPolicy, exemplarPolicies)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+	duplicateMetricTypePolicy, err := lookup("duplicate_metric_type_policy", file.DuplicateMetricTypePolicy, duplicateMetricTypePolicies)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+	zeroTimestampPolicy, err := lookup("zero_timestamp_policy", file.ZeroTimestampPolicy, zeroTimestampPolicies)
+	if err != nil {
+		return metricsExporter.Config{}, err
+	}
+
+	var labelTrimOrder []metricsExporter.LabelSource
+	for _, source := range file.LabelTrimOrder {
+		labelSource, err := lookupRequired("label_trim_order", source, labelSources)
+		if err != nil {
+			return metricsExporter.Config{}, err
+		}
+		labelTrimOrder = append(labelTrimOrder, labelSource)
+	}
+	var temporality map[metric.InstrumentKind]metricdata.Temporality
+	for kind, value := range file.Temporality {
+		instrumentKind, err := lookupRequired("temporality", kind, instrumentKinds)
+		if err != nil {
+			return metricsExporter.Config{}, err
+		}
+		temporalityValue, err := lookupRequired("temporality."+kind, value, temporalities)
+		if err != nil {
+			return metricsExporter.Config{}, err
+		}
+		if temporality == nil {
+			temporality = map[metric.InstrumentKind]metricdata.Temporality{}
+		}
+		temporality[instrumentKind] = temporalityValue
+	}
+	var overrides map[string]metricsExporter.MetricTypeOverride
+	for metricName, value := range file.MetricTypeOverrides {
+		override, err := lookupRequired("metric_type_overrides."+metricName, value, metricTypeOverrides)
+		if err != nil {
+			return metricsExporter.Config{}, err
+		}
+		if overrides == nil {
+			overrides = map[string]metricsExporter.MetricTypeOverride{}
+		}
+		overrides[metricName] = override
+	}
+
+	var sendWindows []metricsExporter.SendWindow
+	for _, window := range file.SendWindows {
+		sendWindows = append(sendWindows, metricsExporter.SendWindow{Start: window.Start, End: window.End})
+	}
+	var budgets []metricsExporter.Budget
+	for _, budget := range file.Budgets {
+		budgets = append(budgets, metricsExporter.Budget{
+			Name:       budget.Name,
+			Labels:     budget.Labels,
+			MaxSamples: budget.MaxSamples,
+			Interval:   budget.Interval,
+		})
+	}
+	var rollups []metricsExporter.Rollup
+	for _, rollup := range file.Rollups {
+		rollups = append(rollups, metricsExporter.Rollup{Metric: rollup.Metric, Name: rollup.Name, Without: rollup.Without})
+	}
+
+	return metricsExporter.Config{
+		LogzioMetricsListener: file.LogzioMetricsListener,
+		FixListenerPort:       file.FixListenerPort,
+		ListenerQueryParams:   file.ListenerQueryParams,
+		LogzioMetricsToken:    file.LogzioMetricsToken,
+		RemoteTimeout:         file.RemoteTimeout,
+		MaxRequestBodyBytes:   file.MaxRequestBodyBytes,
+		Compression:           compression,
+		RemoteWriteProtocol:   remoteWriteProtocol,
+		Headers:               file.Headers,
+		BatchIDHeader:         file.BatchIDHeader,
+		DialNetwork:           file.DialNetwork,
+		DialFallbackDelay:     file.DialFallbackDelay,
+		RedirectPolicy:        redirectPolicy,
+		Retry: metricsExporter.RetryConfig{
+			MaxAttempts:    file.Retry.MaxAttempts,
+			InitialBackoff: file.Retry.InitialBackoff,
+			MaxBackoff:     file.Retry.MaxBackoff,
+			Jitter:         file.Retry.Jitter,
+		},
+		PushInterval:     file.PushInterval,
+		MinPushInterval:  file.MinPushInterval,
+		PushJitter:       file.PushJitter,
+		MinSendInterval:  file.MinSendInterval,
+		ExportTimeBudget: file.ExportTimeBudget,
+		SplitSlowExports: file.SplitSlowExports,
+		SendWindows:      sendWindows,
+		AsyncQueueSize:   file.AsyncQueueSize,
+		Spool: metricsExporter.SpoolConfig{
+			Dir:       file.Spool.Dir,
+			MaxBytes:  file.Spool.MaxBytes,
+			Retention: file.Spool.Retention,
+		},
+		FallbackAfter:          file.FallbackAfter,
+		JobMode:                file.JobMode,
+		ExportOnStart:          file.ExportOnStart,
+		EphemeralJob:           file.EphemeralJob,
+		EphemeralAttribute:     file.EphemeralAttribute,
+		SlowExportThreshold:    file.SlowExportThreshold,
+		Quantiles:              file.Quantiles,
+		HistogramBoundaries:    file.HistogramBoundaries,
+		MaxHistogramBuckets:    file.MaxHistogramBuckets,
+		HistogramQuantiles:     histogramQuantiles,
+		ExponentialHistograms:  exponentialHistograms,
+		ExternalLabels:         file.ExternalLabels,
+		LabelNamespace:         file.LabelNamespace,
+		UTF8Names:              file.UTF8Names,
+		MaxLabelsPerSeries:     file.MaxLabelsPerSeries,
+		MaxLabelValueLength:    file.MaxLabelValueLength,
+		LabelLimitPolicy:       labelLimitPolicy,
+		LabelTrimOrder:         labelTrimOrder,
+		AddMetricSuffixes:      file.AddMetricSuffixes,
+		InferUnits:             file.InferUnits,
+		Temporality:            temporality,
+		SendMetadata:           file.SendMetadata,
+		MetricTypeOverrides:    overrides,
+		MetricDescriptions:     file.MetricDescriptions,
+		EmitScopeInfo:          file.EmitScopeInfo,
+		MaxScopeAttributeBytes: file.MaxScopeAttributeBytes,
+		OversizedScopePolicy:   oversizedScopePolicy,
+		EmitBuildInfo:          file.EmitBuildInfo,
+		ExemplarTraceIDLabel:   file.ExemplarTraceIDLabel,
+		ExemplarSpanIDLabel:    file.ExemplarSpanIDLabel,
+		ExemplarPolicy:         exemplarPolicy,
+		LowMemory:              file.LowMemory,
+		Strict:                 file.Strict,
+		MaxTrackedSeries:       file.MaxTrackedSeries,
+		Budgets:                budgets,
+		Rollups:                rollups,
+		Filter: metricsExporter.FilterConfig{
+			KeepMetrics: file.Filter.KeepMetrics,
+			DropMetrics: file.Filter.DropMetrics,
+			KeepLabels:  file.Filter.KeepLabels,
+			DropLabels:  file.Filter.DropLabels,
+		},
+		DuplicateMetricTypePolicy: duplicateMetricTypePolicy,
+		ZeroTimestampPolicy:       zeroTimestampPolicy,
+		NonMonotonicSumSuffix:     file.NonMonotonicSumSuffix,
+		UpDownCounterGaugeNames:   file.UpDownCounterGaugeNames,
+		CopyResourceAttributes:    file.CopyResourceAttributes,
+		AddInstanceLabel:          file.AddInstanceLabel,
+		AddEnvLabel:               file.AddEnvLabel,
+		Instance:                  file.Instance,
+	}, nil
+}
+
+// toFileConfig returns the file representation of a Config.
+func toFileConfig(config metricsExporter.Config) fileConfig {
+	var sendWindows []fileSendWindow
+	for _, window := range config.SendWindows {
+		sendWindows = append(sendWindows, fileSendWindow{Start: window.Start, End: window.End})
+	}
+	var labelTrimOrder []string
+	for _, source := range config.LabelTrimOrder {
+		labelTrimOrder = append(labelTrimOrder, name(source, labelSources))
+	}
+	var temporality map[string]string
+	for kind, value := range config.Temporality {
+		if temporality == nil {
+			temporality = map[string]string{}
+		}
+		temporality[name(kind, instrumentKinds)] = name(value, temporalities)
+	}
+	var overrides map[string]string
+	for metricName, value := range config.MetricTypeOverrides {
+		if overrides == nil {
+			overrides = map[string]string{}
+		}
+		overrides[metricName] = name(value, metricTypeOverrides)
+	}
+	var budgets []fileBudget
+	for _, budget := range config.Budgets {
+		budgets = append(budgets, fileBudget{
+			Name:       budget.Name,
+			Labels:     budget.Labels,
+			MaxSamples: budget.MaxSamples,
+			Interval:   budget.Interval,
+		})
+	}
+	var rollups []fileRollup
+	for _, rollup := range config.Rollups {
+		rollups = append(rollups, fileRollup{Metric: rollup.Metric, Name: rollup.Name, Without: rollup.Without})
+	}
+
+	return fileConfig{
+		LogzioMetricsListener: config.LogzioMetricsListener,
+		FixListenerPort:       config.FixListenerPort,
+		ListenerQueryParams:   config.ListenerQueryParams,
+		LogzioMetricsToken:    config.LogzioMetricsToken,
+		RemoteTimeout:         config.RemoteTimeout,
+		MaxRequestBodyBytes:   config.MaxRequestBodyBytes,
+		Compression:           name(config.Compression, compressions),
+		RemoteWriteProtocol:   name(config.RemoteWriteProtocol, remoteWriteProtocols),
+		Headers:               config.Headers,
+		BatchIDHeader:         config.BatchIDHeader,
+		DialNetwork:           config.DialNetwork,
+		DialFallbackDelay:     config.DialFallbackDelay,
+		RedirectPolicy:        name(config.RedirectPolicy, redirectPolicies),
+		Retry: fileRetryConfig{
+			MaxAttempts:    config.Retry.MaxAttempts,
+			InitialBackoff: config.Retry.InitialBackoff,
+			MaxBackoff:     config.Retry.MaxBackoff,
+			Jitter:         config.Retry.Jitter,
+		},
+		PushInterval:     config.PushInterval,
+		MinPushInterval:  config.MinPushInterval,
+		PushJitter:       config.PushJitter,
+		MinSendInterval:  config.MinSendInterval,
+		ExportTimeBudget: config.ExportTimeBudget,
+		SplitSlowExports: config.SplitSlowExports,
+		SendWindows:      sendWindows,
+		AsyncQueueSize:   config.AsyncQueueSize,
+		Spool: fileSpoolConfig{
+			Dir:       config.Spool.Dir,
+			MaxBytes:  config.Spool.MaxBytes,
+			Retention: config.Spool.Retention,
+		},
+		FallbackAfter:          config.FallbackAfter,
+		JobMode:                config.JobMode,
+		ExportOnStart:          config.ExportOnStart,
+		EphemeralJob:           config.EphemeralJob,
+		EphemeralAttribute:     config.EphemeralAttribute,
+		SlowExportThreshold:    config.SlowExportThreshold,
+		Quantiles:              config.Quantiles,
+		HistogramBoundaries:    config.HistogramBoundaries,
+		MaxHistogramBuckets:    config.MaxHistogramBuckets,
+		HistogramQuantiles:     name(config.HistogramQuantiles, histogramQuantilesModes),
+		ExponentialHistograms:  name(config.ExponentialHistograms, exponentialHistogramModes),
+		ExternalLabels:         config.ExternalLabels,
+		LabelNamespace:         config.LabelNamespace,
+		UTF8Names:              config.UTF8Names,
+		MaxLabelsPerSeries:     config.MaxLabelsPerSeries,
+		MaxLabelValueLength:    config.MaxLabelValueLength,
+		LabelLimitPolicy:       name(config.LabelLimitPolicy, labelLimitPolicies),
+		LabelTrimOrder:         labelTrimOrder,
+		AddMetricSuffixes:      config.AddMetricSuffixes,
+		InferUnits:             config.InferUnits,
+		Temporality:            temporality,
+		SendMetadata:           config.SendMetadata,
+		MetricTypeOverrides:    overrides,
+		MetricDescriptions:     config.MetricDescriptions,
+		EmitScopeInfo:          config.EmitScopeInfo,
+		MaxScopeAttributeBytes: config.MaxScopeAttributeBytes,
+		OversizedScopePolicy:   name(config.OversizedScopePolicy, oversizedScopePolicies),
+		EmitBuildInfo:          config.EmitBuildInfo,
+		ExemplarTraceIDLabel:   config.ExemplarTraceIDLabel,
+		ExemplarSpanIDLabel:    config.ExemplarSpanIDLabel,
+		ExemplarPolicy:         name(config.ExemplarPolicy, exemplarPolicies),
+		LowMemory:              config.LowMemory,
+		Strict:                 config.Strict,
+		MaxTrackedSeries:       config.MaxTrackedSeries,
+		Budgets:                budgets,
+		Rollups:                rollups,
+		Filter: fileFilterConfig{
+			KeepMetrics: config.Filter.KeepMetrics,
+			DropMetrics: config.Filter.DropMetrics,
+			KeepLabels:  config.Filter.KeepLabels,
+			DropLabels:  config.Filter.DropLabels,
+		},
+		DuplicateMetricTypePolicy: name(config.DuplicateMetricTypePolicy, duplicateMetricTypePolicies),
+		ZeroTimestampPolicy:       name(config.ZeroTimestampPolicy, zeroTimestampPolicies),
+		NonMonotonicSumSuffix:     config.NonMonotonicSumSuffix,
+		UpDownCounterGaugeNames:   config.UpDownCounterGaugeNames,
+		CopyResourceAttributes:    config.CopyResourceAttributes,
+		AddInstanceLabel:          config.AddInstanceLabel,
+		AddEnvLabel:               config.AddEnvLabel,
+		Instance:                  config.Instance,
+	}
+}
+
+// lookup returns the value named value in values, or the zero value when value is empty.
+func lookup[V comparable](field, value string, values map[string]V) (V, error) {
+	var zero V
+	if value == "" {
+		return zero, nil
+	}
+	if v, ok := values[value]; ok {
+		return v, nil
+	}
+	names := make([]string, 0, len(values))
+	for n := range values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return zero, fmt.Errorf("%s must be one of %s, got %q", field, strings.Join(names, ", "), value)
+}
+
+// lookupRequired returns the value named value in values, which must not be empty.
+func lookupRequired[V comparable](field, value string, values map[string]V) (V, error) {
+	if value == "" {
+		var zero V
+		return zero, fmt.Errorf("%s must not be empty", field)
+	}
+	return lookup(field, value, values)
+}
+
+// name returns the name of value in values.
+func name[V comparable](value V, values map[string]V) string {
+	for n, v := range values {
+		if v == value {
+			return n
+		}
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,263 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// everyOption sets every option of fileConfig except job_mode, which conflicts with async_queue_size, spool
+// and send_windows.
+const everyOption = `logzio_metrics_listener: https://listener.logz.io:8053
+fix_listener_port: true
+listener_query_params:
+  region: us
+logzio_metrics_token: 123456789abc
+remote_timeout: 20s
+max_request_body_bytes: 1048576
+compression: gzip
+remote_write_protocol: v2
+headers:
+  X-Feature: secret
+batch_id_header: X-Batch-ID
+dial_network: tcp4
+dial_fallback_delay: 100ms
+redirect_policy: fail
+retry:
+  max_attempts: 3
+  initial_backoff: 1s
+  max_backoff: 10s
+  jitter: 500ms
+push_interval: 30s
+min_push_interval: 10s
+push_jitter: 2s
+min_send_interval: 1s
+export_time_budget: 0.5
+split_slow_exports: true
+send_windows:
+  - start: 22h
+    end: 6h
+async_queue_size: 4
+spool:
+  dir: /var/spool/metrics
+  max_bytes: 1048576
+  retention: 1h
+fallback_after: 5m
+export_on_start: true
+ephemeral_job: true
+ephemeral_attribute: job.ephemeral
+slow_export_threshold: 5s
+quantiles: [0.5, 0.99]
+histogram_boundaries: [0.1, 1, 10]
+max_histogram_buckets: 8
+histogram_quantiles: with_buckets
+exponential_histograms: native
+external_labels:
+  cluster: prod
+label_namespace: app
+utf8_names: true
+max_labels_per_series: 20
+max_label_value_length: 256
+label_limit_policy: drop_series
+label_trim_order: [resource, scope, data_point]
+add_metric_suffixes: true
+infer_units: true
+temporality:
+  counter: delta
+  observable_gauge: cumulative
+send_metadata: true
+metric_type_overrides:
+  queue_size: gauge
+metric_descriptions:
+  queue_size: Number of jobs waiting in the queue.
+emit_scope_info: true
+max_scope_attribute_bytes: 1024
+oversized_scope_policy: drop
+emit_build_info: true
+exemplar_trace_id_label: trace
+exemplar_span_id_label: span
+exemplar_policy: include
+low_memory: true
+strict: true
+max_tracked_series: 10000
+budgets:
+  - name: team
+    labels:
+      team: payments
+    max_samples: 1000
+    interval: 1m
+rollups:
+  - metric: http_requests_total
+    name: http_requests_by_route_total
+    without: [pod]
+filter:
+  keep_metrics: ["http_.*"]
+  drop_metrics: ["http_debug_.*"]
+  keep_labels: [".*"]
+  drop_labels: ["pod_uid"]
+duplicate_metric_type_policy: error
+zero_timestamp_policy: drop
+non_monotonic_sum_suffix: _sum
+up_down_counter_gauge_names: true
+copy_resource_attributes: false
+add_instance_label: true
+add_env_label: true
+instance: host-1
+`
+
+// codeOnlyOptions are the Config fields that can only be set from code.
+var codeOnlyOptions = map[string]bool{
+	"RequestSigner":          true,
+	"PayloadTransformer":     true,
+	"SharedTransport":        true,
+	"SendErrorHandler":       true,
+	"DeadLetterSink":         true,
+	"FaultInjector":          true,
+	"FallbackLogger":         true,
+	"Logger":                 true,
+	"DryRun":                 true,
+	"TelemetryMeterProvider": true,
+	"OnSlowExports":          true,
+	"SeriesSampler":          true,
+}
+
+// writeConfig writes the configuration file content to a temporary file and returns its path.
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestRunValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		wantCode int
+		want     []string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `logzio_metrics_token: 123456789abc
+push_interval: 30s
+headers:
+  X-Feature: secret
+duplicate_metric_type_policy: drop
+`,
+			wantCode: 0,
+			want: []string{
+				"logzio_metrics_listener: https://listener.logz.io:8053",
+				"********9abc",
+				"X-Feature: ",
+				"******",
+				"push_interval: 30s",
+				"remote_timeout: 30s",
+				"duplicate_metric_type_policy: drop",
+			},
+		},
+		{
+			name:     "json",
+			file:     "config.json",
+			content:  `{"logzio_metrics_token": "123456789abc", "zero_timestamp_policy": "drop"}`,
+			wantCode: 0,
+			want:     []string{"zero_timestamp_policy: drop"},
+		},
+		{
+			name:     "missing token",
+			file:     "config.yaml",
+			content:  "push_interval: 30s\n",
+			wantCode: 1,
+			want:     []string{"invalid configuration: no Logz.io metrics token provided"},
+		},
+		{
+			name:     "unknown field",
+			file:     "config.yaml",
+			content:  "logzio_metrics_token: 123456789abc\npush_intervall: 30s\n",
+			wantCode: 1,
+			want:     []string{"push_intervall"},
+		},
+		{
+			name:     "unknown policy",
+			file:     "config.yaml",
+			content:  "logzio_metrics_token: 123456789abc\nzero_timestamp_policy: ignore\n",
+			wantCode: 1,
+			want:     []string{"zero_timestamp_policy must be one of drop, export_time"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"validate", writeConfig(t, tt.file, tt.content)}, &stdout, &stderr)
+			require.Equal(t, tt.wantCode, code, stderr.String())
+
+			output := stdout.String()
+			if tt.wantCode != 0 {
+				output = stderr.String()
+			}
+			for _, want := range tt.want {
+				assert.Contains(t, output, want)
+			}
+			assert.NotContains(t, stdout.String(), "123456789abc")
+		})
+	}
+}
+
+// TestLoadConfigEveryOption checks that a file can set every Config option that does not need code, and that
+// the options survive the round trip through the file representation.
+func TestLoadConfigEveryOption(t *testing.T) {
+	config, err := loadConfig(writeConfig(t, "config.yaml", everyOption+"job_mode: true\n"))
+	require.NoError(t, err)
+
+	configValue := reflect.ValueOf(config)
+	for i := 0; i < configValue.NumField(); i++ {
+		field := configValue.Type().Field(i)
+		if field.IsExported() && !codeOnlyOptions[field.Name] {
+			assert.False(t, configValue.Field(i).IsZero(), "Config.%s cannot be set from a file", field.Name)
+		}
+	}
+
+	file := toFileConfig(config)
+	fileValue := reflect.ValueOf(file)
+	for i := 0; i < fileValue.NumField(); i++ {
+		assert.False(t, fileValue.Field(i).IsZero(), "fileConfig.%s is lost by toFileConfig", fileValue.Type().Field(i).Name)
+	}
+	roundTrip, err := fromFileConfig(file)
+	require.NoError(t, err)
+	assert.Equal(t, config, roundTrip)
+}
+
+func TestRunValidateEveryOption(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"validate", writeConfig(t, "config.yaml", everyOption)}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "remote_write_protocol: v2")
+	assert.Contains(t, stdout.String(), "label_trim_order:")
+	assert.NotContains(t, stdout.String(), "123456789abc")
+}
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run([]string{"check"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), usage)
+}
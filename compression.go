@@ -0,0 +1,109 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression of the body of the requests.
+type Compression int
+
+const (
+	// CompressionSnappy compresses the requests with Snappy, as the remote write protocol specifies.
+	CompressionSnappy Compression = iota
+	// CompressionGzip compresses the requests with gzip, which produces smaller requests than Snappy at a higher
+	// CPU cost.
+	CompressionGzip
+	// CompressionZstd compresses the requests with zstd, which produces smaller requests than Snappy at a lower
+	// CPU cost than gzip.
+	CompressionZstd
+)
+
+// zstdEncoder returns the encoder of the requests compressed with zstd, which is safe for concurrent use.
+var zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+	return zstd.NewWriter(nil)
+})
+
+// contentEncoding returns the Content-Encoding header of the requests compressed with c.
+func (c Compression) contentEncoding() string {
+	switch c {
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZstd:
+		return "zstd"
+	}
+	return "snappy"
+}
+
+// compress compresses an uncompressed message with c.
+func (c Compression) compress(uncompressed []byte) ([]byte, error) {
+	switch c {
+	case CompressionGzip:
+		return gzipCompress(uncompressed)
+	case CompressionZstd:
+		encoder, err := zstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(uncompressed, nil), nil
+	}
+	return snappy.Encode(nil, uncompressed), nil
+}
+
+// gzipCompress compresses an uncompressed message with gzip.
+func gzipCompress(uncompressed []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(uncompressed); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeMessage returns the body of the requests of a message, with the remote write protocol of the body. Messages
+// are built, queued, spooled and passed to the DeadLetterSink as Snappy-compressed Remote Write 1.0 messages, and
+// are only converted to the configured protocol and compression when they are sent, so that spooled messages can be
+// replayed with any configuration, and messages can be sent again with Remote Write 1.0 when the listener rejects 2.0.
+func (e *Exporter) encodeMessage(message []byte) ([]byte, RemoteWriteProtocol, error) {
+	protocol := e.protocol()
+	if protocol == RemoteWrite1 && e.config.Compression == CompressionSnappy {
+		return message, protocol, nil
+	}
+
+	uncompressed, err := snappy.Decode(nil, message)
+	if err != nil {
+		return nil, protocol, err
+	}
+	if protocol == RemoteWrite2 {
+		converted, err := e.toRemoteWrite2(uncompressed)
+		if err != nil {
+			e.logger().Printf("Logz.io metrics exporter: failed to convert a message to Remote Write 2.0, sending it with Remote Write 1.0: %v", err)
+			protocol = RemoteWrite1
+		} else {
+			uncompressed = converted
+		}
+	}
+	body, err := e.config.Compression.compress(uncompressed)
+	return body, protocol, err
+}
@@ -0,0 +1,101 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionGzip(t *testing.T) {
+	var received prompb.WriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "gzip", req.Header.Get("Content-Encoding"))
+		reader, err := gzip.NewReader(req.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, received.Unmarshal(body))
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a", Compression: CompressionGzip})
+	require.NoError(t, err)
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+
+	require.Len(t, received.Timeseries, 1)
+	assert.Equal(t, 5.0, received.Timeseries[0].Samples[0].Value)
+}
+
+func TestCompressionZstd(t *testing.T) {
+	var received prompb.WriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "zstd", req.Header.Get("Content-Encoding"))
+		reader, err := zstd.NewReader(req.Body)
+		require.NoError(t, err)
+		defer reader.Close()
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, received.Unmarshal(body))
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a", Compression: CompressionZstd})
+	require.NoError(t, err)
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+
+	require.Len(t, received.Timeseries, 1)
+	assert.Equal(t, 5.0, received.Timeseries[0].Samples[0].Value)
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	message := []byte("uncompressed message")
+
+	compressed, err := CompressionSnappy.compress(message)
+	require.NoError(t, err)
+	decoded, err := snappy.Decode(nil, compressed)
+	require.NoError(t, err)
+	assert.Equal(t, message, decoded)
+
+	compressed, err = CompressionGzip.compress(message)
+	require.NoError(t, err)
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	decoded, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, message, decoded)
+
+	compressed, err = CompressionZstd.compress(message)
+	require.NoError(t, err)
+	decoder, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer decoder.Close()
+	decoded, err = decoder.DecodeAll(compressed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, message, decoded)
+}
@@ -15,30 +15,278 @@
 package metrics_exporter
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	m "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const (
+	// DefaultListener is the Logz.io metrics listener used when LogzioMetricsListener is not set.
+	DefaultListener = "https://listener.logz.io:8053"
+	// DefaultRemoteTimeout is the RemoteTimeout used when it is not set.
+	DefaultRemoteTimeout = 30 * time.Second
+	// DefaultPushInterval is the PushInterval used when it is not set.
+	DefaultPushInterval = 10 * time.Second
+	// DefaultMinPushInterval is the lowest PushInterval allowed when MinPushInterval is not set.
+	DefaultMinPushInterval = 100 * time.Millisecond
+)
+
+// DefaultQuantiles are the Quantiles used when they are not set. Changing them changes the quantiles of the
+// configs validated afterwards.
+var DefaultQuantiles = []float64{0.5, 0.9, 0.95, 0.99}
+
+const (
+	// metricExportIntervalEnv is the standard OpenTelemetry environment variable of the export interval.
+	metricExportIntervalEnv = "OTEL_METRIC_EXPORT_INTERVAL"
+	// metricExportTimeoutEnv is the standard OpenTelemetry environment variable of the export timeout.
+	metricExportTimeoutEnv = "OTEL_METRIC_EXPORT_TIMEOUT"
 )
 
 var (
 	// ErrNoLogzioMetricsToken occurs when no Logz.io metrics token was provided for authorization.
 	ErrNoLogzioMetricsToken = fmt.Errorf("no Logz.io metrics token provided")
 
+	// ErrInvalidLogzioMetricsListener occurs when the Logz.io metrics listener is not an absolute http or https URL.
+	ErrInvalidLogzioMetricsListener = fmt.Errorf("logz.io metrics listener must be an absolute http or https URL")
+
+	// ErrWrongListenerPort occurs when a Logz.io listener URL does not use the metrics listener port of its scheme,
+	// e.g. when the logs listener URL is used.
+	ErrWrongListenerPort = fmt.Errorf("logz.io metrics listener must use port 8053 with https or port 8052 with http")
+
+	// ErrInvalidCompression occurs when the compression is neither Snappy, gzip nor zstd.
+	ErrInvalidCompression = fmt.Errorf("compression must be CompressionSnappy, CompressionGzip or CompressionZstd")
+
+	// ErrInvalidMaxRequestBodyBytes occurs when the maximum request body size is negative.
+	ErrInvalidMaxRequestBodyBytes = fmt.Errorf("max request body bytes cannot be negative")
+
+	// ErrInvalidDialNetwork occurs when the dial network is not tcp, tcp4 or tcp6.
+	ErrInvalidDialNetwork = fmt.Errorf("dial network must be tcp, tcp4 or tcp6")
+
 	// ErrInvalidQuantiles occurs when the supplied quantiles are not between 0 and 1.
 	ErrInvalidQuantiles = fmt.Errorf("cannot have quantiles that are less than 0 or greater than 1")
+
+	// ErrInvalidHistogramBoundaries occurs when the histogram boundaries are not strictly increasing.
+	ErrInvalidHistogramBoundaries = fmt.Errorf("histogram boundaries must be strictly increasing")
+
+	// ErrInvalidTemporality occurs when a configured temporality is neither cumulative nor delta.
+	ErrInvalidTemporality = fmt.Errorf("temporality must be cumulative or delta")
+
+	// ErrInvalidMetricTypeOverride occurs when a metric type override is neither a counter nor a gauge.
+	ErrInvalidMetricTypeOverride = fmt.Errorf("metric type overrides must be MetricTypeCounter or MetricTypeGauge")
+
+	// ErrInvalidPushInterval occurs when the push interval is below the minimum push interval.
+	ErrInvalidPushInterval = fmt.Errorf("push interval cannot be below the minimum push interval")
+
+	// ErrInvalidMinPushInterval occurs when the minimum push interval is negative.
+	ErrInvalidMinPushInterval = fmt.Errorf("minimum push interval cannot be negative")
+
+	// ErrInvalidPushJitter occurs when the push jitter is negative.
+	ErrInvalidPushJitter = fmt.Errorf("push jitter cannot be negative")
+
+	// ErrInvalidRetry occurs when the retry attempts, backoffs or jitter are negative, or the initial backoff
+	// exceeds the max backoff.
+	ErrInvalidRetry = fmt.Errorf("retry attempts, backoffs and jitter cannot be negative, and the initial backoff cannot exceed the max backoff")
+
+	// ErrInvalidSpool occurs when the spool size or retention is negative, or either is set without a directory.
+	ErrInvalidSpool = fmt.Errorf("spool max bytes and retention cannot be negative, and require a spool directory")
+
+	// ErrInvalidJobMode occurs when job mode is combined with options that defer or skip sending.
+	ErrInvalidJobMode = fmt.Errorf("job mode sends synchronously and cannot be used with an async queue, a spool or send windows")
+
+	// ErrInvalidSendWindow occurs when a send window is empty or not within a day.
+	ErrInvalidSendWindow = fmt.Errorf("send windows must have different start and end offsets between 0 and 24h")
+
+	// ErrInvalidExportTimeBudget occurs when the export time budget is not a fraction of the push interval.
+	ErrInvalidExportTimeBudget = fmt.Errorf("export time budget must be between 0 and 1")
+
+	// ErrExportTimeBudgetExceeded occurs when the series left to send once an export exceeded its time budget
+	// are not sent.
+	ErrExportTimeBudgetExceeded = fmt.Errorf("export time budget exceeded")
+
+	// ErrInvalidAsyncQueueSize occurs when the async queue size is negative.
+	ErrInvalidAsyncQueueSize = fmt.Errorf("async queue size cannot be negative")
+
+	// ErrInvalidMaxLabelsPerSeries occurs when the maximum number of labels per series is negative.
+	ErrInvalidMaxLabelsPerSeries = fmt.Errorf("max labels per series cannot be negative")
+
+	// ErrInvalidMaxLabelValueLength occurs when the maximum length of label values is negative.
+	ErrInvalidMaxLabelValueLength = fmt.Errorf("max label value length cannot be negative")
+
+	// ErrInvalidLabelNamespace occurs when the label namespace is not a valid label name or starts with "__",
+	// which is reserved for internal labels.
+	ErrInvalidLabelNamespace = fmt.Errorf("label namespace must be a valid label name not starting with __")
+
+	// ErrInvalidLabelTrimOrder occurs when the label trim order contains an unknown label source.
+	ErrInvalidLabelTrimOrder = fmt.Errorf("label trim order must only contain data point, scope, and resource label sources")
+
+	// ErrSpecViolation occurs in strict mode when a metric has an invalid name, colliding labels, NaN values,
+	// or out-of-order samples.
+	ErrSpecViolation = fmt.Errorf("metric violates the Prometheus specification")
+
+	// ErrInvalidFallbackAfter occurs when the outage duration after which messages are logged is negative.
+	ErrInvalidFallbackAfter = fmt.Errorf("fallback after cannot be negative")
+
+	// ErrInvalidMaxTrackedSeries occurs when the maximum number of series the exporter keeps state for is negative.
+	ErrInvalidMaxTrackedSeries = fmt.Errorf("max tracked series cannot be negative")
+
+	// ErrInvalidBudget occurs when a budget has no name or labels, a duplicate name, a non-positive sample limit,
+	// or a negative interval.
+	ErrInvalidBudget = fmt.Errorf("budgets must have a unique name, labels, positive max samples and a non-negative interval")
+
+	// ErrInvalidRollup occurs when a rollup has an invalid metric or name, the same metric and name, or no labels to
+	// aggregate over, or aggregates over the __name__, le or quantile labels.
+	ErrInvalidRollup = fmt.Errorf("rollups must have a valid metric and a different valid name, and labels to aggregate over other than __name__, le and quantile")
+
+	// ErrInvalidMaxScopeAttributeBytes occurs when the maximum size of the scope attributes is negative.
+	ErrInvalidMaxScopeAttributeBytes = fmt.Errorf("max scope attribute bytes cannot be negative")
+
+	// ErrInvalidFilter occurs when a filter rule is not a valid regular expression.
+	ErrInvalidFilter = fmt.Errorf("filter rules must be valid regular expressions")
+
+	// ErrInvalidMaxHistogramBuckets occurs when the maximum histogram bucket count is negative or too low to keep +Inf.
+	ErrInvalidMaxHistogramBuckets = fmt.Errorf("max histogram buckets must be 0 (unlimited) or at least 2")
+
+	// ErrDeltaExponentialHistogram occurs when an exponential histogram with delta temporality is exported.
+	ErrDeltaExponentialHistogram = fmt.Errorf("exponential histograms with delta temporality are not supported, use cumulative temporality")
+
+	// ErrNoManualReader occurs when metrics are collected on demand before Exporter.NewManualReader was called.
+	ErrNoManualReader = fmt.Errorf("no manual reader to collect metrics from, call Exporter.NewManualReader")
+)
+
+// HistogramQuantilesMode controls whether quantiles computed from histogram buckets are exported.
+type HistogramQuantilesMode int
+
+const (
+	// HistogramQuantilesDisabled exports histograms as buckets only.
+	HistogramQuantilesDisabled HistogramQuantilesMode = iota
+	// HistogramQuantilesWithBuckets exports the computed quantiles alongside the buckets.
+	HistogramQuantilesWithBuckets
+	// HistogramQuantilesOnly exports the computed quantiles instead of the buckets.
+	HistogramQuantilesOnly
+)
+
+// DuplicateMetricTypePolicy controls how a metric is exported when its name was already exported in the same
+// batch with a different metric type.
+type DuplicateMetricTypePolicy int
+
+const (
+	// DuplicateMetricTypeSuffix exports the later metric with its type appended to the name, e.g. "_gauge".
+	DuplicateMetricTypeSuffix DuplicateMetricTypePolicy = iota
+	// DuplicateMetricTypeDrop drops the later metric.
+	DuplicateMetricTypeDrop
+	// DuplicateMetricTypeError drops the later metric and reports an export error.
+	DuplicateMetricTypeError
+)
+
+// ZeroTimestampPolicy controls how samples without a timestamp, i.e. with a zero or Unix epoch time, are exported.
+type ZeroTimestampPolicy int
+
+const (
+	// ZeroTimestampExportTime exports the samples with the time of the export.
+	ZeroTimestampExportTime ZeroTimestampPolicy = iota
+	// ZeroTimestampDrop drops the samples.
+	ZeroTimestampDrop
 )
 
 // Config contains properties the Exporter uses to export metrics data to Logz.io.
 type Config struct {
-	LogzioMetricsListener string
-	LogzioMetricsToken    string
-	RemoteTimeout         time.Duration
-	PushInterval          time.Duration
-	Quantiles             []float64
-	HistogramBoundaries   []float64
-	ExternalLabels        map[string]string
-	AddMetricSuffixes     bool
-	client                *http.Client
+	LogzioMetricsListener     string
+	FixListenerPort           bool
+	ListenerQueryParams       map[string]string
+	LogzioMetricsToken        string
+	RemoteTimeout             time.Duration
+	MaxRequestBodyBytes       int
+	Compression               Compression
+	RemoteWriteProtocol       RemoteWriteProtocol
+	Headers                   map[string]string
+	BatchIDHeader             string
+	RequestSigner             RequestSigner
+	PayloadTransformer        PayloadTransformer
+	SharedTransport           *SharedTransport
+	DialNetwork               string
+	DialFallbackDelay         time.Duration
+	RedirectPolicy            RedirectPolicy
+	Retry                     RetryConfig
+	PushInterval              time.Duration
+	MinPushInterval           time.Duration
+	PushJitter                time.Duration
+	MinSendInterval           time.Duration
+	ExportTimeBudget          float64
+	SplitSlowExports          bool
+	SendWindows               []SendWindow
+	AsyncQueueSize            int
+	SendErrorHandler          func(SendError)
+	DeadLetterSink            DeadLetterSink
+	Spool                     SpoolConfig
+	FaultInjector             FaultInjector
+	FallbackAfter             time.Duration
+	FallbackLogger            *log.Logger
+	JobMode                   bool
+	ExportOnStart             bool
+	EphemeralJob              bool
+	EphemeralAttribute        string
+	Logger                    *log.Logger
+	DryRun                    io.Writer
+	TelemetryMeterProvider    m.MeterProvider
+	SlowExportThreshold       time.Duration
+	OnSlowExports             func(SendLatency)
+	Quantiles                 []float64
+	HistogramBoundaries       []float64
+	MaxHistogramBuckets       int
+	HistogramQuantiles        HistogramQuantilesMode
+	ExponentialHistograms     ExponentialHistogramMode
+	ExternalLabels            map[string]string
+	LabelNamespace            string
+	UTF8Names                 bool
+	MaxLabelsPerSeries        int
+	MaxLabelValueLength       int
+	LabelLimitPolicy          LabelLimitPolicy
+	LabelTrimOrder            []LabelSource
+	AddMetricSuffixes         bool
+	InferUnits                bool
+	Temporality               map[metric.InstrumentKind]metricdata.Temporality
+	SendMetadata              bool
+	MetricTypeOverrides       map[string]MetricTypeOverride
+	MetricDescriptions        map[string]string
+	EmitScopeInfo             bool
+	MaxScopeAttributeBytes    int
+	OversizedScopePolicy      OversizedScopePolicy
+	EmitBuildInfo             bool
+	ExemplarTraceIDLabel      string
+	ExemplarSpanIDLabel       string
+	ExemplarPolicy            ExemplarPolicy
+	LowMemory                 bool
+	Strict                    bool
+	MaxTrackedSeries          int
+	Budgets                   []Budget
+	Rollups                   []Rollup
+	SeriesSampler             SeriesSampler
+	Filter                    FilterConfig
+	DuplicateMetricTypePolicy DuplicateMetricTypePolicy
+	ZeroTimestampPolicy       ZeroTimestampPolicy
+	NonMonotonicSumSuffix     string
+	UpDownCounterGaugeNames   bool
+	// CopyResourceAttributes defaults to true when nil.
+	CopyResourceAttributes *bool
+	AddInstanceLabel       bool
+	AddEnvLabel            bool
+	Instance               string
+	client                 *http.Client
 }
 
 // Validate checks a Config struct for missing required properties and property conflicts.
@@ -49,6 +297,21 @@ func (c *Config) Validate() error {
 		return ErrNoLogzioMetricsToken
 	}
 
+	if c.MaxRequestBodyBytes < 0 {
+		return ErrInvalidMaxRequestBodyBytes
+	}
+	if c.Compression < CompressionSnappy || c.Compression > CompressionZstd {
+		return ErrInvalidCompression
+	}
+	if c.MaxScopeAttributeBytes < 0 {
+		return ErrInvalidMaxScopeAttributeBytes
+	}
+	switch c.DialNetwork {
+	case "", "tcp", "tcp4", "tcp6":
+	default:
+		return ErrInvalidDialNetwork
+	}
+
 	// Verify that provided quantiles are between 0 and 1.
 	if c.Quantiles != nil {
 		for _, quantile := range c.Quantiles {
@@ -58,20 +321,357 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for i := 1; i < len(c.HistogramBoundaries); i++ {
+		if c.HistogramBoundaries[i] <= c.HistogramBoundaries[i-1] {
+			return ErrInvalidHistogramBoundaries
+		}
+	}
+
+	for _, temporality := range c.Temporality {
+		if temporality != metricdata.CumulativeTemporality && temporality != metricdata.DeltaTemporality {
+			return ErrInvalidTemporality
+		}
+	}
+	for _, override := range c.MetricTypeOverrides {
+		if override != MetricTypeCounter && override != MetricTypeGauge {
+			return ErrInvalidMetricTypeOverride
+		}
+	}
+
+	if c.MinPushInterval < 0 {
+		return ErrInvalidMinPushInterval
+	}
+	// A zero push interval is replaced by the default below.
+	if c.PushInterval != 0 && c.PushInterval < c.minPushInterval() {
+		return ErrInvalidPushInterval
+	}
+
+	if c.PushJitter < 0 {
+		return ErrInvalidPushJitter
+	}
+
+	if c.Retry.MaxAttempts < 0 || c.Retry.InitialBackoff < 0 || c.Retry.MaxBackoff < 0 || c.Retry.Jitter < 0 ||
+		(c.Retry.MaxBackoff > 0 && c.Retry.InitialBackoff > c.Retry.MaxBackoff) {
+		return ErrInvalidRetry
+	}
+
+	if c.Spool.MaxBytes < 0 || c.Spool.Retention < 0 || (c.Spool.Dir == "" && (c.Spool.MaxBytes > 0 || c.Spool.Retention > 0)) {
+		return ErrInvalidSpool
+	}
+	if c.JobMode && (c.AsyncQueueSize > 0 || c.Spool.Dir != "" || len(c.SendWindows) > 0) {
+		return ErrInvalidJobMode
+	}
+
+	for _, window := range c.SendWindows {
+		if window.Start == window.End || window.Start < 0 || window.End < 0 ||
+			window.Start >= 24*time.Hour || window.End > 24*time.Hour {
+			return ErrInvalidSendWindow
+		}
+	}
+
+	if c.FallbackAfter < 0 {
+		return ErrInvalidFallbackAfter
+	}
+
+	if c.ExportTimeBudget < 0 || c.ExportTimeBudget > 1 {
+		return ErrInvalidExportTimeBudget
+	}
+
+	if c.AsyncQueueSize < 0 {
+		return ErrInvalidAsyncQueueSize
+	}
+
+	// A histogram needs at least one finite bucket in addition to the +Inf bucket.
+	if c.MaxHistogramBuckets < 0 || c.MaxHistogramBuckets == 1 {
+		return ErrInvalidMaxHistogramBuckets
+	}
+
+	if c.MaxTrackedSeries < 0 {
+		return ErrInvalidMaxTrackedSeries
+	}
+
+	if c.MaxLabelsPerSeries < 0 {
+		return ErrInvalidMaxLabelsPerSeries
+	}
+	if c.MaxLabelValueLength < 0 {
+		return ErrInvalidMaxLabelValueLength
+	}
+
+	if c.LabelNamespace != "" && (!IsValidLabelName(c.LabelNamespace) || strings.HasPrefix(c.LabelNamespace, "__")) {
+		return ErrInvalidLabelNamespace
+	}
+
+	budgetNames := map[string]bool{}
+	for _, budget := range c.Budgets {
+		if budget.Name == "" || budgetNames[budget.Name] || len(budget.Labels) == 0 || budget.MaxSamples <= 0 || budget.Interval < 0 {
+			return ErrInvalidBudget
+		}
+		budgetNames[budget.Name] = true
+	}
+	for _, rollup := range c.Rollups {
+		dropsKeptLabel := slices.ContainsFunc(rollup.Without, func(name string) bool {
+			return slices.Contains(rollupKeptLabelNames, name)
+		})
+		if !IsValidMetricName(rollup.Metric) || !IsValidMetricName(rollup.Name) || rollup.Metric == rollup.Name ||
+			len(rollup.Without) == 0 || dropsKeptLabel {
+			return ErrInvalidRollup
+		}
+	}
+	if _, err := c.Filter.compile(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	for _, source := range c.LabelTrimOrder {
+		if source < LabelSourceDataPoint || source > LabelSourceResource {
+			return ErrInvalidLabelTrimOrder
+		}
+	}
+
 	// Add default values for missing properties.
 	if c.LogzioMetricsListener == "" {
-		c.LogzioMetricsListener = "https://listener.logz.io:8053"
+		c.LogzioMetricsListener = DefaultListener
+	}
+	if _, err := c.listenerURL(); err != nil {
+		return err
 	}
+	listener, err := checkListenerPort(c.LogzioMetricsListener, c.FixListenerPort)
+	if err != nil {
+		return err
+	}
+	c.LogzioMetricsListener = listener
+	// The standard OpenTelemetry environment variables take precedence over the defaults, so the exporter is
+	// tuned like the other exporters of the process.
 	if c.RemoteTimeout == 0 {
-		c.RemoteTimeout = 30 * time.Second
+		c.RemoteTimeout = envMilliseconds(metricExportTimeoutEnv, 0, DefaultRemoteTimeout)
 	}
 	// Default time interval between pushes for the push controller is 10s.
 	if c.PushInterval == 0 {
-		c.PushInterval = 10 * time.Second
+		c.PushInterval = envMilliseconds(metricExportIntervalEnv, c.minPushInterval(), DefaultPushInterval)
 	}
 	if c.Quantiles == nil {
-		c.Quantiles = []float64{0.5, 0.9, 0.95, 0.99}
+		c.Quantiles = slices.Clone(DefaultQuantiles)
+	}
+	if c.JobMode && c.Retry.MaxAttempts == 0 {
+		c.Retry.MaxAttempts = DefaultJobRetryAttempts
+	}
+	if c.AddInstanceLabel && c.Instance == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to get hostname for the instance label: %w", err)
+		}
+		c.Instance = hostname
+	}
+	if c.AddEnvLabel {
+		c.addEnvLabel()
 	}
 
 	return nil
 }
+
+// listenerURL returns the Logz.io metrics listener URL with the ListenerQueryParams added to its query. The path
+// and query of the configured listener are kept.
+func (c *Config) listenerURL() (string, error) {
+	listener, err := url.Parse(c.LogzioMetricsListener)
+	if err != nil || (listener.Scheme != "http" && listener.Scheme != "https") || listener.Host == "" {
+		return "", ErrInvalidLogzioMetricsListener
+	}
+	if len(c.ListenerQueryParams) == 0 {
+		return c.LogzioMetricsListener, nil
+	}
+
+	query := listener.Query()
+	for name, value := range c.ListenerQueryParams {
+		query.Set(name, value)
+	}
+	listener.RawQuery = query.Encode()
+	return listener.String(), nil
+}
+
+// transport returns the transport of the requests to Logz.io. It dials DialNetwork with DialFallbackDelay when
+// either is set, and is http.DefaultTransport otherwise.
+func (c *Config) transport() http.RoundTripper {
+	if c.DialNetwork == "" && c.DialFallbackDelay == 0 {
+		return http.DefaultTransport
+	}
+
+	network := c.DialNetwork
+	if network == "" {
+		network = "tcp"
+	}
+	dialer := &net.Dialer{
+		Timeout:       30 * time.Second,
+		KeepAlive:     30 * time.Second,
+		FallbackDelay: c.DialFallbackDelay,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return transport
+}
+
+// envMilliseconds returns the duration in milliseconds of the environment variable, or the default when the
+// variable is not set, is not a positive integer, or is below minimum.
+func envMilliseconds(name string, minimum time.Duration, defaultValue time.Duration) time.Duration {
+	milliseconds, err := strconv.ParseInt(strings.TrimSpace(os.Getenv(name)), 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	duration := time.Duration(milliseconds) * time.Millisecond
+	if duration <= 0 || duration < minimum {
+		return defaultValue
+	}
+	return duration
+}
+
+// addEnvLabel adds an env label to the ExternalLabels from the ENV or DEPLOY_ENV environment variables, or the
+// deployment.environment attribute of OTEL_RESOURCE_ATTRIBUTES. An existing env label is kept.
+func (c *Config) addEnvLabel() {
+	name := namespacedLabelName(c.LabelNamespace, envLabelName)
+	if _, ok := c.ExternalLabels[name]; ok {
+		return
+	}
+
+	env := os.Getenv("ENV")
+	if env == "" {
+		env = os.Getenv("DEPLOY_ENV")
+	}
+	if env == "" {
+		for _, attr := range strings.Split(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"), ",") {
+			key, value, _ := strings.Cut(attr, "=")
+			key = strings.TrimSpace(key)
+			if key == "deployment.environment" || key == "deployment.environment.name" {
+				env = strings.TrimSpace(value)
+				break
+			}
+		}
+	}
+	if env == "" {
+		return
+	}
+
+	labels := maps.Clone(c.ExternalLabels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[name] = env
+	c.ExternalLabels = labels
+}
+
+// copyResourceAttributes reports whether resource attributes should be attached as labels.
+func (c *Config) copyResourceAttributes() bool {
+	return c.CopyResourceAttributes == nil || *c.CopyResourceAttributes
+}
+
+// labelTrimOrder returns the order labels are trimmed in when a series has more than MaxLabelsPerSeries labels.
+func (c *Config) labelTrimOrder() []LabelSource {
+	if c.LabelTrimOrder == nil {
+		return defaultLabelTrimOrder
+	}
+	return c.LabelTrimOrder
+}
+
+// exemplarLabelNames returns the exemplar label names for the trace and span IDs, trace_id and span_id by default.
+func (c *Config) exemplarLabelNames() exemplarLabelNames {
+	names := exemplarLabelNames{traceID: traceIdLabelName, spanID: spanIdLabelName}
+	if c.ExemplarTraceIDLabel != "" {
+		names.traceID = c.ExemplarTraceIDLabel
+	}
+	if c.ExemplarSpanIDLabel != "" {
+		names.spanID = c.ExemplarSpanIDLabel
+	}
+	return names
+}
+
+// minPushInterval returns the lowest PushInterval allowed.
+func (c *Config) minPushInterval() time.Duration {
+	if c.MinPushInterval == 0 {
+		return DefaultMinPushInterval
+	}
+	return c.MinPushInterval
+}
+
+// exportTimeBudget returns the time an export may take before it is considered slow, or 0 when there is no budget.
+func (c *Config) exportTimeBudget() time.Duration {
+	return time.Duration(c.ExportTimeBudget * float64(c.PushInterval))
+}
+
+// jitteredPushInterval returns the push interval with a random duration up to PushJitter added.
+func (c *Config) jitteredPushInterval() time.Duration {
+	if c.PushJitter <= 0 {
+		return c.PushInterval
+	}
+	return c.PushInterval + rand.N(c.PushJitter)
+}
+
+// Redacted returns a copy of the config that is safe to log: the token is masked except for its last 4
+// characters, and the header values and the secret of an HMACRequestSigner or *HMACRequestSigner are masked.
+// The copy does not share its maps and slices with the config, and cannot be used to export.
+func (c Config) Redacted() Config {
+	redacted := c
+	redacted.LogzioMetricsToken = maskSecret(c.LogzioMetricsToken, 4)
+	redacted.ListenerQueryParams = maps.Clone(c.ListenerQueryParams)
+	if c.Headers != nil {
+		redacted.Headers = make(map[string]string, len(c.Headers))
+		for header, value := range c.Headers {
+			redacted.Headers[header] = maskSecret(value, 0)
+		}
+	}
+	switch signer := c.RequestSigner.(type) {
+	case HMACRequestSigner:
+		signer.Secret = nil
+		redacted.RequestSigner = signer
+	case *HMACRequestSigner:
+		// Copy the signer, so the secret of the caller's signer is kept.
+		if signer != nil {
+			redactedSigner := *signer
+			redactedSigner.Secret = nil
+			redacted.RequestSigner = &redactedSigner
+		}
+	}
+	redacted.SendWindows = slices.Clone(c.SendWindows)
+	redacted.Quantiles = slices.Clone(c.Quantiles)
+	redacted.HistogramBoundaries = slices.Clone(c.HistogramBoundaries)
+	redacted.ExternalLabels = maps.Clone(c.ExternalLabels)
+	redacted.LabelTrimOrder = slices.Clone(c.LabelTrimOrder)
+	redacted.Temporality = maps.Clone(c.Temporality)
+	redacted.MetricTypeOverrides = maps.Clone(c.MetricTypeOverrides)
+	redacted.MetricDescriptions = maps.Clone(c.MetricDescriptions)
+	redacted.Budgets = slices.Clone(c.Budgets)
+	for i := range redacted.Budgets {
+		redacted.Budgets[i].Labels = maps.Clone(redacted.Budgets[i].Labels)
+	}
+	redacted.Rollups = slices.Clone(c.Rollups)
+	for i := range redacted.Rollups {
+		redacted.Rollups[i].Without = slices.Clone(redacted.Rollups[i].Without)
+	}
+	redacted.Filter = FilterConfig{
+		KeepMetrics: slices.Clone(c.Filter.KeepMetrics),
+		DropMetrics: slices.Clone(c.Filter.DropMetrics),
+		KeepLabels:  slices.Clone(c.Filter.KeepLabels),
+		DropLabels:  slices.Clone(c.Filter.DropLabels),
+	}
+	if c.CopyResourceAttributes != nil {
+		copyResourceAttributes := *c.CopyResourceAttributes
+		redacted.CopyResourceAttributes = &copyResourceAttributes
+	}
+	redacted.client = nil
+	return redacted
+}
+
+// maskSecret replaces all but the last visible characters of a secret with asterisks, or all of them when the
+// secret is not longer than twice visible.
+func maskSecret(secret string, visible int) string {
+	if len(secret) <= 2*visible {
+		visible = 0
+	}
+	return strings.Repeat("*", len(secret)-visible) + secret[len(secret)-visible:]
+}
+
+// EffectiveConfig returns a redacted copy of the configuration the exporter uses, including the defaults and
+// environment variables applied by Validate, so operators can verify what a running service uses.
+func (e *Exporter) EffectiveConfig() Config {
+	// The client of the config is created by the first request, under clientMu.
+	e.clientMu.Lock()
+	defer e.clientMu.Unlock()
+	return e.config.Redacted()
+}
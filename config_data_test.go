@@ -102,3 +102,12 @@ var exampleValidQuantilesConfig = metricsExporter.Config{
 	PushInterval:          10 * time.Second,
 	Quantiles:             []float64{0, 0.5, 1},
 }
+
+// Example Config struct with an invalid max histogram buckets value.
+var exampleInvalidMaxHistogramBucketsConfig = metricsExporter.Config{
+	LogzioMetricsListener: "https://listener.logz.io:8053",
+	LogzioMetricsToken:    "123456789a",
+	RemoteTimeout:         30 * time.Second,
+	PushInterval:          10 * time.Second,
+	MaxHistogramBuckets:   1,
+}
@@ -16,6 +16,7 @@ package metrics_exporter_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -73,6 +74,12 @@ func TestValidate(t *testing.T) {
 			expectedConfig: &validatedQuantilesConfig,
 			expectedError:  nil,
 		},
+		{
+			testName:       "Config with Invalid Max Histogram Buckets",
+			config:         &exampleInvalidMaxHistogramBucketsConfig,
+			expectedConfig: nil,
+			expectedError:  metricsExporter.ErrInvalidMaxHistogramBuckets,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
@@ -84,3 +91,352 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+// TestValidatePushInterval checks that sub-second push intervals are accepted down to the minimum push interval.
+func TestValidatePushInterval(t *testing.T) {
+	tests := []struct {
+		testName      string
+		config        metricsExporter.Config
+		expectedError error
+	}{
+		{
+			testName: "Sub-second Push Interval",
+			config:   metricsExporter.Config{LogzioMetricsToken: "123456789a", PushInterval: 250 * time.Millisecond},
+		},
+		{
+			testName:      "Push Interval below the default minimum",
+			config:        metricsExporter.Config{LogzioMetricsToken: "123456789a", PushInterval: 50 * time.Millisecond},
+			expectedError: metricsExporter.ErrInvalidPushInterval,
+		},
+		{
+			testName: "Push Interval above a custom minimum",
+			config: metricsExporter.Config{
+				LogzioMetricsToken: "123456789a",
+				PushInterval:       50 * time.Millisecond,
+				MinPushInterval:    10 * time.Millisecond,
+			},
+		},
+		{
+			testName: "Push Interval below a custom minimum",
+			config: metricsExporter.Config{
+				LogzioMetricsToken: "123456789a",
+				PushInterval:       time.Second,
+				MinPushInterval:    5 * time.Second,
+			},
+			expectedError: metricsExporter.ErrInvalidPushInterval,
+		},
+		{
+			testName:      "Negative Push Interval",
+			config:        metricsExporter.Config{LogzioMetricsToken: "123456789a", PushInterval: -time.Second},
+			expectedError: metricsExporter.ErrInvalidPushInterval,
+		},
+		{
+			testName:      "Negative Min Push Interval",
+			config:        metricsExporter.Config{LogzioMetricsToken: "123456789a", MinPushInterval: -time.Second},
+			expectedError: metricsExporter.ErrInvalidMinPushInterval,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			require.Equal(t, test.expectedError, test.config.Validate())
+		})
+	}
+}
+
+// TestValidateLogzioMetricsListener checks that the listener must be an absolute http or https URL.
+func TestValidateLogzioMetricsListener(t *testing.T) {
+	tests := []struct {
+		listener      string
+		expectedError error
+	}{
+		{listener: "https://listener.logz.io:8053/api/v1/write?region=eu"},
+		{listener: "http://localhost:8053"},
+		{listener: "listener.logz.io:8053", expectedError: metricsExporter.ErrInvalidLogzioMetricsListener},
+		{listener: "ftp://listener.logz.io", expectedError: metricsExporter.ErrInvalidLogzioMetricsListener},
+		{listener: "https://", expectedError: metricsExporter.ErrInvalidLogzioMetricsListener},
+	}
+	for _, test := range tests {
+		t.Run(test.listener, func(t *testing.T) {
+			config := metricsExporter.Config{LogzioMetricsToken: "123456789a", LogzioMetricsListener: test.listener}
+			require.Equal(t, test.expectedError, config.Validate())
+		})
+	}
+}
+
+func TestValidateMaxRequestBodyBytes(t *testing.T) {
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789a", MaxRequestBodyBytes: 1 << 20}
+	require.NoError(t, config.Validate())
+
+	config.MaxRequestBodyBytes = -1
+	require.Equal(t, metricsExporter.ErrInvalidMaxRequestBodyBytes, config.Validate())
+}
+
+func TestValidateMaxScopeAttributeBytes(t *testing.T) {
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789a", MaxScopeAttributeBytes: 1024}
+	require.NoError(t, config.Validate())
+
+	config.MaxScopeAttributeBytes = -1
+	require.Equal(t, metricsExporter.ErrInvalidMaxScopeAttributeBytes, config.Validate())
+}
+
+func TestValidateDialNetwork(t *testing.T) {
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789a", DialNetwork: "tcp4"}
+	require.NoError(t, config.Validate())
+
+	config.DialNetwork = "udp"
+	require.Equal(t, metricsExporter.ErrInvalidDialNetwork, config.Validate())
+}
+
+func TestValidateAddEnvLabel(t *testing.T) {
+	t.Setenv("ENV", "")
+	t.Setenv("DEPLOY_ENV", "")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "service.name=api, deployment.environment=staging")
+
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789a", AddEnvLabel: true}
+	require.NoError(t, config.Validate())
+	require.Equal(t, map[string]string{"env": "staging"}, config.ExternalLabels)
+
+	t.Setenv("DEPLOY_ENV", "prod")
+	labels := map[string]string{"team": "core"}
+	config = metricsExporter.Config{LogzioMetricsToken: "123456789a", AddEnvLabel: true, ExternalLabels: labels}
+	require.NoError(t, config.Validate())
+	require.Equal(t, map[string]string{"team": "core", "env": "prod"}, config.ExternalLabels)
+	require.Equal(t, map[string]string{"team": "core"}, labels, "the configured labels are not modified")
+
+	config = metricsExporter.Config{LogzioMetricsToken: "123456789a", AddEnvLabel: true, ExternalLabels: map[string]string{"env": "dev"}}
+	require.NoError(t, config.Validate())
+	require.Equal(t, map[string]string{"env": "dev"}, config.ExternalLabels)
+}
+
+// TestValidateBudgets checks that budgets need a unique name, labels, and positive max samples.
+func TestValidateBudgets(t *testing.T) {
+	valid := metricsExporter.Budget{Name: "payments", Labels: map[string]string{"team": "payments"}, MaxSamples: 100}
+	tests := []struct {
+		name          string
+		budgets       []metricsExporter.Budget
+		expectedError error
+	}{
+		{name: "valid", budgets: []metricsExporter.Budget{valid}},
+		{name: "no name", budgets: []metricsExporter.Budget{{Labels: valid.Labels, MaxSamples: 100}}, expectedError: metricsExporter.ErrInvalidBudget},
+		{name: "duplicate name", budgets: []metricsExporter.Budget{valid, valid}, expectedError: metricsExporter.ErrInvalidBudget},
+		{name: "no labels", budgets: []metricsExporter.Budget{{Name: "payments", MaxSamples: 100}}, expectedError: metricsExporter.ErrInvalidBudget},
+		{name: "no max samples", budgets: []metricsExporter.Budget{{Name: "payments", Labels: valid.Labels}}, expectedError: metricsExporter.ErrInvalidBudget},
+		{name: "negative interval", budgets: []metricsExporter.Budget{{Name: "payments", Labels: valid.Labels, MaxSamples: 100, Interval: -time.Second}}, expectedError: metricsExporter.ErrInvalidBudget},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := metricsExporter.Config{LogzioMetricsToken: "123456789a", Budgets: test.budgets}
+			require.Equal(t, test.expectedError, config.Validate())
+		})
+	}
+}
+
+// TestRedacted checks that the secrets are masked and the redacted config does not share its maps.
+func TestRedacted(t *testing.T) {
+	config := metricsExporter.Config{
+		LogzioMetricsToken: "123456789abc",
+		Headers:            map[string]string{"X-Api-Key": "secret"},
+		RequestSigner:      metricsExporter.HMACRequestSigner{Header: "X-Signature", Secret: []byte("secret")},
+		ExternalLabels:     map[string]string{"team": "core"},
+	}
+
+	redacted := config.Redacted()
+	require.Equal(t, "********9abc", redacted.LogzioMetricsToken)
+	require.Equal(t, map[string]string{"X-Api-Key": "******"}, redacted.Headers)
+	require.Equal(t, metricsExporter.HMACRequestSigner{Header: "X-Signature"}, redacted.RequestSigner)
+
+	redacted.ExternalLabels["team"] = "other"
+	require.Equal(t, "core", config.ExternalLabels["team"])
+	require.Equal(t, "123456789abc", config.LogzioMetricsToken)
+	require.Equal(t, "****", metricsExporter.Config{LogzioMetricsToken: "1234"}.Redacted().LogzioMetricsToken)
+}
+
+// TestRedactedSignerPointer checks that the secret of a signer passed by pointer is masked without changing the
+// caller's signer.
+func TestRedactedSignerPointer(t *testing.T) {
+	signer := &metricsExporter.HMACRequestSigner{Header: "X-Signature", Secret: []byte("secret")}
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789abc", RequestSigner: signer}
+
+	redacted := config.Redacted()
+	require.Equal(t, &metricsExporter.HMACRequestSigner{Header: "X-Signature"}, redacted.RequestSigner)
+	require.Equal(t, []byte("secret"), signer.Secret)
+	require.Same(t, signer, config.RequestSigner)
+}
+
+// TestEffectiveConfig checks that the effective config holds the defaults applied by Validate.
+func TestEffectiveConfig(t *testing.T) {
+	exporter, err := metricsExporter.New(metricsExporter.Config{LogzioMetricsToken: "123456789abc"})
+	require.NoError(t, err)
+
+	config := exporter.EffectiveConfig()
+	require.Equal(t, "https://listener.logz.io:8053", config.LogzioMetricsListener)
+	require.Equal(t, 10*time.Second, config.PushInterval)
+	require.Equal(t, "********9abc", config.LogzioMetricsToken)
+}
+
+// TestValidateOTelEnvironmentVariables checks that the standard environment variables set the defaults of the
+// push interval and remote timeout, but not the configured values.
+func TestValidateOTelEnvironmentVariables(t *testing.T) {
+	t.Setenv("OTEL_METRIC_EXPORT_INTERVAL", "15000")
+	t.Setenv("OTEL_METRIC_EXPORT_TIMEOUT", "5000")
+
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789a"}
+	require.NoError(t, config.Validate())
+	require.Equal(t, 15*time.Second, config.PushInterval)
+	require.Equal(t, 5*time.Second, config.RemoteTimeout)
+
+	config = metricsExporter.Config{LogzioMetricsToken: "123456789a", PushInterval: time.Minute, RemoteTimeout: time.Second}
+	require.NoError(t, config.Validate())
+	require.Equal(t, time.Minute, config.PushInterval)
+	require.Equal(t, time.Second, config.RemoteTimeout)
+
+	t.Setenv("OTEL_METRIC_EXPORT_INTERVAL", "10")
+	t.Setenv("OTEL_METRIC_EXPORT_TIMEOUT", "5s")
+	config = metricsExporter.Config{LogzioMetricsToken: "123456789a"}
+	require.NoError(t, config.Validate())
+	require.Equal(t, 10*time.Second, config.PushInterval, "an interval below the minimum push interval is ignored")
+	require.Equal(t, 30*time.Second, config.RemoteTimeout, "an invalid timeout is ignored")
+}
+
+func TestValidateLabelNamespace(t *testing.T) {
+	for namespace, expectedError := range map[string]error{
+		"":        nil,
+		"logzio":  nil,
+		"_logzio": nil,
+		"__otel":  metricsExporter.ErrInvalidLabelNamespace,
+		"logz.io": metricsExporter.ErrInvalidLabelNamespace,
+		"1logzio": metricsExporter.ErrInvalidLabelNamespace,
+	} {
+		config := metricsExporter.Config{LogzioMetricsToken: "123456789a", LabelNamespace: namespace}
+		require.Equal(t, expectedError, config.Validate(), namespace)
+	}
+}
+
+// TestValidateDefaults checks that the exported defaults are applied, and that the default quantiles are copied.
+func TestValidateDefaults(t *testing.T) {
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789a"}
+	require.NoError(t, config.Validate())
+	require.Equal(t, metricsExporter.DefaultListener, config.LogzioMetricsListener)
+	require.Equal(t, metricsExporter.DefaultRemoteTimeout, config.RemoteTimeout)
+	require.Equal(t, metricsExporter.DefaultPushInterval, config.PushInterval)
+	require.Equal(t, metricsExporter.DefaultQuantiles, config.Quantiles)
+
+	config.Quantiles[0] = 0.1
+	require.Equal(t, 0.5, metricsExporter.DefaultQuantiles[0])
+}
+
+func TestValidateRetry(t *testing.T) {
+	tests := []struct {
+		retry         metricsExporter.RetryConfig
+		expectedError error
+	}{
+		{retry: metricsExporter.RetryConfig{}},
+		{retry: metricsExporter.RetryConfig{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: time.Minute, Jitter: time.Second}},
+		{retry: metricsExporter.RetryConfig{MaxAttempts: -1}, expectedError: metricsExporter.ErrInvalidRetry},
+		{retry: metricsExporter.RetryConfig{InitialBackoff: -time.Second}, expectedError: metricsExporter.ErrInvalidRetry},
+		{retry: metricsExporter.RetryConfig{Jitter: -time.Second}, expectedError: metricsExporter.ErrInvalidRetry},
+		{retry: metricsExporter.RetryConfig{InitialBackoff: time.Minute, MaxBackoff: time.Second}, expectedError: metricsExporter.ErrInvalidRetry},
+	}
+	for _, tt := range tests {
+		config := metricsExporter.Config{LogzioMetricsToken: "123456789a", Retry: tt.retry}
+		require.Equal(t, tt.expectedError, config.Validate(), "%+v", tt.retry)
+	}
+}
+
+func TestValidateSpool(t *testing.T) {
+	tests := []struct {
+		spool         metricsExporter.SpoolConfig
+		expectedError error
+	}{
+		{spool: metricsExporter.SpoolConfig{}},
+		{spool: metricsExporter.SpoolConfig{Dir: "/var/spool/metrics", MaxBytes: 1 << 20, Retention: time.Hour}},
+		{spool: metricsExporter.SpoolConfig{Dir: "/var/spool/metrics", MaxBytes: -1}, expectedError: metricsExporter.ErrInvalidSpool},
+		{spool: metricsExporter.SpoolConfig{Dir: "/var/spool/metrics", Retention: -time.Hour}, expectedError: metricsExporter.ErrInvalidSpool},
+		{spool: metricsExporter.SpoolConfig{MaxBytes: 1 << 20}, expectedError: metricsExporter.ErrInvalidSpool},
+	}
+	for _, tt := range tests {
+		config := metricsExporter.Config{LogzioMetricsToken: "123456789a", Spool: tt.spool}
+		require.Equal(t, tt.expectedError, config.Validate(), "%+v", tt.spool)
+	}
+}
+
+func TestValidateListenerPort(t *testing.T) {
+	tests := []struct {
+		listener     string
+		fix          bool
+		wantListener string
+		wantErr      bool
+	}{
+		{listener: "https://listener-eu.logz.io:8053", wantListener: "https://listener-eu.logz.io:8053"},
+		{listener: "http://listener.logz.io:8052", wantListener: "http://listener.logz.io:8052"},
+		{listener: "https://listener.logz.io:8071", wantErr: true},
+		{listener: "https://listener.logz.io:8052", wantErr: true},
+		{listener: "https://listener.logz.io", wantErr: true},
+		{listener: "https://listener.logz.io:8071", fix: true, wantListener: "https://listener.logz.io:8053"},
+		{listener: "http://listener-uk.logz.io:8070/api", fix: true, wantListener: "http://listener-uk.logz.io:8052/api"},
+		{listener: "https://proxy.example.com:8071", wantListener: "https://proxy.example.com:8071"},
+	}
+	for _, tt := range tests {
+		config := metricsExporter.Config{LogzioMetricsToken: "123456789a", LogzioMetricsListener: tt.listener, FixListenerPort: tt.fix}
+		err := config.Validate()
+		if tt.wantErr {
+			require.ErrorIs(t, err, metricsExporter.ErrWrongListenerPort, tt.listener)
+			continue
+		}
+		require.NoError(t, err, tt.listener)
+		require.Equal(t, tt.wantListener, config.LogzioMetricsListener)
+	}
+}
+
+func TestValidateCompression(t *testing.T) {
+	for _, compression := range []metricsExporter.Compression{metricsExporter.CompressionSnappy, metricsExporter.CompressionGzip, metricsExporter.CompressionZstd} {
+		config := metricsExporter.Config{LogzioMetricsToken: "123456789a", Compression: compression}
+		require.NoError(t, config.Validate())
+	}
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789a", Compression: metricsExporter.CompressionZstd + 1}
+	require.Equal(t, metricsExporter.ErrInvalidCompression, config.Validate())
+}
+
+func TestValidateMetricTypeOverrides(t *testing.T) {
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789a", MetricTypeOverrides: map[string]metricsExporter.MetricTypeOverride{
+		"requests": metricsExporter.MetricTypeCounter,
+		"queue":    metricsExporter.MetricTypeGauge,
+	}}
+	require.NoError(t, config.Validate())
+
+	config.MetricTypeOverrides["other"] = 0
+	require.Equal(t, metricsExporter.ErrInvalidMetricTypeOverride, config.Validate())
+}
+
+func TestValidateJobMode(t *testing.T) {
+	config := metricsExporter.Config{LogzioMetricsToken: "123456789a", JobMode: true}
+	require.NoError(t, config.Validate())
+	require.Equal(t, metricsExporter.DefaultJobRetryAttempts, config.Retry.MaxAttempts)
+
+	for _, config := range []metricsExporter.Config{
+		{AsyncQueueSize: 10},
+		{Spool: metricsExporter.SpoolConfig{Dir: "/var/spool/metrics"}},
+		{SendWindows: []metricsExporter.SendWindow{{Start: time.Hour, End: 2 * time.Hour}}},
+	} {
+		config.LogzioMetricsToken = "123456789a"
+		config.JobMode = true
+		require.Equal(t, metricsExporter.ErrInvalidJobMode, config.Validate(), "%+v", config)
+	}
+}
+
+func TestValidateRollups(t *testing.T) {
+	tests := []struct {
+		rollup        metricsExporter.Rollup
+		expectedError error
+	}{
+		{rollup: metricsExporter.Rollup{Metric: "requests_total", Name: "service_requests_total", Without: []string{"pod"}}},
+		{rollup: metricsExporter.Rollup{Metric: "requests_total", Name: "requests_total", Without: []string{"pod"}}, expectedError: metricsExporter.ErrInvalidRollup},
+		{rollup: metricsExporter.Rollup{Metric: "requests_total", Name: "service-requests", Without: []string{"pod"}}, expectedError: metricsExporter.ErrInvalidRollup},
+		{rollup: metricsExporter.Rollup{Metric: "requests_total", Name: "service_requests_total"}, expectedError: metricsExporter.ErrInvalidRollup},
+		{rollup: metricsExporter.Rollup{Metric: "latency", Name: "service_latency", Without: []string{"le"}}, expectedError: metricsExporter.ErrInvalidRollup},
+	}
+	for _, tt := range tests {
+		config := metricsExporter.Config{LogzioMetricsToken: "123456789a", Rollups: []metricsExporter.Rollup{tt.rollup}}
+		require.Equal(t, tt.expectedError, config.Validate(), "%+v", tt.rollup)
+	}
+}
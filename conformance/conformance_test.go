@@ -0,0 +1,217 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conformance_test checks the exporter output against the Prometheus remote write 1.0 protocol.
+// The requests are decoded the way a Prometheus remote write receiver decodes them, and the decoded series
+// are compared with the values recorded through the OpenTelemetry SDK.
+package conformance_test
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	m "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	metricsExporter "github.com/logzio/go-metrics-sdk"
+)
+
+// receiver is a remote write receiver that decodes every request like Prometheus does.
+type receiver struct {
+	t        *testing.T
+	requests []*prompb.WriteRequest
+	headers  []http.Header
+}
+
+func (r *receiver) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	compressed, err := io.ReadAll(req.Body)
+	require.NoError(r.t, err)
+	uncompressed, err := snappy.Decode(nil, compressed)
+	require.NoError(r.t, err, "body must be snappy block compressed")
+
+	wr := &prompb.WriteRequest{}
+	require.NoError(r.t, wr.Unmarshal(uncompressed), "body must be a protobuf WriteRequest")
+
+	r.requests = append(r.requests, wr)
+	r.headers = append(r.headers, req.Header.Clone())
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// export records values with the OpenTelemetry SDK and exports them to a receiver.
+func export(t *testing.T, config metricsExporter.Config) (*receiver, time.Time, time.Time) {
+	recv := &receiver{t: t}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	config.LogzioMetricsListener = server.URL
+	config.LogzioMetricsToken = "123456789a"
+	exporter, err := metricsExporter.New(config)
+	require.NoError(t, err)
+
+	reader := metric.NewManualReader()
+	provider := metric.NewMeterProvider(
+		metric.WithReader(reader),
+		metric.WithResource(resource.NewSchemaless(attribute.String("service.name", "conformance"))),
+	)
+	meter := provider.Meter("conformance-meter", m.WithInstrumentationVersion("1.0.0"))
+	ctx := context.Background()
+
+	counter, err := meter.Int64Counter("requests_total")
+	require.NoError(t, err)
+	counter.Add(ctx, 3, m.WithAttributes(attribute.String("method", "GET")))
+	counter.Add(ctx, 4, m.WithAttributes(attribute.String("method", "POST")))
+
+	upDown, err := meter.Float64UpDownCounter("queue_size")
+	require.NoError(t, err)
+	upDown.Add(ctx, 5)
+	upDown.Add(ctx, -1.5)
+
+	gauge, err := meter.Int64Gauge("temperature")
+	require.NoError(t, err)
+	gauge.Record(ctx, 21, m.WithAttributes(attribute.String("room.name", "lab")))
+
+	histogram, err := meter.Float64Histogram("latency", m.WithExplicitBucketBoundaries(1, 5, 10))
+	require.NoError(t, err)
+	for _, v := range []float64{0.5, 2, 3, 7, 20} {
+		histogram.Record(ctx, v)
+	}
+
+	start := time.Now()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	require.NoError(t, exporter.Export(ctx, &rm))
+	end := time.Now()
+
+	require.NotEmpty(t, recv.requests)
+	return recv, start, end
+}
+
+// series returns the decoded series of all requests keyed by their label set.
+func series(recv *receiver) map[string]prompb.TimeSeries {
+	result := map[string]prompb.TimeSeries{}
+	for _, wr := range recv.requests {
+		for _, ts := range wr.Timeseries {
+			result[labelSet(ts).String()] = ts
+		}
+	}
+	return result
+}
+
+func labelSet(ts prompb.TimeSeries) labels.Labels {
+	builder := labels.NewScratchBuilder(len(ts.Labels))
+	for _, l := range ts.Labels {
+		builder.Add(l.Name, l.Value)
+	}
+	return builder.Labels()
+}
+
+func value(t *testing.T, all map[string]prompb.TimeSeries, lbls ...string) float64 {
+	want := labels.FromStrings(append([]string{
+		"service_name", "conformance",
+		"otel_scope_name", "conformance-meter",
+		"otel_scope_version", "1.0.0",
+	}, lbls...)...)
+	ts, ok := all[want.String()]
+	require.True(t, ok, "missing series %s", want)
+	require.Len(t, ts.Samples, 1)
+	return ts.Samples[0].Value
+}
+
+// TestHeaders checks the headers required by the remote write protocol.
+func TestHeaders(t *testing.T) {
+	recv, _, _ := export(t, metricsExporter.Config{})
+
+	for _, header := range recv.headers {
+		assert.Equal(t, "snappy", header.Get("Content-Encoding"))
+		assert.Equal(t, "application/x-protobuf", header.Get("Content-Type"))
+		assert.Equal(t, "0.1.0", header.Get("X-Prometheus-Remote-Write-Version"))
+		assert.NotEmpty(t, header.Get("User-Agent"))
+	}
+}
+
+// TestSeriesFormat checks the label and sample rules of the remote write protocol for every series.
+func TestSeriesFormat(t *testing.T) {
+	recv, start, end := export(t, metricsExporter.Config{EmitScopeInfo: true})
+
+	for _, wr := range recv.requests {
+		for _, ts := range wr.Timeseries {
+			names := make([]string, 0, len(ts.Labels))
+			for _, l := range ts.Labels {
+				assert.True(t, model.LabelName(l.Name).IsValidLegacy(), "invalid label name %q", l.Name)
+				assert.NotEmpty(t, l.Value, "label %q has an empty value", l.Name)
+				names = append(names, l.Name)
+			}
+			assert.True(t, sort.StringsAreSorted(names), "labels are not sorted: %v", names)
+			assert.Len(t, labelSet(ts), len(ts.Labels), "duplicate label names: %v", names)
+
+			metricName := labelSet(ts).Get(model.MetricNameLabel)
+			assert.True(t, model.IsValidLegacyMetricName(metricName), "invalid metric name %q", metricName)
+
+			require.NotEmpty(t, ts.Samples)
+			for _, sample := range ts.Samples {
+				assert.GreaterOrEqual(t, sample.Timestamp, start.UnixMilli()-1, "timestamps must be in milliseconds")
+				assert.LessOrEqual(t, sample.Timestamp, end.UnixMilli())
+				assert.False(t, math.IsNaN(sample.Value))
+			}
+		}
+	}
+}
+
+// TestSemanticEquivalence checks that the decoded series hold the values recorded with the SDK.
+func TestSemanticEquivalence(t *testing.T) {
+	recv, _, _ := export(t, metricsExporter.Config{})
+	all := series(recv)
+
+	assert.Equal(t, float64(3), value(t, all, "__name__", "requests_total", "method", "GET"))
+	assert.Equal(t, float64(4), value(t, all, "__name__", "requests_total", "method", "POST"))
+	assert.Equal(t, 3.5, value(t, all, "__name__", "queue_size"))
+	assert.Equal(t, float64(21), value(t, all, "__name__", "temperature", "room_name", "lab"))
+
+	assert.Equal(t, float64(5), value(t, all, "__name__", "latency_count"))
+	assert.Equal(t, 32.5, value(t, all, "__name__", "latency_sum"))
+	assert.Equal(t, 0.5, value(t, all, "__name__", "latency_min"))
+	assert.Equal(t, float64(20), value(t, all, "__name__", "latency_max"))
+
+	// Buckets are cumulative and the +Inf bucket equals the count.
+	wantBuckets := map[float64]float64{1: 1, 5: 3, 10: 4, math.Inf(1): 5}
+	for _, ts := range all {
+		lbls := labelSet(ts)
+		if lbls.Get(model.MetricNameLabel) != "latency" {
+			continue
+		}
+		le, err := strconv.ParseFloat(lbls.Get(model.BucketLabel), 64)
+		require.NoError(t, err, "le must be a float")
+		want, ok := wantBuckets[le]
+		require.True(t, ok, "unexpected bucket %v", le)
+		assert.Equal(t, want, ts.Samples[0].Value, "bucket le=%v", le)
+		delete(wantBuckets, le)
+	}
+	assert.Empty(t, wantBuckets, "missing buckets")
+}
@@ -0,0 +1,179 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	m "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+
+	metricsExporter "github.com/logzio/go-metrics-sdk"
+)
+
+// prometheusURLEnv is the environment variable with the URL of the Prometheus server the integration test
+// exports to, e.g. http://localhost:9090. The server must run with --web.enable-remote-write-receiver and
+// --enable-feature=exemplar-storage.
+const prometheusURLEnv = "PROMETHEUS_URL"
+
+// prometheus queries the HTTP API of a Prometheus server.
+type prometheus struct {
+	t   *testing.T
+	url string
+}
+
+// get decodes the data of an API response into data.
+func (p prometheus) get(path string, params url.Values, data any) {
+	res, err := http.Get(p.url + path + "?" + params.Encode())
+	require.NoError(p.t, err)
+	defer res.Body.Close()
+
+	var body struct {
+		Status string          `json:"status"`
+		Error  string          `json:"error"`
+		Data   json.RawMessage `json:"data"`
+	}
+	require.NoError(p.t, json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(p.t, "success", body.Status, body.Error)
+	require.NoError(p.t, json.Unmarshal(body.Data, data))
+}
+
+// query returns the value of the single series of an instant query, or false when it has no result yet.
+func (p prometheus) query(query string) (float64, bool) {
+	var data struct {
+		Result []struct {
+			Value [2]any `json:"value"`
+		} `json:"result"`
+	}
+	p.get("/api/v1/query", url.Values{"query": {query}}, &data)
+	if len(data.Result) == 0 {
+		return 0, false
+	}
+	require.Len(p.t, data.Result, 1, "query %s", query)
+	value, err := strconv.ParseFloat(data.Result[0].Value[1].(string), 64)
+	require.NoError(p.t, err)
+	return value, true
+}
+
+// eventually waits for the single series of an instant query to be queryable and returns its value.
+func (p prometheus) eventually(query string) float64 {
+	var value float64
+	require.Eventually(p.t, func() bool {
+		var ok bool
+		value, ok = p.query(query)
+		return ok
+	}, 30*time.Second, 500*time.Millisecond, "no result for %s", query)
+	return value
+}
+
+// TestPrometheusIntegration exports through the full pipeline to a real Prometheus server and queries the data
+// back. It only runs when PROMETHEUS_URL is set, e.g. to a server started with:
+//
+//	docker run --rm -p 9090:9090 prom/prometheus --config.file=/etc/prometheus/prometheus.yml \
+//	    --web.enable-remote-write-receiver --enable-feature=exemplar-storage
+func TestPrometheusIntegration(t *testing.T) {
+	prometheusURL := os.Getenv(prometheusURLEnv)
+	if prometheusURL == "" {
+		t.Skipf("set %s to run the integration test", prometheusURLEnv)
+	}
+	prom := prometheus{t: t, url: strings.TrimSuffix(prometheusURL, "/")}
+
+	// Every run has its own label, so the runs against the same server do not mix.
+	runID := strconv.FormatInt(time.Now().UnixNano(), 10)
+	exporter, err := metricsExporter.New(metricsExporter.Config{
+		LogzioMetricsListener: prom.url + "/api/v1/write",
+		LogzioMetricsToken:    "123456789a",
+		ExternalLabels:        map[string]string{"run_id": runID},
+	})
+	require.NoError(t, err)
+
+	reader := metric.NewManualReader()
+	provider := metric.NewMeterProvider(
+		metric.WithReader(reader),
+		metric.WithResource(resource.NewSchemaless(attribute.String("service.name", "integration"))),
+	)
+	meter := provider.Meter("integration-meter")
+
+	// The default exemplar filter only samples measurements recorded in a sampled span.
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	spanID := trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	counter, err := meter.Int64Counter("integration_requests_total")
+	require.NoError(t, err)
+	counter.Add(ctx, 7)
+
+	histogram, err := meter.Float64Histogram("integration_latency", m.WithExplicitBucketBoundaries(1, 5, 10))
+	require.NoError(t, err)
+	for _, v := range []float64{0.5, 2, 3, 7, 20} {
+		histogram.Record(ctx, v)
+	}
+
+	start := time.Now()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	require.NoError(t, exporter.Export(ctx, &rm))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	selector := func(name string, matchers ...string) string {
+		return fmt.Sprintf(`%s{%s}`, name, strings.Join(append([]string{`run_id="` + runID + `"`}, matchers...), ","))
+	}
+
+	assert.Equal(t, float64(7), prom.eventually(selector("integration_requests_total")))
+
+	// The buckets are cumulative, the +Inf bucket equals the count, and the server can compute quantiles from them.
+	for le, want := range map[string]float64{"1": 1, "5": 3, "10": 4, "+Inf": 5} {
+		assert.Equal(t, want, prom.eventually(selector("integration_latency", `le="`+le+`"`)), "bucket le=%s", le)
+	}
+	assert.Equal(t, float64(5), prom.eventually(selector("integration_latency_count")))
+	assert.Equal(t, 32.5, prom.eventually(selector("integration_latency_sum")))
+	median := prom.eventually(fmt.Sprintf("histogram_quantile(0.5, %s)", selector("integration_latency")))
+	assert.True(t, median > 1 && median <= 5, "median %v is not in the (1, 5] bucket", median)
+
+	// Exemplars are stored with the trace and span IDs of the measurements.
+	var exemplars []struct {
+		Exemplars []struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"exemplars"`
+	}
+	prom.get("/api/v1/query_exemplars", url.Values{
+		"query": {selector("integration_requests_total")},
+		"start": {strconv.FormatInt(start.Add(-time.Minute).Unix(), 10)},
+		"end":   {strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)},
+	}, &exemplars)
+	require.NotEmpty(t, exemplars, "no exemplars stored")
+	require.NotEmpty(t, exemplars[0].Exemplars)
+	assert.Equal(t, traceID.String(), exemplars[0].Exemplars[0].Labels["trace_id"])
+	assert.Equal(t, spanID.String(), exemplars[0].Exemplars[0].Labels["span_id"])
+}
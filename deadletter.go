@@ -0,0 +1,118 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// DeadLetter is a message that could not be sent to Logz.io.
+type DeadLetter struct {
+	// Message is the Snappy-compressed protobuf WriteRequest.
+	Message []byte
+	// Series is the number of timeseries in the message.
+	Series int
+	// Err is the error of the last send attempt.
+	Err error
+	// Time is when the message was built.
+	Time time.Time
+}
+
+// DeadLetterSink receives the messages that permanently failed to be sent, so that they can be stored
+// and replayed later.
+type DeadLetterSink interface {
+	WriteDeadLetter(ctx context.Context, letter DeadLetter) error
+}
+
+// DeadLetterSinkFunc is an adapter to use a function as a DeadLetterSink.
+type DeadLetterSinkFunc func(ctx context.Context, letter DeadLetter) error
+
+// WriteDeadLetter calls f(ctx, letter).
+func (f DeadLetterSinkFunc) WriteDeadLetter(ctx context.Context, letter DeadLetter) error {
+	return f(ctx, letter)
+}
+
+// deadLetterSeq numbers the dead letter files written by the process.
+var deadLetterSeq atomic.Uint64
+
+// FileDeadLetterSink writes every dead letter message to its own file in a directory. The files hold the
+// compressed message as it would have been sent, and can be replayed with any remote write client.
+type FileDeadLetterSink struct {
+	Dir string
+}
+
+// WriteDeadLetter writes the letter message to a file named after the letter time and a sequence number.
+func (s FileDeadLetterSink) WriteDeadLetter(_ context.Context, letter DeadLetter) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	// The sequence number keeps the names unique when several exporters of the process share the directory.
+	name := filepath.Join(s.Dir, fmt.Sprintf("%d-%d.snappy", letter.Time.UnixNano(), deadLetterSeq.Add(1)))
+	return os.WriteFile(name, letter.Message, 0o600)
+}
+
+// deliver sends a compressed message to Logz.io. When the listener is unreachable, the message is spooled if
+// the Spool is set. Otherwise, a message that fails to be sent is passed to the DeadLetterSink, unless the listener
+// has been unreachable for longer than FallbackAfter. The requests are canceled when ctx is done. A dry run
+// neither sends nor removes the spooled messages.
+func (e *Exporter) deliver(ctx context.Context, message []byte, series, exemplars int, built time.Time) error {
+	spooling := e.config.Spool.Dir != "" && e.config.DryRun == nil
+	if spooling {
+		// The spooled messages are sent first, so that the listener receives the samples of each series in order.
+		if err := e.drainSpool(ctx, time.Now()); err != nil {
+			return e.spoolUnsent(message, built, err)
+		}
+	}
+
+	err := e.sendMessage(ctx, message, exemplars)
+	e.telemetry.recordDelivery(series, len(message), err)
+	if spooling && isUnreachable(err) {
+		return e.spoolUnsent(message, built, err)
+	}
+	if e.logFallback(err, series, len(message), time.Now()) {
+		return err
+	}
+	if err == nil {
+		return nil
+	}
+	return e.writeDeadLetter(DeadLetter{Message: message, Series: series, Err: err, Time: built})
+}
+
+// spoolUnsent spools a message that was not sent because of err. It returns nil once the message is spooled.
+func (e *Exporter) spoolUnsent(message []byte, built time.Time, err error) error {
+	if spoolErr := e.spoolMessage(message, built); spoolErr != nil {
+		return multierror.Append(err, fmt.Errorf("failed to spool message: %w", spoolErr))
+	}
+	return nil
+}
+
+// writeDeadLetter passes a letter to the DeadLetterSink, if any, and returns the letter error along with the error
+// of the sink.
+func (e *Exporter) writeDeadLetter(letter DeadLetter) error {
+	if e.config.DeadLetterSink == nil {
+		return letter.Err
+	}
+	if sinkErr := e.config.DeadLetterSink.WriteDeadLetter(context.Background(), letter); sinkErr != nil {
+		return multierror.Append(letter.Err, fmt.Errorf("failed to write dead letter: %w", sinkErr))
+	}
+	return letter.Err
+}
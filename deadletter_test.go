@@ -0,0 +1,75 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeadLetterSink tests that a failed message is passed to the DeadLetterSink and that
+// a successful message is not.
+func TestDeadLetterSink(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	var letters []DeadLetter
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		DeadLetterSink: DeadLetterSinkFunc(func(_ context.Context, letter DeadLetter) error {
+			letters = append(letters, letter)
+			return nil
+		}),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+	assert.Empty(t, letters)
+
+	status = http.StatusServiceUnavailable
+	require.Error(t, exporter.Export(context.Background(), getSumMetric(5)))
+	require.Len(t, letters, 1)
+	assert.Equal(t, 1, letters[0].Series)
+	assert.NotEmpty(t, letters[0].Message)
+	assert.Error(t, letters[0].Err)
+}
+
+func TestFileDeadLetterSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dead-letters")
+	sink := FileDeadLetterSink{Dir: dir}
+	letter := DeadLetter{Message: []byte("message"), Series: 1, Time: time.Unix(0, 42)}
+
+	// Letters of the same time, e.g. of exporters sharing the directory, are written to different files.
+	require.NoError(t, sink.WriteDeadLetter(context.Background(), letter))
+	require.NoError(t, sink.WriteDeadLetter(context.Background(), letter))
+	names, err := filepath.Glob(filepath.Join(dir, "42-*.snappy"))
+	require.NoError(t, err)
+	require.Len(t, names, 2)
+	content, err := os.ReadFile(names[0])
+	require.NoError(t, err)
+	assert.Equal(t, letter.Message, content)
+}
@@ -0,0 +1,61 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// helpEscaper escapes help texts as in the Prometheus exposition format.
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// writeDryRun decodes a compressed message and writes it to the DryRun writer instead of sending it to Logz.io.
+// The message is rendered after a header line with its number of series and size, with its metadata, if any, as
+// # HELP, # TYPE and # UNIT lines, and with its series as rendered by Preview.
+func (e *Exporter) writeDryRun(message []byte) error {
+	uncompressed, err := snappy.Decode(nil, message)
+	if err != nil {
+		return fmt.Errorf("failed to decode dry run message: %w", err)
+	}
+	var writeRequest prompb.WriteRequest
+	if err := writeRequest.Unmarshal(uncompressed); err != nil {
+		return fmt.Errorf("failed to decode dry run message: %w", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# WriteRequest: %d series, %d bytes\n", len(writeRequest.Timeseries), len(message))
+	for _, metadata := range writeRequest.Metadata {
+		if metadata.Help != "" {
+			fmt.Fprintf(&sb, "# HELP %s %s\n", metadata.MetricFamilyName, helpEscaper.Replace(metadata.Help))
+		}
+		fmt.Fprintf(&sb, "# TYPE %s %s\n", metadata.MetricFamilyName, strings.ToLower(metadata.Type.String()))
+		if metadata.Unit != "" {
+			fmt.Fprintf(&sb, "# UNIT %s %s\n", metadata.MetricFamilyName, metadata.Unit)
+		}
+	}
+	sb.WriteString(previewTimeSeries(writeRequest.Timeseries))
+
+	// The messages of concurrent exports are written one at a time, as their requests are sent.
+	e.clientMu.Lock()
+	defer e.clientMu.Unlock()
+	if _, err := e.config.DryRun.Write([]byte(sb.String())); err != nil {
+		return fmt.Errorf("failed to write dry run message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		SendMetadata:          true,
+		DryRun:                &out,
+	})
+	require.NoError(t, err)
+
+	rm := getSumMetric(5)
+	rm.ScopeMetrics[0].Metrics[0].Description = "Requests\nserved"
+	require.NoError(t, exporter.Export(context.Background(), rm))
+	assert.Zero(t, requests)
+
+	written := out.String()
+	assert.Regexp(t, `^# WriteRequest: 1 series, \d+ bytes\n`, written)
+	assert.Contains(t, written, "# HELP metric_sum Requests\\nserved\n# TYPE metric_sum counter\n")
+	assert.Regexp(t, `\nmetric_sum\{[^}]*\} 5 \d+\n$`, written)
+}
+
+func TestDryRunKeepsSpool(t *testing.T) {
+	var out bytes.Buffer
+	exporter, err := New(Config{
+		LogzioMetricsToken: "123456789a",
+		Spool:              SpoolConfig{Dir: t.TempDir()},
+		DryRun:             &out,
+	})
+	require.NoError(t, err)
+	require.NoError(t, exporter.spoolMessage([]byte("pending"), time.Now()))
+
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+	assert.Equal(t, 1, strings.Count(out.String(), "# WriteRequest:"), "only the new message is written")
+	spooled, err := exporter.spooledMessages()
+	require.NoError(t, err)
+	assert.Len(t, spooled, 1, "the spooled message is kept")
+}
@@ -0,0 +1,55 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"errors"
+	"net/http"
+)
+
+// IsRetryable reports whether err was caused by a transient failure, i.e. a network error, a timeout, or a
+// 429 or 5xx response of the listener, so that sending the metrics again later may succeed. Errors of requests
+// that were retried until Retry.MaxAttempts are retryable.
+func IsRetryable(err error) bool {
+	var retryable *retryableError
+	return errors.As(err, &retryable)
+}
+
+// IsAuthError reports whether err was caused by the listener rejecting the token with a 401 or 403 response.
+func IsAuthError(err error) bool {
+	status := responseStatus(err)
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// IsThrottled reports whether err was caused by the listener throttling the requests with a 429 response.
+func IsThrottled(err error) bool {
+	return responseStatus(err) == http.StatusTooManyRequests
+}
+
+// IsPayloadTooLarge reports whether err was caused by the listener rejecting a request body as too large with a
+// 413 response, which MaxRequestBodyBytes prevents.
+func IsPayloadTooLarge(err error) bool {
+	return responseStatus(err) == http.StatusRequestEntityTooLarge
+}
+
+// responseStatus returns the status code of the listener response that caused err, or 0 when err was not caused
+// by a response.
+func responseStatus(err error) int {
+	var status *statusError
+	if !errors.As(err, &status) {
+		return 0
+	}
+	return status.statusCode
+}
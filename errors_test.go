@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorPredicates(t *testing.T) {
+	tests := []struct {
+		status        int
+		wantRetryable bool
+		wantAuth      bool
+		wantThrottled bool
+		wantTooLarge  bool
+	}{
+		{status: http.StatusUnauthorized, wantAuth: true},
+		{status: http.StatusForbidden, wantAuth: true},
+		{status: http.StatusTooManyRequests, wantRetryable: true, wantThrottled: true},
+		{status: http.StatusRequestEntityTooLarge, wantTooLarge: true},
+		{status: http.StatusBadGateway, wantRetryable: true},
+		{status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a", Retry: RetryConfig{MaxAttempts: 1}})
+			require.NoError(t, err)
+			err = exporter.Export(context.Background(), getSumMetric(5))
+			require.Error(t, err)
+
+			assert.Equal(t, tt.wantRetryable, IsRetryable(err))
+			assert.Equal(t, tt.wantAuth, IsAuthError(err))
+			assert.Equal(t, tt.wantThrottled, IsThrottled(err))
+			assert.Equal(t, tt.wantTooLarge, IsPayloadTooLarge(err))
+		})
+	}
+}
+
+func TestErrorPredicatesOtherErrors(t *testing.T) {
+	err := errors.New("failed")
+	assert.False(t, IsRetryable(err))
+	assert.False(t, IsAuthError(err))
+	assert.False(t, IsThrottled(nil))
+	assert.False(t, IsPayloadTooLarge(ErrQueueFull))
+}
@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"net/http"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// exemplarsWrittenHeader is the response header in which remote write receivers report the number of exemplars
+// they stored from a request.
+const exemplarsWrittenHeader = "X-Prometheus-Remote-Write-Exemplars-Written"
+
+// ExemplarPolicy controls whether the exemplars of counters and histograms are sent to Logz.io.
+type ExemplarPolicy int
+
+const (
+	// ExemplarsAuto sends exemplars until the listener reports in the X-Prometheus-Remote-Write-Exemplars-Written
+	// response header that it stored none of the exemplars of a request, and strips them from then on.
+	ExemplarsAuto ExemplarPolicy = iota
+	// ExemplarsInclude always sends exemplars.
+	ExemplarsInclude
+	// ExemplarsDrop never sends exemplars, e.g. for accounts that do not store them.
+	ExemplarsDrop
+)
+
+// ExemplarsStripped reports whether exemplars are stripped because the listener reported that it does not
+// store them.
+func (e *Exporter) ExemplarsStripped() bool {
+	return e.noExemplars.Load()
+}
+
+// includeExemplars reports whether the exemplars are converted.
+func (e *Exporter) includeExemplars() bool {
+	switch e.config.ExemplarPolicy {
+	case ExemplarsDrop:
+		return false
+	case ExemplarsAuto:
+		return !e.noExemplars.Load()
+	default:
+		return true
+	}
+}
+
+// exemplarsToProbe returns the number of exemplars of the series in ExemplarsAuto mode, whose response tells
+// whether the listener stores them, and 0 otherwise.
+func (e *Exporter) exemplarsToProbe(timeseries []prompb.TimeSeries) int {
+	if e.config.ExemplarPolicy != ExemplarsAuto || e.noExemplars.Load() {
+		return 0
+	}
+	exemplars := 0
+	for i := range timeseries {
+		exemplars += len(timeseries[i].Exemplars)
+	}
+	return exemplars
+}
+
+// probeExemplarSupport strips the exemplars of the next exports when the response to a request holding exemplars
+// reports that the listener stored none of them. Listeners that do not report it keep receiving exemplars.
+func (e *Exporter) probeExemplarSupport(header http.Header, exemplars int) {
+	if exemplars == 0 || header.Get(exemplarsWrittenHeader) != "0" {
+		return
+	}
+	if !e.noExemplars.Swap(true) {
+		e.logger().Printf("Logz.io metrics exporter: the listener stored none of %d exemplars, exemplars are no longer sent", exemplars)
+	}
+}
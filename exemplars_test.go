@@ -0,0 +1,89 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// getSumMetricWithExemplar returns a resource metric with a sum aggregation record holding an exemplar.
+func getSumMetricWithExemplar() *metricdata.ResourceMetrics {
+	rm := getSumMetric(5)
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	sum.DataPoints[0].Exemplars = []metricdata.Exemplar[int64]{{Value: 5, Time: time.Now(), TraceID: []byte{1}, SpanID: []byte{2}}}
+	rm.ScopeMetrics[0].Metrics[0].Data = sum
+	return rm
+}
+
+func TestExemplarPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       ExemplarPolicy
+		written      string
+		wantExported []int
+		wantStripped bool
+	}{
+		{name: "auto stores exemplars", policy: ExemplarsAuto, written: "1", wantExported: []int{1, 1}},
+		{name: "auto does not report", policy: ExemplarsAuto, wantExported: []int{1, 1}},
+		{name: "auto stores none", policy: ExemplarsAuto, written: "0", wantExported: []int{1, 0}, wantStripped: true},
+		{name: "include", policy: ExemplarsInclude, written: "0", wantExported: []int{1, 1}},
+		{name: "drop", policy: ExemplarsDrop, written: "1", wantExported: []int{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exported []int
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				compressed, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				uncompressed, err := snappy.Decode(nil, compressed)
+				require.NoError(t, err)
+				wr := &prompb.WriteRequest{}
+				require.NoError(t, wr.Unmarshal(uncompressed))
+
+				exported = append(exported, len(wr.Timeseries[0].Exemplars))
+				if tt.written != "" {
+					rw.Header().Set(exemplarsWrittenHeader, tt.written)
+				}
+				rw.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			exporter, err := New(Config{
+				LogzioMetricsListener: server.URL,
+				LogzioMetricsToken:    "123456789a",
+				ExemplarPolicy:        tt.policy,
+			})
+			require.NoError(t, err)
+
+			for range tt.wantExported {
+				require.NoError(t, exporter.Export(context.Background(), getSumMetricWithExemplar()))
+			}
+			assert.Equal(t, tt.wantExported, exported)
+			assert.Equal(t, tt.wantStripped, exporter.ExemplarsStripped())
+		})
+	}
+}
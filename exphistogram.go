@@ -0,0 +1,193 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// The schemas of Prometheus native histograms are exponential histogram scales between
+// nativeHistogramMinSchema and nativeHistogramMaxSchema.
+const (
+	nativeHistogramMinSchema = -4
+	nativeHistogramMaxSchema = 8
+)
+
+// ExponentialHistogramMode controls how exponential histograms are exported.
+type ExponentialHistogramMode int
+
+const (
+	// ExponentialHistogramBuckets exports exponential histograms as classic histograms, with a bucket per
+	// exponential bucket bounded by its upper boundary.
+	ExponentialHistogramBuckets ExponentialHistogramMode = iota
+	// ExponentialHistogramNative exports exponential histograms as Prometheus native histograms.
+	ExponentialHistogramNative
+)
+
+// convertExponentialHistogram converts the exponential histogram according to the configured
+// ExponentialHistogramMode. Histograms with a scale too low for a native histogram are exported as classic
+// histograms.
+func convertExponentialHistogram[N int64 | float64](metricName string, histogram metricdata.ExponentialHistogram[N], labels map[string]string, config Config, arena *seriesArena) ([]prompb.TimeSeries, error) {
+	// The bucket layout of delta exponential histograms changes between collections, so they cannot be
+	// accumulated into the cumulative series Prometheus expects.
+	if histogram.Temporality == metricdata.DeltaTemporality {
+		return nil, fmt.Errorf("%w: %s", ErrDeltaExponentialHistogram, metricName)
+	}
+	if config.ExponentialHistograms == ExponentialHistogramNative && nativeSchemaSupported(histogram) {
+		return convertNativeHistogram(metricName, histogram, labels, !config.LowMemory, config.exemplarLabelNames(), config.UTF8Names, arena), nil
+	}
+	return convertHistogram(metricName, exponentialToExplicit(histogram), labels, config, arena)
+}
+
+// exponentialToExplicit converts the exponential buckets of every datapoint to explicit buckets.
+func exponentialToExplicit[N int64 | float64](histogram metricdata.ExponentialHistogram[N]) metricdata.Histogram[N] {
+	dataPoints := make([]metricdata.HistogramDataPoint[N], len(histogram.DataPoints))
+	for i, dp := range histogram.DataPoints {
+		bounds, counts := explicitBuckets(dp.Scale, dp.ZeroThreshold, dp.ZeroCount, dp.NegativeBucket, dp.PositiveBucket)
+		dataPoints[i] = metricdata.HistogramDataPoint[N]{
+			Attributes:   dp.Attributes,
+			StartTime:    dp.StartTime,
+			Time:         dp.Time,
+			Count:        dp.Count,
+			Bounds:       bounds,
+			BucketCounts: counts,
+			Min:          dp.Min,
+			Max:          dp.Max,
+			Sum:          dp.Sum,
+			Exemplars:    dp.Exemplars,
+		}
+	}
+	return metricdata.Histogram[N]{Temporality: histogram.Temporality, DataPoints: dataPoints}
+}
+
+// explicitBuckets returns the explicit bounds and bucket counts holding the same counts as the exponential
+// buckets. Each negative, zero and positive bucket becomes an explicit bucket bounded by its upper boundary. The
+// zero bucket is bounded by the zero threshold, and is omitted when it is empty and no bucket precedes it.
+func explicitBuckets(scale int32, zeroThreshold float64, zeroCount uint64, negative, positive metricdata.ExponentialBucket) ([]float64, []uint64) {
+	n := len(negative.Counts) + len(positive.Counts) + 1
+	bounds := make([]float64, 0, n)
+	counts := make([]uint64, 0, n+1)
+
+	// Negative bucket i holds the values in [-base^(i+1), -base^i), so the buckets are added from the highest index.
+	for i := len(negative.Counts) - 1; i >= 0; i-- {
+		bounds = append(bounds, -exponentialBoundary(scale, negative.Offset+int32(i)))
+		counts = append(counts, negative.Counts[i])
+	}
+	if zeroCount > 0 || len(negative.Counts) > 0 {
+		bounds = append(bounds, zeroThreshold)
+		counts = append(counts, zeroCount)
+	}
+	// Positive bucket i holds the values in (base^i, base^(i+1)].
+	for i, count := range positive.Counts {
+		bounds = append(bounds, exponentialBoundary(scale, positive.Offset+int32(i)+1))
+		counts = append(counts, count)
+	}
+	// The overflow bucket is empty, since the exponential buckets cover every value.
+	return bounds, append(counts, 0)
+}
+
+// exponentialBoundary returns base^index for the base 2^(2^-scale) of the scale.
+func exponentialBoundary(scale, index int32) float64 {
+	return math.Exp2(float64(index) * math.Exp2(-float64(scale)))
+}
+
+// nativeSchemaSupported reports whether every datapoint of the histogram can be exported as a native histogram.
+// Scales above the maximum schema are reduced, but lower scales cannot be increased.
+func nativeSchemaSupported[N int64 | float64](histogram metricdata.ExponentialHistogram[N]) bool {
+	for _, dp := range histogram.DataPoints {
+		if dp.Scale < nativeHistogramMinSchema {
+			return false
+		}
+	}
+	return true
+}
+
+// convertNativeHistogram returns a timeseries holding a native histogram for each datapoint.
+func convertNativeHistogram[N int64 | float64](metricName string, histogram metricdata.ExponentialHistogram[N], labels map[string]string, withExemplars bool, exemplarLabels exemplarLabelNames, utf8Names bool, arena *seriesArena) []prompb.TimeSeries {
+	timeSeries := make([]prompb.TimeSeries, 0, len(histogram.DataPoints))
+	b := getLabelBuilder(labels, utf8Names, arena)
+	defer b.release()
+
+	for _, dp := range histogram.DataPoints {
+		var ex []prompb.Exemplar
+		if withExemplars {
+			ex = generateExamplers(dp.Exemplars, exemplarLabels, utf8Names)
+		}
+		b.setDataPoint(metricName, dp.Attributes)
+		timeSeries = append(timeSeries, prompb.TimeSeries{
+			Labels:     b.build(),
+			Histograms: []prompb.Histogram{nativeHistogram(dp)},
+			Exemplars:  ex,
+		})
+	}
+	return timeSeries
+}
+
+// nativeHistogram returns the native histogram of the datapoint, reducing its scale to the maximum schema.
+func nativeHistogram[N int64 | float64](dp metricdata.ExponentialHistogramDataPoint[N]) prompb.Histogram {
+	scale, negative, positive := dp.Scale, dp.NegativeBucket, dp.PositiveBucket
+	for ; scale > nativeHistogramMaxSchema; scale-- {
+		negative, positive = downscaleBucket(negative), downscaleBucket(positive)
+	}
+	negativeSpans, negativeDeltas := nativeBuckets(negative)
+	positiveSpans, positiveDeltas := nativeBuckets(positive)
+
+	return prompb.Histogram{
+		Count:          &prompb.Histogram_CountInt{CountInt: dp.Count},
+		Sum:            float64(dp.Sum),
+		Schema:         scale,
+		ZeroThreshold:  dp.ZeroThreshold,
+		ZeroCount:      &prompb.Histogram_ZeroCountInt{ZeroCountInt: dp.ZeroCount},
+		NegativeSpans:  negativeSpans,
+		NegativeDeltas: negativeDeltas,
+		PositiveSpans:  positiveSpans,
+		PositiveDeltas: positiveDeltas,
+		Timestamp:      timestampMillis(dp.Time),
+	}
+}
+
+// downscaleBucket returns the bucket at the next lower scale. Bucket i at the lower scale covers buckets 2i and
+// 2i+1, so their counts are merged.
+func downscaleBucket(bucket metricdata.ExponentialBucket) metricdata.ExponentialBucket {
+	if len(bucket.Counts) == 0 {
+		return bucket
+	}
+	offset := bucket.Offset >> 1
+	last := (bucket.Offset + int32(len(bucket.Counts)) - 1) >> 1
+	counts := make([]uint64, last-offset+1)
+	for i, count := range bucket.Counts {
+		counts[(bucket.Offset+int32(i))>>1-offset] += count
+	}
+	return metricdata.ExponentialBucket{Offset: offset, Counts: counts}
+}
+
+// nativeBuckets returns the spans and count deltas of the native histogram buckets for the exponential bucket.
+// Exponential bucket i holds the values in (base^i, base^(i+1)], which is native histogram bucket i+1.
+func nativeBuckets(bucket metricdata.ExponentialBucket) ([]prompb.BucketSpan, []int64) {
+	if len(bucket.Counts) == 0 {
+		return nil, nil
+	}
+	deltas := make([]int64, len(bucket.Counts))
+	var previous int64
+	for i, count := range bucket.Counts {
+		deltas[i] = int64(count) - previous
+		previous = int64(count)
+	}
+	return []prompb.BucketSpan{{Offset: bucket.Offset + 1, Length: uint32(len(bucket.Counts))}}, deltas
+}
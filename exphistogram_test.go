@@ -0,0 +1,139 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// getExponentialHistogramMetric returns an exponential histogram at scale 0, whose bucket boundaries are powers of 2.
+func getExponentialHistogramMetric(temporality metricdata.Temporality) *metricdata.ResourceMetrics {
+	return &metricdata.ResourceMetrics{
+		Resource: getResource(),
+		ScopeMetrics: []metricdata.ScopeMetrics{
+			{
+				Scope: getScope(),
+				Metrics: []metricdata.Metrics{
+					{
+						Name: "metric_exponential_histogram",
+						Data: metricdata.ExponentialHistogram[float64]{
+							Temporality: temporality,
+							DataPoints: []metricdata.ExponentialHistogramDataPoint[float64]{
+								{
+									Attributes:     attribute.Set{},
+									Time:           time.Now(),
+									Count:          10,
+									Sum:            12.5,
+									Scale:          0,
+									ZeroCount:      1,
+									NegativeBucket: metricdata.ExponentialBucket{Offset: 0, Counts: []uint64{2}},
+									PositiveBucket: metricdata.ExponentialBucket{Offset: 0, Counts: []uint64{3, 4}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestExplicitBuckets(t *testing.T) {
+	bounds, counts := explicitBuckets(0, 0, 1,
+		metricdata.ExponentialBucket{Offset: 0, Counts: []uint64{2}},
+		metricdata.ExponentialBucket{Offset: 0, Counts: []uint64{3, 4}})
+	assert.Equal(t, []float64{-1, 0, 2, 4}, bounds)
+	assert.Equal(t, []uint64{2, 1, 3, 4, 0}, counts)
+
+	bounds, counts = explicitBuckets(1, 0, 0, metricdata.ExponentialBucket{},
+		metricdata.ExponentialBucket{Offset: 1, Counts: []uint64{5}})
+	assert.Equal(t, []float64{2}, bounds, "the empty zero bucket is omitted")
+	assert.Equal(t, []uint64{5, 0}, counts)
+}
+
+func TestDownscaleBucket(t *testing.T) {
+	got := downscaleBucket(metricdata.ExponentialBucket{Offset: -1, Counts: []uint64{1, 2, 3, 4}})
+	assert.Equal(t, metricdata.ExponentialBucket{Offset: -1, Counts: []uint64{1, 5, 4}}, got)
+	assert.Equal(t, metricdata.ExponentialBucket{}, downscaleBucket(metricdata.ExponentialBucket{}))
+}
+
+func TestConvertExponentialHistogramBuckets(t *testing.T) {
+	exporter := Exporter{}
+	got, err := exporter.ConvertToTimeSeries(getExponentialHistogramMetric(metricdata.CumulativeTemporality))
+	require.NoError(t, err)
+
+	buckets := map[string]float64{}
+	for _, ts := range got {
+		if seriesName(ts.Labels) != "metric_exponential_histogram" {
+			continue
+		}
+		for _, label := range ts.Labels {
+			if label.Name == "le" {
+				buckets[label.Value] = ts.Samples[0].Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{"-1": 2, "0": 3, "2": 6, "4": 10, "+inf": 10}, buckets)
+}
+
+func TestConvertExponentialHistogramNative(t *testing.T) {
+	exporter := Exporter{config: Config{ExponentialHistograms: ExponentialHistogramNative}}
+	rm := getExponentialHistogramMetric(metricdata.CumulativeTemporality)
+	data := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.ExponentialHistogram[float64])
+	data.DataPoints[0].Scale = nativeHistogramMaxSchema + 1
+	data.DataPoints[0].PositiveBucket = metricdata.ExponentialBucket{Offset: 2, Counts: []uint64{3, 4, 1}}
+	got, err := exporter.ConvertToTimeSeries(rm)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Histograms, 1)
+	assert.Empty(t, got[0].Samples)
+	assert.Equal(t, "metric_exponential_histogram", seriesName(got[0].Labels))
+
+	histogram := got[0].Histograms[0]
+	assert.Equal(t, int32(nativeHistogramMaxSchema), histogram.Schema, "the scale is reduced to the maximum schema")
+	assert.Equal(t, uint64(10), histogram.GetCountInt())
+	assert.Equal(t, uint64(1), histogram.GetZeroCountInt())
+	assert.Equal(t, 12.5, histogram.Sum)
+	assert.Equal(t, []prompb.BucketSpan{{Offset: 2, Length: 2}}, histogram.PositiveSpans)
+	assert.Equal(t, []int64{7, -6}, histogram.PositiveDeltas)
+	assert.Equal(t, []prompb.BucketSpan{{Offset: 1, Length: 1}}, histogram.NegativeSpans)
+	assert.Equal(t, []int64{2}, histogram.NegativeDeltas)
+	assert.NotZero(t, histogram.Timestamp)
+}
+
+func TestConvertExponentialHistogramNativeLowScale(t *testing.T) {
+	exporter := Exporter{config: Config{ExponentialHistograms: ExponentialHistogramNative}}
+	rm := getExponentialHistogramMetric(metricdata.CumulativeTemporality)
+	data := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.ExponentialHistogram[float64])
+	data.DataPoints[0].Scale = nativeHistogramMinSchema - 1
+	got, err := exporter.ConvertToTimeSeries(rm)
+	require.NoError(t, err)
+	for _, ts := range got {
+		assert.Empty(t, ts.Histograms, "scales below the minimum schema are exported as classic histograms")
+	}
+}
+
+func TestConvertExponentialHistogramDelta(t *testing.T) {
+	exporter := Exporter{}
+	_, err := exporter.ConvertToTimeSeries(getExponentialHistogramMetric(metricdata.DeltaTemporality))
+	assert.ErrorIs(t, err, ErrDeltaExponentialHistogram)
+}
@@ -0,0 +1,41 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"maps"
+)
+
+// exportLabelsKey is the context key of the labels added by WithExportLabels.
+type exportLabelsKey struct{}
+
+// WithExportLabels returns a copy of ctx carrying labels that Export adds to every series of the batch, e.g.
+// to tag the metrics of a job flushed with metric.PeriodicReader.ForceFlush(ctx). The labels take precedence
+// over the ExternalLabels and the resource labels, and are merged with the labels of a parent context.
+func WithExportLabels(ctx context.Context, labels map[string]string) context.Context {
+	merged := maps.Clone(exportLabelsFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(labels))
+	}
+	maps.Copy(merged, labels)
+	return context.WithValue(ctx, exportLabelsKey{}, merged)
+}
+
+// exportLabelsFromContext returns the labels added to ctx by WithExportLabels.
+func exportLabelsFromContext(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(exportLabelsKey{}).(map[string]string)
+	return labels
+}
@@ -0,0 +1,78 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithExportLabels(t *testing.T) {
+	ctx := WithExportLabels(context.Background(), map[string]string{"job_run": "1", "env": "staging"})
+	ctx = WithExportLabels(ctx, map[string]string{"env": "prod"})
+
+	assert.Equal(t, map[string]string{"job_run": "1", "env": "prod"}, exportLabelsFromContext(ctx))
+	assert.Nil(t, exportLabelsFromContext(context.Background()))
+}
+
+// TestExportWithExportLabels tests that the labels of the export context are added to every series of the batch.
+func TestExportWithExportLabels(t *testing.T) {
+	var labels []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		uncompressed, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		wr := &prompb.WriteRequest{}
+		require.NoError(t, wr.Unmarshal(uncompressed))
+
+		for _, ts := range wr.Timeseries {
+			l := map[string]string{}
+			for _, label := range ts.Labels {
+				l[label.Name] = label.Value
+			}
+			labels = append(labels, l)
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		ExternalLabels:        map[string]string{"env": "staging"},
+	})
+	require.NoError(t, err)
+
+	ctx := WithExportLabels(context.Background(), map[string]string{"job_run": "42", "env": "prod"})
+	require.NoError(t, exporter.Export(ctx, getSumMetric(5)))
+	require.Len(t, labels, 1)
+	assert.Equal(t, "42", labels[0]["job_run"])
+	assert.Equal(t, "prod", labels[0]["env"])
+
+	labels = nil
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+	require.Len(t, labels, 1)
+	assert.NotContains(t, labels[0], "job_run")
+	assert.Equal(t, "staging", labels[0]["env"])
+}
@@ -0,0 +1,45 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"time"
+)
+
+// checkExportTime logs a backpressure warning when an export took longer than the ExportTimeBudget, once until
+// an export completes within the budget again. Exports that keep exceeding the budget overlap with the next ones.
+func (e *Exporter) checkExportTime(elapsed time.Duration) {
+	budget := e.config.exportTimeBudget()
+	if budget == 0 {
+		return
+	}
+	if elapsed <= budget {
+		e.overBudget.Store(false)
+		return
+	}
+	if e.overBudget.CompareAndSwap(false, true) {
+		action := "set SplitSlowExports or AsyncQueueSize to prevent overlapping exports"
+		if e.config.SplitSlowExports {
+			action = "the next exports are sent in batches until their time budget is exceeded"
+		}
+		e.logger().Printf("Logz.io metrics exporter: export took %s, more than its time budget of %s: %s", elapsed, budget, action)
+	}
+}
+
+// splitExports reports whether the next export is sent in batches within its time budget, because the last
+// export exceeded it.
+func (e *Exporter) splitExports() bool {
+	return e.config.SplitSlowExports && e.overBudget.Load()
+}
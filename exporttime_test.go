@@ -0,0 +1,78 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckExportTime(t *testing.T) {
+	var logs bytes.Buffer
+	exporter := Exporter{config: Config{PushInterval: time.Second, ExportTimeBudget: 0.5, Logger: log.New(&logs, "", 0)}}
+
+	exporter.checkExportTime(400 * time.Millisecond)
+	assert.Empty(t, logs.String())
+
+	exporter.checkExportTime(600 * time.Millisecond)
+	exporter.checkExportTime(700 * time.Millisecond)
+	assert.Equal(t, 1, strings.Count(logs.String(), "more than its time budget of 500ms"), "the warning is logged once")
+	assert.False(t, exporter.splitExports(), "exports are only split with SplitSlowExports")
+
+	exporter.checkExportTime(100 * time.Millisecond)
+	exporter.checkExportTime(600 * time.Millisecond)
+	assert.Equal(t, 2, strings.Count(logs.String(), "more than its time budget"), "the warning is logged again after recovering")
+}
+
+// TestExportSplitSlowExports tests that an export following a slow export is sent in batches, and that the
+// batches left once its time budget is exceeded are not sent.
+func TestExportSplitSlowExports(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		time.Sleep(60 * time.Millisecond)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := Exporter{config: Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		PushInterval:          100 * time.Millisecond,
+		ExportTimeBudget:      0.5,
+		SplitSlowExports:      true,
+		Logger:                log.New(&bytes.Buffer{}, "", 0),
+	}}
+	rm := getSumMetricWithSeries(3 * lowMemoryBatchSize)
+
+	require.NoError(t, exporter.Export(context.Background(), rm))
+	assert.Equal(t, int32(1), requests.Load())
+	assert.True(t, exporter.splitExports())
+
+	err := exporter.Export(context.Background(), rm)
+	require.ErrorIs(t, err, ErrExportTimeBudgetExceeded)
+	assert.Contains(t, err.Error(), "1000 series not sent")
+	assert.Equal(t, int32(2), requests.Load())
+}
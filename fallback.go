@@ -0,0 +1,56 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"sync"
+	"time"
+)
+
+// outageTracker keeps the time of the first failed send of the current outage.
+type outageTracker struct {
+	mu    sync.Mutex
+	since time.Time
+}
+
+// logFallback tracks the outages of the Logz.io listener. Once the listener has been unreachable for longer than
+// FallbackAfter, it logs the series count and size of a message that failed to be sent, and reports true so the
+// message is dropped instead of being passed to the DeadLetterSink.
+func (e *Exporter) logFallback(sendErr error, series, bytes int, now time.Time) bool {
+	if e.config.FallbackAfter == 0 {
+		return false
+	}
+
+	e.outage.mu.Lock()
+	defer e.outage.mu.Unlock()
+	if sendErr == nil {
+		e.outage.since = time.Time{}
+		return false
+	}
+	if e.outage.since.IsZero() {
+		e.outage.since = now
+	}
+	outage := now.Sub(e.outage.since)
+	if outage < e.config.FallbackAfter {
+		return false
+	}
+
+	logger := e.config.FallbackLogger
+	if logger == nil {
+		logger = e.logger()
+	}
+	logger.Printf("Logz.io metrics listener unreachable for %s, dropped %d series (%d bytes): %v", outage, series, bytes, sendErr)
+	return true
+}
@@ -0,0 +1,48 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogFallback(t *testing.T) {
+	var logs bytes.Buffer
+	exporter := Exporter{config: Config{FallbackAfter: time.Minute, FallbackLogger: log.New(&logs, "", 0)}}
+	sendErr := errors.New("connection refused")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.False(t, exporter.logFallback(sendErr, 10, 100, now), "outage started")
+	assert.False(t, exporter.logFallback(sendErr, 10, 100, now.Add(30*time.Second)), "outage below the threshold")
+	assert.True(t, exporter.logFallback(sendErr, 10, 100, now.Add(time.Minute)))
+	assert.Equal(t, "Logz.io metrics listener unreachable for 1m0s, dropped 10 series (100 bytes): connection refused\n", logs.String())
+
+	assert.False(t, exporter.logFallback(nil, 10, 100, now.Add(2*time.Minute)), "outage ended")
+	assert.False(t, exporter.logFallback(sendErr, 10, 100, now.Add(3*time.Minute)), "new outage started")
+}
+
+func TestLogFallbackDisabled(t *testing.T) {
+	exporter := Exporter{}
+	now := time.Now()
+
+	assert.False(t, exporter.logFallback(errors.New("connection refused"), 1, 1, now))
+	assert.False(t, exporter.logFallback(errors.New("connection refused"), 1, 1, now.Add(time.Hour)))
+}
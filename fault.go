@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+// FaultStage is a stage of the export pipeline that a FaultInjector can fail.
+type FaultStage int
+
+const (
+	// FaultStageConversion is the conversion of the metrics to timeseries.
+	FaultStageConversion FaultStage = iota
+	// FaultStageCompression is the marshaling and compression of a message.
+	FaultStageCompression
+	// FaultStageSend is the request sending a message to Logz.io.
+	FaultStageSend
+)
+
+// FaultInjector injects failures into the export pipeline, so the alerting on metric pipeline failures can be
+// tested. It must not be set in production.
+type FaultInjector interface {
+	// InjectFault returns the error the stage fails with, or nil to run the stage normally.
+	InjectFault(stage FaultStage) error
+}
+
+// FaultInjectorFunc is an adapter to use a function as a FaultInjector.
+type FaultInjectorFunc func(stage FaultStage) error
+
+// InjectFault calls f(stage).
+func (f FaultInjectorFunc) InjectFault(stage FaultStage) error {
+	return f(stage)
+}
+
+// injectFault returns the error the configured FaultInjector fails the stage with.
+func (e *Exporter) injectFault(stage FaultStage) error {
+	if e.config.FaultInjector == nil {
+		return nil
+	}
+	return e.config.FaultInjector.InjectFault(stage)
+}
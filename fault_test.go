@@ -0,0 +1,53 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultInjector(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fault := errors.New("injected")
+	for _, stage := range []FaultStage{FaultStageConversion, FaultStageCompression, FaultStageSend} {
+		exporter, err := New(Config{
+			LogzioMetricsListener: server.URL,
+			LogzioMetricsToken:    "123456789a",
+			FaultInjector: FaultInjectorFunc(func(s FaultStage) error {
+				if s == stage {
+					return fault
+				}
+				return nil
+			}),
+		})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, exporter.Export(context.Background(), getSumMetric(5)), fault)
+	}
+	assert.Zero(t, requests)
+}
@@ -0,0 +1,100 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// FilterConfig selects the metrics and labels that are exported. Each rule is a regular expression matching
+// the whole Prometheus name of a metric or label, so a name without special characters is an exact match.
+type FilterConfig struct {
+	// KeepMetrics are the metrics that are exported. When empty, all metrics are exported.
+	KeepMetrics []string
+	// DropMetrics are the metrics that are not exported, even if they match KeepMetrics.
+	DropMetrics []string
+	// KeepLabels are the labels that are exported. When empty, all labels are exported.
+	KeepLabels []string
+	// DropLabels are the labels that are not exported, even if they match KeepLabels.
+	DropLabels []string
+}
+
+// seriesFilter holds the compiled rules of a FilterConfig. A nil rule matches nothing.
+type seriesFilter struct {
+	keepMetrics *regexp.Regexp
+	dropMetrics *regexp.Regexp
+	keepLabels  *regexp.Regexp
+	dropLabels  *regexp.Regexp
+}
+
+// compile returns the compiled rules of the filter config.
+func (c FilterConfig) compile() (seriesFilter, error) {
+	var filter seriesFilter
+	for _, rule := range []struct {
+		patterns []string
+		compiled **regexp.Regexp
+	}{
+		{c.KeepMetrics, &filter.keepMetrics},
+		{c.DropMetrics, &filter.dropMetrics},
+		{c.KeepLabels, &filter.keepLabels},
+		{c.DropLabels, &filter.dropLabels},
+	} {
+		if len(rule.patterns) == 0 {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + strings.Join(rule.patterns, "|") + ")$")
+		if err != nil {
+			return seriesFilter{}, err
+		}
+		*rule.compiled = re
+	}
+	return filter, nil
+}
+
+// keepMetric reports whether the metric is exported.
+func (f *seriesFilter) keepMetric(name string) bool {
+	if f.keepMetrics != nil && !f.keepMetrics.MatchString(name) {
+		return false
+	}
+	return f.dropMetrics == nil || !f.dropMetrics.MatchString(name)
+}
+
+// keepLabel reports whether the label is exported. The labels the series cannot be interpreted without are
+// always exported.
+func (f *seriesFilter) keepLabel(name string) bool {
+	if protectedLabelNames[name] {
+		return true
+	}
+	if f.keepLabels != nil && !f.keepLabels.MatchString(name) {
+		return false
+	}
+	return f.dropLabels == nil || !f.dropLabels.MatchString(name)
+}
+
+// filterSeriesLabels removes the labels that are not exported from the series.
+func (e *Exporter) filterSeriesLabels(timeseries []prompb.TimeSeries) {
+	if e.filter.keepLabels == nil && e.filter.dropLabels == nil {
+		return
+	}
+	for i := range timeseries {
+		timeseries[i].Labels = slices.DeleteFunc(timeseries[i].Labels, func(label prompb.Label) bool {
+			return !e.filter.keepLabel(label.Name)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestFilter(t *testing.T) {
+	rm := getSumMetric(5)
+	metrics := &rm.ScopeMetrics[0].Metrics
+	for _, name := range []string{"debug_cache_size", "requests"} {
+		*metrics = append(*metrics, metricdata.Metrics{
+			Name: name,
+			Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{{
+				Attributes: attribute.NewSet(attribute.String("http.url", "/users/42"), attribute.String("http.method", "GET")),
+				Time:       time.Now(),
+				Value:      1,
+			}}},
+		})
+	}
+
+	tests := []struct {
+		name        string
+		filter      FilterConfig
+		wantMetrics []string
+		wantLabels  []string
+		dropLabels  []string
+	}{
+		{
+			name:        "no filter",
+			wantMetrics: []string{"debug_cache_size", "metric_sum", "requests"},
+			wantLabels:  []string{"__name__", "http_method", "http_url", "service_name"},
+		},
+		{
+			name:        "drop",
+			filter:      FilterConfig{DropMetrics: []string{"debug_.*"}, DropLabels: []string{"http_url"}},
+			wantMetrics: []string{"metric_sum", "requests"},
+			wantLabels:  []string{"__name__", "http_method", "service_name"},
+			dropLabels:  []string{"http_url"},
+		},
+		{
+			name:        "keep",
+			filter:      FilterConfig{KeepMetrics: []string{"requests", "debug_.*"}, DropMetrics: []string{"debug_.*"}, KeepLabels: []string{"http_method"}},
+			wantMetrics: []string{"requests"},
+			wantLabels:  []string{"__name__", "http_method"},
+			dropLabels:  []string{"http_url", "service_name", "otel_scope_name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter, err := New(Config{LogzioMetricsToken: "123456789a", Filter: tt.filter})
+			require.NoError(t, err)
+			got, err := exporter.ConvertToTimeSeries(rm)
+			require.NoError(t, err)
+
+			var names []string
+			for _, ts := range got {
+				name := seriesName(ts.Labels)
+				names = append(names, name)
+				if name == "requests" {
+					var labels []string
+					for _, label := range ts.Labels {
+						labels = append(labels, label.Name)
+					}
+					assert.Subset(t, labels, tt.wantLabels)
+					for _, label := range tt.dropLabels {
+						assert.NotContains(t, labels, label)
+					}
+				}
+			}
+			assert.ElementsMatch(t, tt.wantMetrics, names)
+		})
+	}
+}
+
+func TestFilterConfigCompile(t *testing.T) {
+	_, err := FilterConfig{DropLabels: []string{"http_("}}.compile()
+	assert.Error(t, err)
+
+	filter, err := FilterConfig{DropMetrics: []string{"debug"}}.compile()
+	require.NoError(t, err)
+	assert.False(t, filter.keepMetric("debug"))
+	assert.True(t, filter.keepMetric("debug_total"), "rules match the whole name")
+}
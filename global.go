@@ -0,0 +1,89 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"sync"
+)
+
+// globalKey identifies the account the exporters returned by Global send to.
+type globalKey struct {
+	listener string
+	token    string
+}
+
+// globalExporter is an exporter shared by the callers of Global.
+type globalExporter struct {
+	exporter *Exporter
+	refs     int
+}
+
+var (
+	globalMu        sync.Mutex
+	globalExporters = map[globalKey]*globalExporter{}
+)
+
+// Global returns the exporter of the process sending to the Logz.io account of config, and creates it on the
+// first call. Libraries that each install the exporter share it instead of running parallel pipelines to the
+// same account. The configuration of the first call is used. Every call must be matched by a call to Release.
+func Global(config Config) (*Exporter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	key := globalKey{listener: config.LogzioMetricsListener, token: config.LogzioMetricsToken}
+
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	if global, ok := globalExporters[key]; ok {
+		global.refs++
+		return global.exporter, nil
+	}
+
+	exporter, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	exporter.globalKey = &key
+	globalExporters[key] = &globalExporter{exporter: exporter, refs: 1}
+	return exporter, nil
+}
+
+// Release releases an exporter returned by Global, and shuts it down when it is no longer used. Shutdown, e.g.
+// called by the reader of the exporter, does not release it, so that a caller that shuts its reader down and
+// calls Release only releases the exporter once. An exporter that was not returned by Global is shut down.
+func (e *Exporter) Release(ctx context.Context) error {
+	if e.globalKey != nil && !e.releaseGlobal() {
+		return nil
+	}
+	return e.shutdown(ctx)
+}
+
+// releaseGlobal releases an exporter returned by Global, and reports whether it is no longer used.
+func (e *Exporter) releaseGlobal() bool {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	global, ok := globalExporters[*e.globalKey]
+	if !ok || global.exporter != e {
+		return true
+	}
+	global.refs--
+	if global.refs > 0 {
+		return false
+	}
+	delete(globalExporters, *e.globalKey)
+	return true
+}
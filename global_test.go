@@ -0,0 +1,82 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobal(t *testing.T) {
+	config := Config{LogzioMetricsToken: "global-token"}
+
+	first, err := Global(config)
+	require.NoError(t, err)
+	second, err := Global(config)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	other, err := Global(Config{LogzioMetricsToken: "other-token"})
+	require.NoError(t, err)
+	assert.NotSame(t, first, other)
+	require.NoError(t, other.Release(context.Background()))
+
+	// The exporter is still used by the second caller.
+	require.NoError(t, first.Release(context.Background()))
+	third, err := Global(config)
+	require.NoError(t, err)
+	assert.Same(t, first, third)
+
+	require.NoError(t, second.Release(context.Background()))
+	require.NoError(t, third.Release(context.Background()))
+
+	// A new exporter is created once the previous one was released by all its callers.
+	fourth, err := Global(config)
+	require.NoError(t, err)
+	assert.NotSame(t, first, fourth)
+	require.NoError(t, fourth.Release(context.Background()))
+
+	_, err = Global(Config{})
+	assert.Equal(t, ErrNoLogzioMetricsToken, err)
+}
+
+func TestGlobalShutdownAndRelease(t *testing.T) {
+	config := Config{LogzioMetricsToken: "global-shutdown-token"}
+
+	first, err := Global(config)
+	require.NoError(t, err)
+	second, err := Global(config)
+	require.NoError(t, err)
+
+	// The first caller shuts its reader down, which shuts the exporter down, then releases it.
+	require.NoError(t, first.Shutdown(context.Background()))
+	require.NoError(t, first.Release(context.Background()))
+
+	// The exporter is still used by the second caller.
+	third, err := Global(config)
+	require.NoError(t, err)
+	assert.Same(t, second, third)
+	require.NoError(t, third.Release(context.Background()))
+
+	require.NoError(t, second.Shutdown(context.Background()))
+	require.NoError(t, second.Release(context.Background()))
+	fourth, err := Global(config)
+	require.NoError(t, err)
+	assert.NotSame(t, second, fourth)
+	require.NoError(t, fourth.Release(context.Background()))
+}
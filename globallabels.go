@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// globalLabelCache holds the global labels of the last exported resource, which rarely changes between exports.
+type globalLabelCache struct {
+	mu       sync.Mutex
+	resource attribute.Distinct
+	labels   map[string]string
+}
+
+// cachedGlobalLabels returns the global labels of the resource and the exporter, including the default instance
+// label. They are only generated when the resource differs from the one of the previous export, so the references
+// of the ExternalLabels to environment variables are expanded then. The labels are shared and must not be modified.
+func (e *Exporter) cachedGlobalLabels(res *resource.Resource) map[string]string {
+	key := res.Equivalent()
+
+	e.globalLabels.mu.Lock()
+	defer e.globalLabels.mu.Unlock()
+	if e.globalLabels.labels != nil && e.globalLabels.resource == key {
+		return e.globalLabels.labels
+	}
+
+	labels := generateGlobalLabels(res, e.config.ExternalLabels, e.config.copyResourceAttributes())
+	if e.config.AddInstanceLabel {
+		addDefaultInstanceLabel(labels, res, e.config.Instance)
+	}
+	e.globalLabels.resource = key
+	e.globalLabels.labels = labels
+	return labels
+}
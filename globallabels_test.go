@@ -0,0 +1,44 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestCachedGlobalLabels(t *testing.T) {
+	exporter := Exporter{config: Config{
+		ExternalLabels:   map[string]string{"team": "metrics"},
+		AddInstanceLabel: true,
+		Instance:         "replica-1",
+	}}
+	res := resource.NewSchemaless(attribute.String("service.name", "api"))
+
+	labels := exporter.cachedGlobalLabels(res)
+	assert.Equal(t, map[string]string{"service.name": "api", "team": "metrics", "instance": "replica-1"}, labels)
+
+	// An equivalent resource reuses the labels.
+	same := exporter.cachedGlobalLabels(resource.NewSchemaless(attribute.String("service.name", "api")))
+	assert.Equal(t, reflect.ValueOf(labels).Pointer(), reflect.ValueOf(same).Pointer())
+
+	other := exporter.cachedGlobalLabels(resource.NewSchemaless(attribute.String("service.name", "worker")))
+	assert.Equal(t, "worker", other["service.name"])
+	assert.Equal(t, "api", labels["service.name"], "the labels of the previous resource are not modified")
+}
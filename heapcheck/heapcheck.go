@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package heapcheck records the live heap of a process across the rounds of a workload to check that its memory
+// stabilizes, e.g. under constant series churn, and writes heap profiles to investigate the memory it retains.
+// It only depends on the standard library, so it can be used by the tests of any consumer of the exporter.
+package heapcheck
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+)
+
+// Recorder records the live heap after the rounds of a workload.
+type Recorder struct {
+	// Samples are the live heap bytes recorded by every call to Record.
+	Samples []uint64
+}
+
+// Record runs a garbage collection and records the live heap.
+func (r *Recorder) Record() {
+	r.Samples = append(r.Samples, liveHeap())
+}
+
+// Growth returns the growth of the live heap over the second half of the samples, as a fraction of the heap at
+// the middle sample. The first half of the samples warms up the caches and pools of the workload.
+func (r *Recorder) Growth() float64 {
+	if len(r.Samples) < 2 {
+		return 0
+	}
+	middle := len(r.Samples) / 2
+	baseline := r.Samples[middle]
+	if baseline == 0 {
+		return 0
+	}
+	return (float64(slices.Max(r.Samples[middle:])) - float64(baseline)) / float64(baseline)
+}
+
+// Stable reports whether the live heap grew by at most tolerance over the second half of the samples.
+func (r *Recorder) Stable(tolerance float64) bool {
+	return r.Growth() <= tolerance
+}
+
+// WriteHeapProfile runs a garbage collection and writes a heap profile to the file at path, which can be
+// inspected with go tool pprof.
+func WriteHeapProfile(path string) error {
+	runtime.GC()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// liveHeap returns the bytes of the heap objects that are still reachable.
+func liveHeap() uint64 {
+	// The second collection frees the objects whose finalizers ran in the first one.
+	runtime.GC()
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
@@ -0,0 +1,59 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heapcheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	var steady Recorder
+	for i := 0; i < 10; i++ {
+		// The buffer is garbage after every round.
+		_ = make([]byte, 1<<20)
+		steady.Record()
+	}
+	assert.Len(t, steady.Samples, 10)
+	assert.True(t, steady.Stable(0.5), "growth %v", steady.Growth())
+
+	var retained [][]byte
+	var leaking Recorder
+	for i := 0; i < 10; i++ {
+		retained = append(retained, make([]byte, 1<<20))
+		leaking.Record()
+	}
+	assert.False(t, leaking.Stable(0.5), "growth %v", leaking.Growth())
+	assert.Len(t, retained, 10)
+}
+
+func TestGrowth(t *testing.T) {
+	assert.Zero(t, (&Recorder{}).Growth())
+	assert.Equal(t, 0.5, (&Recorder{Samples: []uint64{50, 100, 100, 150, 120}}).Growth())
+	assert.Equal(t, float64(0), (&Recorder{Samples: []uint64{300, 100, 100, 90}}).Growth())
+}
+
+func TestWriteHeapProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heap.pprof")
+	require.NoError(t, WriteHeapProfile(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Positive(t, info.Size())
+}
@@ -0,0 +1,260 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var quantileLabelName = "quantile"
+
+// convertHistogram reduces the histogram buckets and converts the histogram to timeseries according to the
+// configured quantiles mode.
+func convertHistogram[N int64 | float64](metricName string, histogram metricdata.Histogram[N], labels map[string]string, config Config, arena *seriesArena) ([]prompb.TimeSeries, error) {
+	histogram = reduceHistogramBuckets(histogram, config.MaxHistogramBuckets)
+
+	timeSeries, err := convertFromHistogram(metricName, histogram, labels, config.HistogramQuantiles != HistogramQuantilesOnly, !config.LowMemory, config.exemplarLabelNames(), config.UTF8Names, arena)
+	if err != nil {
+		return nil, err
+	}
+	if config.HistogramQuantiles != HistogramQuantilesDisabled {
+		timeSeries = append(timeSeries, convertQuantilesFromHistogram(metricName, histogram, labels, config.Quantiles, config.UTF8Names, arena)...)
+	}
+	return timeSeries, nil
+}
+
+// convertQuantilesFromHistogram returns a gauge timeseries per datapoint and quantile, holding the quantile
+// approximated from the datapoint buckets.
+func convertQuantilesFromHistogram[N int64 | float64](metricName string, histogram metricdata.Histogram[N], labels map[string]string, quantiles []float64, utf8Names bool, arena *seriesArena) []prompb.TimeSeries {
+	var timeSeries []prompb.TimeSeries
+	b := getLabelBuilder(labels, utf8Names, arena)
+	defer b.release()
+
+	for _, dp := range histogram.DataPoints {
+		if dp.Count == 0 {
+			continue
+		}
+		b.setDataPoint(metricName, dp.Attributes)
+		for _, q := range quantiles {
+			b.set(quantileLabelName, strconv.FormatFloat(q, 'g', -1, 64))
+			timeSeries = append(timeSeries, b.series(bucketQuantile(q, dp.Bounds, dp.BucketCounts), dp.Time, nil))
+		}
+	}
+	return timeSeries
+}
+
+// bucketQuantile approximates the q quantile from explicit histogram buckets by linear interpolation within
+// the bucket holding the quantile rank, like the PromQL histogram_quantile function. The lower bound of the
+// first bucket is assumed to be 0 when its upper bound is positive, and a quantile falling into the overflow
+// bucket returns the highest finite bound.
+func bucketQuantile(q float64, bounds []float64, counts []uint64) float64 {
+	var total uint64
+	for _, count := range counts {
+		total += count
+	}
+	if total == 0 || len(bounds) == 0 {
+		return math.NaN()
+	}
+
+	rank := q * float64(total)
+	var cumulative float64
+	for i, count := range counts {
+		if i >= len(bounds) {
+			break
+		}
+		if cumulative+float64(count) < rank || count == 0 {
+			cumulative += float64(count)
+			continue
+		}
+
+		upper := bounds[i]
+		lower := 0.0
+		if i > 0 {
+			lower = bounds[i-1]
+		} else if upper <= 0 {
+			return upper
+		}
+		return lower + (upper-lower)*(rank-cumulative)/float64(count)
+	}
+	return bounds[len(bounds)-1]
+}
+
+// reduceHistogramBuckets merges adjacent buckets of every datapoint so that no datapoint has more than
+// maxBuckets buckets, including the +Inf bucket. A maxBuckets of 0 leaves the histogram unchanged.
+func reduceHistogramBuckets[N int64 | float64](histogram metricdata.Histogram[N], maxBuckets int) metricdata.Histogram[N] {
+	if maxBuckets <= 0 {
+		return histogram
+	}
+
+	dataPoints := make([]metricdata.HistogramDataPoint[N], len(histogram.DataPoints))
+	for i, dp := range histogram.DataPoints {
+		dp.Bounds, dp.BucketCounts = mergeBuckets(dp.Bounds, dp.BucketCounts, maxBuckets)
+		dataPoints[i] = dp
+	}
+	histogram.DataPoints = dataPoints
+	return histogram
+}
+
+// mergeBuckets merges adjacent buckets into groups of equal size until at most maxBuckets remain.
+// Each merged bucket keeps the upper bound of its last member and the sum of its members' counts, so the
+// cumulative count at every retained bound is unchanged. The overflow bucket is always merged into the last
+// group, which keeps the +Inf bucket intact.
+func mergeBuckets(bounds []float64, counts []uint64, maxBuckets int) ([]float64, []uint64) {
+	if len(counts) <= maxBuckets {
+		return bounds, counts
+	}
+
+	groupSize := (len(counts) + maxBuckets - 1) / maxBuckets
+	mergedBounds := make([]float64, 0, maxBuckets)
+	mergedCounts := make([]uint64, 0, maxBuckets)
+
+	var groupCount uint64
+	for i, count := range counts {
+		groupCount += count
+		if (i+1)%groupSize != 0 && i != len(counts)-1 {
+			continue
+		}
+		if i < len(bounds) {
+			mergedBounds = append(mergedBounds, bounds[i])
+		}
+		mergedCounts = append(mergedCounts, groupCount)
+		groupCount = 0
+	}
+	return mergedBounds, mergedCounts
+}
+
+// mergeHistogramMetrics returns the metrics with the datapoints of histograms sharing a name and type appended to
+// the first of them, e.g. when views yield the same histogram twice in a scope, so that their datapoints with
+// identical attributes are merged by mergeHistogramDataPoints instead of producing conflicting series. The metrics
+// are not modified.
+func mergeHistogramMetrics(metrics []metricdata.Metrics) []metricdata.Metrics {
+	var merged []metricdata.Metrics
+	first := map[string]int{}
+	for i, m := range metrics {
+		if !isHistogram(m.Data) {
+			if merged != nil {
+				merged = append(merged, m)
+			}
+			continue
+		}
+
+		j, seen := first[m.Name]
+		if !seen {
+			if merged != nil {
+				first[m.Name] = len(merged)
+				merged = append(merged, m)
+			} else {
+				first[m.Name] = i
+			}
+			continue
+		}
+
+		if merged == nil {
+			merged = slices.Clone(metrics[:i])
+		}
+		if data, ok := appendHistogramDataPoints(merged[j].Data, m.Data); ok {
+			merged[j].Data = data
+		} else {
+			merged = append(merged, m)
+		}
+	}
+
+	if merged == nil {
+		return metrics
+	}
+	return merged
+}
+
+// isHistogram returns true when the data is a histogram.
+func isHistogram(data metricdata.Aggregation) bool {
+	switch data.(type) {
+	case metricdata.Histogram[int64], metricdata.Histogram[float64]:
+		return true
+	default:
+		return false
+	}
+}
+
+// appendHistogramDataPoints returns the histogram a with the datapoints of the histogram b appended, when both
+// have the same type and temporality.
+func appendHistogramDataPoints(a, b metricdata.Aggregation) (metricdata.Aggregation, bool) {
+	switch a := a.(type) {
+	case metricdata.Histogram[int64]:
+		if b, ok := b.(metricdata.Histogram[int64]); ok && a.Temporality == b.Temporality {
+			a.DataPoints = append(slices.Clip(a.DataPoints), b.DataPoints...)
+			return a, true
+		}
+	case metricdata.Histogram[float64]:
+		if b, ok := b.(metricdata.Histogram[float64]); ok && a.Temporality == b.Temporality {
+			a.DataPoints = append(slices.Clip(a.DataPoints), b.DataPoints...)
+			return a, true
+		}
+	}
+	return a, false
+}
+
+// mergeHistogramDataPoints merges the datapoints of the histogram with identical attributes and bucket
+// boundaries, which would otherwise be exported as conflicting series. The datapoints are not modified.
+func mergeHistogramDataPoints[N int64 | float64](histogram metricdata.Histogram[N]) metricdata.Histogram[N] {
+	if len(histogram.DataPoints) < 2 {
+		return histogram
+	}
+
+	dataPoints := make([]metricdata.HistogramDataPoint[N], 0, len(histogram.DataPoints))
+	index := make(map[attribute.Distinct]int, len(histogram.DataPoints))
+	for _, dp := range histogram.DataPoints {
+		key := dp.Attributes.Equivalent()
+		if i, ok := index[key]; ok && slices.Equal(dataPoints[i].Bounds, dp.Bounds) {
+			dataPoints[i] = mergeHistogramDataPoint(dataPoints[i], dp)
+			continue
+		}
+		index[key] = len(dataPoints)
+		dataPoints = append(dataPoints, dp)
+	}
+
+	if len(dataPoints) < len(histogram.DataPoints) {
+		histogram.DataPoints = dataPoints
+	}
+	return histogram
+}
+
+// mergeHistogramDataPoint returns the datapoint a with the counts, sum, extrema and exemplars of b added to it,
+// covering the time range of both.
+func mergeHistogramDataPoint[N int64 | float64](a, b metricdata.HistogramDataPoint[N]) metricdata.HistogramDataPoint[N] {
+	a.BucketCounts = slices.Clone(a.BucketCounts)
+	for i := range a.BucketCounts {
+		if i < len(b.BucketCounts) {
+			a.BucketCounts[i] += b.BucketCounts[i]
+		}
+	}
+	a.Count += b.Count
+	a.Sum += b.Sum
+	a.Min = mergeExtrema(a.Min, b.Min, func(x, y N) bool { return x < y })
+	a.Max = mergeExtrema(a.Max, b.Max, func(x, y N) bool { return x > y })
+	if !b.StartTime.IsZero() && (a.StartTime.IsZero() || b.StartTime.Before(a.StartTime)) {
+		a.StartTime = b.StartTime
+	}
+	if b.Time.After(a.Time) {
+		a.Time = b.Time
+	}
+	a.Exemplars = append(slices.Clip(a.Exemplars), b.Exemplars...)
+	return a
+}
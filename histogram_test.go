@@ -0,0 +1,197 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMergeBuckets(t *testing.T) {
+	tests := []struct {
+		name       string
+		bounds     []float64
+		counts     []uint64
+		maxBuckets int
+		wantBounds []float64
+		wantCounts []uint64
+	}{
+		{
+			name:       "below limit",
+			bounds:     []float64{1, 2},
+			counts:     []uint64{1, 2, 3},
+			maxBuckets: 3,
+			wantBounds: []float64{1, 2},
+			wantCounts: []uint64{1, 2, 3},
+		},
+		{
+			name:       "even groups",
+			bounds:     []float64{1, 2, 3, 4, 5},
+			counts:     []uint64{1, 1, 1, 1, 1, 1},
+			maxBuckets: 3,
+			wantBounds: []float64{2, 4},
+			wantCounts: []uint64{2, 2, 2},
+		},
+		{
+			name:       "uneven groups keep the +Inf bucket",
+			bounds:     []float64{1, 2, 3, 4, 5, 6},
+			counts:     []uint64{1, 2, 3, 4, 5, 6, 7},
+			maxBuckets: 3,
+			wantBounds: []float64{3, 6},
+			wantCounts: []uint64{6, 15, 7},
+		},
+		{
+			name:       "single finite bucket",
+			bounds:     []float64{1, 2, 3},
+			counts:     []uint64{1, 2, 3, 4},
+			maxBuckets: 2,
+			wantBounds: []float64{2},
+			wantCounts: []uint64{3, 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBounds, gotCounts := mergeBuckets(tt.bounds, tt.counts, tt.maxBuckets)
+			assert.Equal(t, tt.wantBounds, gotBounds)
+			assert.Equal(t, tt.wantCounts, gotCounts)
+		})
+	}
+}
+
+func TestReduceHistogramBucketsDoesNotModifyInput(t *testing.T) {
+	histogram := metricdata.Histogram[int64]{
+		DataPoints: []metricdata.HistogramDataPoint[int64]{
+			{
+				Bounds:       []float64{1, 2, 3},
+				BucketCounts: []uint64{1, 1, 1, 1},
+				Count:        4,
+			},
+		},
+	}
+
+	got := reduceHistogramBuckets(histogram, 2)
+	assert.Equal(t, []float64{2}, got.DataPoints[0].Bounds)
+	assert.Equal(t, []uint64{2, 2}, got.DataPoints[0].BucketCounts)
+	assert.Equal(t, uint64(4), got.DataPoints[0].Count)
+	assert.Equal(t, []float64{1, 2, 3}, histogram.DataPoints[0].Bounds)
+}
+
+func TestBucketQuantile(t *testing.T) {
+	bounds := []float64{1, 2, 4}
+	counts := []uint64{2, 2, 4, 2}
+
+	tests := []struct {
+		name     string
+		quantile float64
+		want     float64
+	}{
+		{name: "first bucket", quantile: 0.1, want: 0.5},
+		{name: "bucket boundary", quantile: 0.4, want: 2},
+		{name: "interpolated", quantile: 0.6, want: 3},
+		{name: "overflow bucket", quantile: 0.95, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, bucketQuantile(tt.quantile, bounds, counts), 1e-9)
+		})
+	}
+	assert.True(t, math.IsNaN(bucketQuantile(0.5, bounds, []uint64{0, 0, 0, 0})))
+}
+
+func TestConvertHistogramQuantilesOnly(t *testing.T) {
+	histogram := metricdata.Histogram[float64]{
+		DataPoints: []metricdata.HistogramDataPoint[float64]{
+			{
+				Bounds:       []float64{1, 2},
+				BucketCounts: []uint64{1, 1, 0},
+				Count:        2,
+				Sum:          2.5,
+			},
+		},
+	}
+	config := Config{Quantiles: []float64{0.5, 0.99}, HistogramQuantiles: HistogramQuantilesOnly}
+
+	got, err := convertHistogram("latency", histogram, map[string]string{}, config, nil)
+	require.NoError(t, err)
+
+	quantiles := make(map[string]float64)
+	for _, ts := range got {
+		for _, label := range ts.Labels {
+			assert.NotEqual(t, "le", label.Name)
+			if label.Name == "quantile" {
+				quantiles[label.Value] = ts.Samples[0].Value
+			}
+		}
+	}
+	assert.Len(t, got, 4)
+	assert.InDelta(t, 1, quantiles["0.5"], 1e-9)
+	assert.InDelta(t, 1.98, quantiles["0.99"], 1e-9)
+}
+
+func TestMergeHistogramDataPoints(t *testing.T) {
+	start := time.Unix(100, 0)
+	attrs := attribute.NewSet(attribute.String("route", "/"))
+	histogram := metricdata.Histogram[int64]{
+		DataPoints: []metricdata.HistogramDataPoint[int64]{
+			{Attributes: attrs, StartTime: start, Time: start.Add(time.Second), Bounds: []float64{1, 2}, BucketCounts: []uint64{1, 0, 1}, Count: 2, Sum: 3, Max: metricdata.NewExtrema[int64](2)},
+			{Attributes: attribute.NewSet(), Bounds: []float64{1, 2}, BucketCounts: []uint64{1, 0, 0}, Count: 1, Sum: 1},
+			{Attributes: attrs, StartTime: start.Add(-time.Second), Time: start.Add(2 * time.Second), Bounds: []float64{1, 2}, BucketCounts: []uint64{0, 1, 1}, Count: 2, Sum: 5, Max: metricdata.NewExtrema[int64](3)},
+			{Attributes: attrs, Bounds: []float64{5}, BucketCounts: []uint64{1, 0}, Count: 1, Sum: 1},
+		},
+	}
+
+	got := mergeHistogramDataPoints(histogram)
+	require.Len(t, got.DataPoints, 3)
+	merged := got.DataPoints[0]
+	assert.Equal(t, []uint64{1, 1, 2}, merged.BucketCounts)
+	assert.Equal(t, uint64(4), merged.Count)
+	assert.Equal(t, int64(8), merged.Sum)
+	assert.Equal(t, metricdata.NewExtrema[int64](3), merged.Max)
+	assert.Equal(t, start.Add(-time.Second), merged.StartTime)
+	assert.Equal(t, start.Add(2*time.Second), merged.Time)
+	assert.Equal(t, []float64{5}, got.DataPoints[2].Bounds, "datapoints with other boundaries are kept")
+	assert.Equal(t, []uint64{1, 0, 1}, histogram.DataPoints[0].BucketCounts, "the input is not modified")
+}
+
+// TestConvertToTimeSeriesMergesDuplicateHistograms tests that a histogram exported twice in a scope is
+// converted to a single set of series.
+func TestConvertToTimeSeriesMergesDuplicateHistograms(t *testing.T) {
+	exporter := Exporter{}
+	single, err := exporter.ConvertToTimeSeries(getHistogramMetric(1, metricdata.NewExtrema[int64](4), metricdata.NewExtrema[int64](4), 4))
+	require.NoError(t, err)
+
+	rm := getHistogramMetric(1, metricdata.NewExtrema[int64](4), metricdata.NewExtrema[int64](4), 4)
+	rm.ScopeMetrics[0].Metrics = append(rm.ScopeMetrics[0].Metrics, rm.ScopeMetrics[0].Metrics[0])
+	got, err := exporter.ConvertToTimeSeries(rm)
+	require.NoError(t, err)
+	require.Len(t, got, len(single))
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 2, "the input is not modified")
+
+	for _, ts := range got {
+		for _, label := range ts.Labels {
+			if label.Name == "__name__" && label.Value == "metric_histogram_count" {
+				assert.Equal(t, float64(2), ts.Samples[0].Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package loadgen drives the exporter with synthetic load against a fake listener and reports throughput,
+// allocations and drop rates. It is used by soak and stress tests of the exporter.
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/attribute"
+	m "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	metricsExporter "github.com/logzio/go-metrics-sdk"
+)
+
+// Config describes the synthetic load.
+type Config struct {
+	// Counters and Histograms are the number of instruments of each kind.
+	Counters   int
+	Histograms int
+	// Cardinality is the number of distinct attribute sets recorded per instrument.
+	Cardinality int
+	// Exports is the number of collect and export cycles.
+	Exports int
+	// Churn replaces the attribute sets with new ones on every export, so every export records new series.
+	Churn bool
+	// FailureRate is the fraction of requests the fake listener rejects with a 503.
+	FailureRate float64
+	// AfterExport is called after every export, e.g. to record the live heap.
+	AfterExport func(export int)
+	// Exporter is the exporter configuration. The listener and token are set by Run.
+	Exporter metricsExporter.Config
+}
+
+// Report holds the results of a run.
+type Report struct {
+	Exports        int
+	FailedExports  int
+	SeriesExported int
+	SeriesReceived int
+	Requests       int
+	BytesReceived  int
+	Duration       time.Duration
+	Mallocs        uint64
+	AllocBytes     uint64
+}
+
+// SeriesPerSecond returns the number of series exported per second.
+func (r Report) SeriesPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.SeriesExported) / r.Duration.Seconds()
+}
+
+// DropRate returns the fraction of exported series that were not received by the listener.
+func (r Report) DropRate() float64 {
+	if r.SeriesExported == 0 {
+		return 0
+	}
+	return 1 - float64(r.SeriesReceived)/float64(r.SeriesExported)
+}
+
+// String returns a one-line summary of the report.
+func (r Report) String() string {
+	return fmt.Sprintf("exports=%d failed=%d series=%d received=%d requests=%d bytes=%d duration=%s series/s=%.0f mallocs=%d alloc_bytes=%d drop_rate=%.4f",
+		r.Exports, r.FailedExports, r.SeriesExported, r.SeriesReceived, r.Requests, r.BytesReceived, r.Duration,
+		r.SeriesPerSecond(), r.Mallocs, r.AllocBytes, r.DropRate())
+}
+
+// listener is a fake Logz.io listener that decodes requests and counts the received series.
+type listener struct {
+	mu          sync.Mutex
+	failureRate float64
+	requests    int
+	series      int
+	bytes       int
+}
+
+func (l *listener) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	compressed, err := io.ReadAll(req.Body)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests++
+	if rand.Float64() < l.failureRate {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	uncompressed, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	wr := &prompb.WriteRequest{}
+	if err := wr.Unmarshal(uncompressed); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	l.series += len(wr.Timeseries)
+	l.bytes += len(compressed)
+	rw.WriteHeader(http.StatusOK)
+}
+
+// Run records the synthetic load with the OpenTelemetry SDK and exports it Exports times to a fake listener.
+func Run(ctx context.Context, config Config) (Report, error) {
+	fake := &listener{failureRate: config.FailureRate}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	exporterConfig := config.Exporter
+	exporterConfig.LogzioMetricsListener = server.URL
+	exporterConfig.LogzioMetricsToken = "loadgen"
+	exporter, err := metricsExporter.New(exporterConfig)
+	if err != nil {
+		return Report{}, err
+	}
+	defer exporter.Shutdown(ctx)
+
+	reader := metric.NewManualReader(metric.WithTemporalitySelector(exporter.Temporality))
+	provider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer provider.Shutdown(ctx)
+	meter := provider.Meter("loadgen")
+
+	attributeSets := make([]m.MeasurementOption, config.Cardinality)
+	setAttributes := func(export int) {
+		for i := range attributeSets {
+			attributeSets[i] = m.WithAttributeSet(attribute.NewSet(attribute.Int("series", export*config.Cardinality+i)))
+		}
+	}
+	setAttributes(0)
+
+	counters := make([]m.Int64Counter, config.Counters)
+	for i := range counters {
+		if counters[i], err = meter.Int64Counter(fmt.Sprintf("loadgen_counter_%d", i)); err != nil {
+			return Report{}, err
+		}
+	}
+	histograms := make([]m.Float64Histogram, config.Histograms)
+	for i := range histograms {
+		if histograms[i], err = meter.Float64Histogram(fmt.Sprintf("loadgen_histogram_%d", i)); err != nil {
+			return Report{}, err
+		}
+	}
+
+	var report Report
+	var before, after runtime.MemStats
+	var counting time.Duration
+	var countingMallocs, countingBytes uint64
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	for export := 0; export < config.Exports; export++ {
+		if config.Churn && export > 0 {
+			setAttributes(export)
+		}
+		for _, set := range attributeSets {
+			for _, counter := range counters {
+				counter.Add(ctx, 1, set)
+			}
+			for _, histogram := range histograms {
+				histogram.Record(ctx, rand.Float64()*1000, set)
+			}
+		}
+
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			return Report{}, err
+		}
+		// The series of every export are counted, since with churn and cumulative temporality the attribute sets of
+		// the previous cycles stay in the export. ConvertToTimeSeries updates no exporter state, so the metrics are
+		// exported as if they were not counted, and the cost of counting is left out of the measurements.
+		series, cost, err := countSeries(exporter, &rm)
+		if err != nil {
+			return Report{}, err
+		}
+		report.SeriesExported += series
+		counting += cost.duration
+		countingMallocs += cost.mallocs
+		countingBytes += cost.bytes
+		if err := exporter.Export(ctx, &rm); err != nil {
+			report.FailedExports++
+		}
+		report.Exports++
+		if config.AfterExport != nil {
+			config.AfterExport(export)
+		}
+	}
+	if err := exporter.ForceFlush(ctx); err != nil {
+		return Report{}, err
+	}
+
+	report.Duration = time.Since(start) - counting
+	runtime.ReadMemStats(&after)
+	report.Mallocs = after.Mallocs - before.Mallocs - countingMallocs
+	report.AllocBytes = after.TotalAlloc - before.TotalAlloc - countingBytes
+
+	fake.mu.Lock()
+	report.Requests = fake.requests
+	report.SeriesReceived = fake.series
+	report.BytesReceived = fake.bytes
+	fake.mu.Unlock()
+
+	return report, nil
+}
+
+// countingCost is the time and allocations spent counting the series of an export.
+type countingCost struct {
+	duration time.Duration
+	mallocs  uint64
+	bytes    uint64
+}
+
+// countSeries returns the number of series the exporter converts the metrics to, and the cost of counting them.
+func countSeries(exporter *metricsExporter.Exporter, rm *metricdata.ResourceMetrics) (int, countingCost, error) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	series, err := exporter.ConvertToTimeSeries(rm)
+	duration := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return len(series), countingCost{
+		duration: duration,
+		mallocs:  after.Mallocs - before.Mallocs,
+		bytes:    after.TotalAlloc - before.TotalAlloc,
+	}, err
+}
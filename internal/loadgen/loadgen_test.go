@@ -0,0 +1,143 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	metricsExporter "github.com/logzio/go-metrics-sdk"
+	"github.com/logzio/go-metrics-sdk/heapcheck"
+)
+
+func TestRun(t *testing.T) {
+	report, err := Run(context.Background(), Config{
+		Counters:    2,
+		Histograms:  1,
+		Cardinality: 10,
+		Exports:     3,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Exports)
+	assert.Equal(t, 3, report.Requests)
+	assert.Zero(t, report.FailedExports)
+	assert.Positive(t, report.SeriesExported)
+	assert.Equal(t, report.SeriesExported, report.SeriesReceived)
+	assert.Zero(t, report.DropRate())
+	assert.Positive(t, report.SeriesPerSecond())
+}
+
+func TestRunWithFailures(t *testing.T) {
+	report, err := Run(context.Background(), Config{
+		Counters:    1,
+		Cardinality: 1,
+		Exports:     5,
+		FailureRate: 1,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.FailedExports)
+	assert.Equal(t, float64(1), report.DropRate())
+}
+
+// TestSoak runs a long, high-cardinality load. It only runs when LOADGEN_SOAK is set.
+func TestSoak(t *testing.T) {
+	if os.Getenv("LOADGEN_SOAK") == "" {
+		t.Skip("set LOADGEN_SOAK to run the soak test")
+	}
+
+	report, err := Run(context.Background(), Config{
+		Counters:    50,
+		Histograms:  20,
+		Cardinality: 200,
+		Exports:     100,
+	})
+	require.NoError(t, err)
+	t.Log(report)
+	assert.Zero(t, report.DropRate())
+}
+
+func TestRunWithChurn(t *testing.T) {
+	exports := 0
+	report, err := Run(context.Background(), Config{
+		Counters:    1,
+		Cardinality: 5,
+		Exports:     3,
+		Churn:       true,
+		AfterExport: func(int) { exports++ },
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, exports)
+	assert.Equal(t, report.SeriesExported, report.SeriesReceived)
+}
+
+// TestLeak checks that the memory of the exporter stabilizes when every export records new series. It only
+// runs when LOADGEN_SOAK is set, and writes a heap profile to investigate the retained memory when it fails.
+func TestLeak(t *testing.T) {
+	if os.Getenv("LOADGEN_SOAK") == "" {
+		t.Skip("set LOADGEN_SOAK to run the leak test")
+	}
+
+	const cardinality = 100
+	var heap heapcheck.Recorder
+	_, err := Run(context.Background(), Config{
+		Counters:    10,
+		Histograms:  5,
+		Cardinality: cardinality,
+		Exports:     400,
+		Churn:       true,
+		Exporter: metricsExporter.Config{
+			Temporality: map[metric.InstrumentKind]metricdata.Temporality{
+				metric.InstrumentKindCounter:   metricdata.DeltaTemporality,
+				metric.InstrumentKindHistogram: metricdata.DeltaTemporality,
+			},
+			// The running totals of the delta series are the only state that grows with churn.
+			MaxTrackedSeries: 15 * cardinality,
+		},
+		AfterExport: func(export int) {
+			if export%20 == 0 {
+				heap.Record()
+			}
+		},
+	})
+	require.NoError(t, err)
+
+	if !heap.Stable(0.1) {
+		path := filepath.Join(os.TempDir(), "loadgen-leak.pprof")
+		require.NoError(t, heapcheck.WriteHeapProfile(path))
+		t.Fatalf("the live heap grew by %.0f%%, see the heap profile in %s: %v", heap.Growth()*100, path, heap.Samples)
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, err := Run(context.Background(), Config{
+			Counters:    10,
+			Histograms:  5,
+			Cardinality: 50,
+			Exports:     1,
+		})
+		require.NoError(b, err)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// DefaultJobRetryAttempts is the RetryConfig MaxAttempts used in JobMode when it is not set.
+const DefaultJobRetryAttempts = 3
+
+// FinishJob collects and exports all the metrics of the reader returned by NewManualReader, then shuts the
+// exporter down, for short-lived jobs that push once at exit. It returns an error when the metrics could not be
+// delivered, so that the job can fail, e.g. with a non-zero exit status.
+func (e *Exporter) FinishJob(ctx context.Context) error {
+	var result *multierror.Error
+	if err := e.CollectAndExport(ctx); err != nil {
+		result = multierror.Append(result, err)
+	}
+	if err := e.Shutdown(ctx); err != nil {
+		result = multierror.Append(result, err)
+	}
+	return result.ErrorOrNil()
+}
@@ -0,0 +1,70 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestFinishJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "delivered", status: http.StatusNoContent, wantRequests: 1},
+		{name: "retried and failed", status: http.StatusServiceUnavailable, wantRequests: DefaultJobRetryAttempts, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				requests++
+				rw.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			exporter, err := New(Config{
+				LogzioMetricsListener: server.URL,
+				LogzioMetricsToken:    "123456789a",
+				JobMode:               true,
+				Retry:                 RetryConfig{InitialBackoff: time.Millisecond},
+			})
+			require.NoError(t, err)
+			provider := metric.NewMeterProvider(metric.WithReader(exporter.NewManualReader()))
+			counter, err := provider.Meter("test").Int64Counter("processed")
+			require.NoError(t, err)
+			counter.Add(context.Background(), 1)
+
+			err = exporter.FinishJob(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
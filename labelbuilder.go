@@ -0,0 +1,136 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// labelBuilderPool reuses labelBuilders across conversions.
+var labelBuilderPool = sync.Pool{
+	New: func() any { return new(labelBuilder) },
+}
+
+// labelBuilder builds the label sets of the series of datapoints from a slice of label pairs, so the labels
+// are not copied into new maps for every datapoint and series. The pairs hold the label names before they
+// are sanitized. The built labels and samples are allocated from the arena.
+type labelBuilder struct {
+	base      []prompb.Label
+	pairs     []prompb.Label
+	sorted    []prompb.Label
+	arena     *seriesArena
+	utf8Names bool
+}
+
+// getLabelBuilder returns a pooled labelBuilder holding the labels, which allocates from the arena. The label
+// names are kept as UTF-8 names when utf8Names is set. The builder must be released after use.
+func getLabelBuilder(labels map[string]string, utf8Names bool, arena *seriesArena) *labelBuilder {
+	b := labelBuilderPool.Get().(*labelBuilder)
+	b.arena = arena
+	b.utf8Names = utf8Names
+	for name, value := range labels {
+		b.base = append(b.base, prompb.Label{Name: name, Value: value})
+	}
+	b.reset()
+	return b
+}
+
+// release returns the builder to the pool.
+func (b *labelBuilder) release() {
+	clear(b.base)
+	clear(b.pairs)
+	clear(b.sorted)
+	b.base, b.pairs, b.sorted = b.base[:0], b.pairs[:0], b.sorted[:0]
+	b.arena = nil
+	labelBuilderPool.Put(b)
+}
+
+// reset drops the labels set since the builder was created.
+func (b *labelBuilder) reset() {
+	b.pairs = append(b.pairs[:0], b.base...)
+}
+
+// set sets the value of a label, replacing its previous value.
+func (b *labelBuilder) set(name, value string) {
+	for i := range b.pairs {
+		if b.pairs[i].Name == name {
+			b.pairs[i].Value = value
+			return
+		}
+	}
+	b.pairs = append(b.pairs, prompb.Label{Name: name, Value: value})
+}
+
+// setDataPoint resets the builder and sets the metric name and the datapoint attributes.
+func (b *labelBuilder) setDataPoint(metricName string, attributes attribute.Set) {
+	b.reset()
+	b.set("__name__", metricName)
+	iter := attributes.Iter()
+	for iter.Next() {
+		attr := iter.Attribute()
+		b.set(string(attr.Key), attr.Value.Emit())
+	}
+}
+
+// series returns a timeseries with the built labels and a single sample.
+func (b *labelBuilder) series(value float64, ts time.Time, exemplars []prompb.Exemplar) prompb.TimeSeries {
+	samples := b.arena.samples(1)
+	samples[0] = prompb.Sample{Value: value, Timestamp: timestampMillis(ts)}
+	return prompb.TimeSeries{
+		Samples:   samples,
+		Labels:    b.build(),
+		Exemplars: exemplars,
+	}
+}
+
+// build returns the labels as a new slice of prompb.Label.
+// The labels are sorted by name as the remote write protocol requires. Labels with an empty value are
+// omitted, and the values of names that are sanitized to the same label name are joined with ";".
+func (b *labelBuilder) build() []prompb.Label {
+	b.sorted = b.sorted[:0]
+	for _, label := range b.pairs {
+		if label.Value != "" {
+			b.sorted = append(b.sorted, label)
+		}
+	}
+	slices.SortFunc(b.sorted, func(a, b prompb.Label) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	res := b.arena.labels(len(b.sorted))
+	for i, label := range b.sorted {
+		res[i] = prompb.Label{Name: labelName(label.Name, b.utf8Names), Value: label.Value}
+	}
+	slices.SortStableFunc(res, func(a, b prompb.Label) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	// Join the values of names that were sanitized to the same label name.
+	merged := res[:0]
+	for _, label := range res {
+		if last := len(merged) - 1; last >= 0 && merged[last].Name == label.Name {
+			merged[last].Value += ";" + label.Value
+			continue
+		}
+		merged = append(merged, label)
+	}
+	return merged
+}
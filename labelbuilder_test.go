@@ -0,0 +1,68 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestLabelBuilder(t *testing.T) {
+	b := getLabelBuilder(map[string]string{"service.name": "test", "env": "prod", "empty": ""}, false, nil)
+	defer b.release()
+
+	b.setDataPoint("requests", attribute.NewSet(attribute.String("env", "dev"), attribute.String("method", "GET")))
+	assert.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: "requests"},
+		{Name: "env", Value: "dev"},
+		{Name: "method", Value: "GET"},
+		{Name: "service_name", Value: "test"},
+	}, b.build())
+
+	b.set("le", "10")
+	b.set("__name__", "latency")
+	assert.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: "latency"},
+		{Name: "env", Value: "dev"},
+		{Name: "le", Value: "10"},
+		{Name: "method", Value: "GET"},
+		{Name: "service_name", Value: "test"},
+	}, b.build())
+
+	// The labels of the previous datapoint are dropped.
+	b.setDataPoint("requests", attribute.NewSet())
+	assert.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: "requests"},
+		{Name: "env", Value: "prod"},
+		{Name: "service_name", Value: "test"},
+	}, b.build())
+}
+
+func TestLabelBuilderUTF8Names(t *testing.T) {
+	b := getLabelBuilder(map[string]string{"service.name": "test", "__internal": "x"}, true, nil)
+	defer b.release()
+
+	b.setDataPoint("http.server.request.duration", attribute.NewSet(attribute.String("http.route", "/"), attribute.String("bad\xff", "y")))
+	assert.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: "http.server.request.duration"},
+		{Name: "bad_", Value: "y"},
+		{Name: "http.route", Value: "/"},
+		{Name: "key__internal", Value: "x"},
+		{Name: "service.name", Value: "test"},
+	}, b.build())
+}
@@ -0,0 +1,182 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"unicode/utf8"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// LabelSource identifies where the label of a series comes from.
+type LabelSource int
+
+const (
+	// LabelSourceDataPoint labels come from the data point attributes.
+	LabelSourceDataPoint LabelSource = iota
+	// LabelSourceScope labels come from the instrumentation scope.
+	LabelSourceScope
+	// LabelSourceResource labels come from the resource and the ExternalLabels.
+	LabelSourceResource
+)
+
+// LabelLimitPolicy is what the exporter does with series over MaxLabelValueLength or MaxLabelsPerSeries.
+type LabelLimitPolicy int
+
+const (
+	// LabelLimitTruncate truncates the label values longer than MaxLabelValueLength, and trims the labels of series
+	// with more than MaxLabelsPerSeries labels.
+	LabelLimitTruncate LabelLimitPolicy = iota
+	// LabelLimitDropLabel drops the labels with values longer than MaxLabelValueLength, and trims the labels of
+	// series with more than MaxLabelsPerSeries labels.
+	LabelLimitDropLabel
+	// LabelLimitDropSeries drops the series with label values longer than MaxLabelValueLength or with more than
+	// MaxLabelsPerSeries labels.
+	LabelLimitDropSeries
+)
+
+// defaultLabelTrimOrder is the order labels are trimmed in when LabelTrimOrder is not set.
+var defaultLabelTrimOrder = []LabelSource{LabelSourceDataPoint, LabelSourceScope, LabelSourceResource}
+
+// protectedLabelNames are never trimmed, as the series cannot be interpreted without them.
+var protectedLabelNames = map[string]bool{
+	"__name__":        true,
+	"le":              true,
+	quantileLabelName: true,
+}
+
+// LabelTrims returns the number of series whose labels were trimmed to MaxLabelsPerSeries.
+func (e *Exporter) LabelTrims() uint64 {
+	return e.labelTrims.Load()
+}
+
+// generateLabelSources returns the source of the resource and scope labels by sanitized label name.
+// Labels missing from the result come from the data point attributes.
+func generateLabelSources(resourceLabels, scopeLabels map[string]string, utf8Names bool) map[string]LabelSource {
+	sources := make(map[string]LabelSource, len(scopeLabels))
+	for name := range scopeLabels {
+		source := LabelSourceScope
+		if _, ok := resourceLabels[name]; ok {
+			source = LabelSourceResource
+		}
+		sources[labelName(name, utf8Names)] = source
+	}
+	return sources
+}
+
+// trimSeriesLabels enforces MaxLabelValueLength and MaxLabelsPerSeries on each series as configured by the
+// LabelLimitPolicy, and returns the series that are kept. The series slice is reused for the result.
+func (e *Exporter) trimSeriesLabels(timeseries []prompb.TimeSeries, sources map[string]LabelSource) []prompb.TimeSeries {
+	maxLabels := e.config.MaxLabelsPerSeries
+	if maxLabels == 0 && e.config.MaxLabelValueLength == 0 {
+		return timeseries
+	}
+
+	kept := timeseries[:0]
+	for _, ts := range timeseries {
+		labels, ok := e.limitLabelValues(ts.Labels)
+		if ok && e.config.LabelLimitPolicy == LabelLimitDropSeries && maxLabels > 0 && len(labels) > maxLabels {
+			ok = false
+		}
+		if !ok {
+			e.telemetry.recordDropped(1)
+			continue
+		}
+		if maxLabels > 0 {
+			if trimmedLabels, trimmed := trimLabels(labels, sources, maxLabels, e.config.labelTrimOrder()); trimmed {
+				labels = trimmedLabels
+				e.labelTrims.Add(1)
+			}
+		}
+		ts.Labels = labels
+		kept = append(kept, ts)
+	}
+	return kept
+}
+
+// limitLabelValues enforces MaxLabelValueLength on the labels of a series. It returns false when the series must be
+// dropped. Protected labels are never truncated or dropped.
+func (e *Exporter) limitLabelValues(labels []prompb.Label) ([]prompb.Label, bool) {
+	maxLength := e.config.MaxLabelValueLength
+	if maxLength == 0 {
+		return labels, true
+	}
+
+	var truncated, dropped int
+	for i := range labels {
+		if len(labels[i].Value) <= maxLength || protectedLabelNames[labels[i].Name] {
+			continue
+		}
+		switch e.config.LabelLimitPolicy {
+		case LabelLimitDropSeries:
+			return nil, false
+		case LabelLimitDropLabel:
+			dropped++
+		default:
+			labels[i].Value = truncateLabelValue(labels[i].Value, maxLength)
+			truncated++
+		}
+	}
+	if dropped > 0 {
+		kept := make([]prompb.Label, 0, len(labels)-dropped)
+		for _, label := range labels {
+			if len(label.Value) <= maxLength || protectedLabelNames[label.Name] {
+				kept = append(kept, label)
+			}
+		}
+		labels = kept
+	}
+	e.telemetry.recordLabelLimits(truncated, dropped)
+	return labels, true
+}
+
+// truncateLabelValue truncates a label value longer than maxLength bytes to at most maxLength bytes, without
+// splitting a UTF-8 character.
+func truncateLabelValue(value string, maxLength int) string {
+	for maxLength > 0 && !utf8.RuneStart(value[maxLength]) {
+		maxLength--
+	}
+	return value[:maxLength]
+}
+
+// trimLabels drops labels until at most maxLabels are left, or only protected labels are left. Labels are
+// dropped by source in the given order, and within a source by descending name, so the same labels are
+// always kept. The labels must be sorted by name.
+func trimLabels(labels []prompb.Label, sources map[string]LabelSource, maxLabels int, order []LabelSource) ([]prompb.Label, bool) {
+	excess := len(labels) - maxLabels
+	if excess <= 0 {
+		return labels, false
+	}
+
+	dropped := make([]bool, len(labels))
+	for _, source := range order {
+		for i := len(labels) - 1; i >= 0 && excess > 0; i-- {
+			name := labels[i].Name
+			if dropped[i] || protectedLabelNames[name] || sources[name] != source {
+				continue
+			}
+			dropped[i] = true
+			excess--
+		}
+	}
+
+	kept := make([]prompb.Label, 0, maxLabels)
+	for i, label := range labels {
+		if !dropped[i] {
+			kept = append(kept, label)
+		}
+	}
+	return kept, len(kept) < len(labels)
+}
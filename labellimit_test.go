@@ -0,0 +1,142 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimLabels(t *testing.T) {
+	labels := []prompb.Label{
+		{Name: "__name__", Value: "requests"},
+		{Name: "a_attr", Value: "1"},
+		{Name: "b_attr", Value: "2"},
+		{Name: "host", Value: "h"},
+		{Name: "le", Value: "10"},
+		{Name: "otel_scope_name", Value: "s"},
+	}
+	sources := map[string]LabelSource{
+		"host":            LabelSourceResource,
+		"otel_scope_name": LabelSourceScope,
+	}
+	names := func(labels []prompb.Label) []string {
+		var result []string
+		for _, label := range labels {
+			result = append(result, label.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		maxLabels int
+		order     []LabelSource
+		want      []string
+	}{
+		{name: "under the limit", maxLabels: 6, order: defaultLabelTrimOrder, want: names(labels)},
+		{name: "data point labels by descending name", maxLabels: 5, order: defaultLabelTrimOrder, want: []string{"__name__", "a_attr", "host", "le", "otel_scope_name"}},
+		{name: "scope after data point", maxLabels: 3, order: defaultLabelTrimOrder, want: []string{"__name__", "host", "le"}},
+		{name: "protected labels are kept", maxLabels: 1, order: defaultLabelTrimOrder, want: []string{"__name__", "le"}},
+		{name: "custom order", maxLabels: 4, order: []LabelSource{LabelSourceResource, LabelSourceScope}, want: []string{"__name__", "a_attr", "b_attr", "le"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trimmed, ok := trimLabels(labels, sources, tt.maxLabels, tt.order)
+			assert.Equal(t, tt.want, names(trimmed))
+			assert.Equal(t, len(tt.want) < len(labels), ok)
+		})
+	}
+}
+
+func TestTrimSeriesLabels(t *testing.T) {
+	exporter := Exporter{config: Config{MaxLabelsPerSeries: 2}}
+	timeseries := []prompb.TimeSeries{
+		{Labels: []prompb.Label{{Name: "__name__", Value: "a"}, {Name: "x", Value: "1"}}},
+		{Labels: []prompb.Label{{Name: "__name__", Value: "b"}, {Name: "x", Value: "1"}, {Name: "y", Value: "2"}}},
+	}
+
+	timeseries = exporter.trimSeriesLabels(timeseries, nil)
+	assert.Len(t, timeseries[0].Labels, 2)
+	assert.Equal(t, []prompb.Label{{Name: "__name__", Value: "b"}, {Name: "x", Value: "1"}}, timeseries[1].Labels)
+	assert.Equal(t, uint64(1), exporter.LabelTrims())
+}
+
+func TestLabelLimitPolicy(t *testing.T) {
+	series := func() []prompb.TimeSeries {
+		return []prompb.TimeSeries{
+			{Labels: []prompb.Label{{Name: "__name__", Value: "requests_with_a_long_name"}, {Name: "path", Value: "/short"}}},
+			{Labels: []prompb.Label{{Name: "__name__", Value: "requests"}, {Name: "path", Value: "/a/very/long/path"}, {Name: "x", Value: "1"}}},
+			{Labels: []prompb.Label{{Name: "__name__", Value: "requests"}, {Name: "a", Value: "1"}, {Name: "b", Value: "2"}, {Name: "c", Value: "3"}}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		policy        LabelLimitPolicy
+		want          [][]prompb.Label
+		wantTelemetry Telemetry
+	}{
+		{
+			name:   "truncate",
+			policy: LabelLimitTruncate,
+			want: [][]prompb.Label{
+				{{Name: "__name__", Value: "requests_with_a_long_name"}, {Name: "path", Value: "/short"}},
+				{{Name: "__name__", Value: "requests"}, {Name: "path", Value: "/a/very"}, {Name: "x", Value: "1"}},
+				{{Name: "__name__", Value: "requests"}, {Name: "a", Value: "1"}, {Name: "b", Value: "2"}},
+			},
+			wantTelemetry: Telemetry{TruncatedLabelValues: 1},
+		},
+		{
+			name:   "drop label",
+			policy: LabelLimitDropLabel,
+			want: [][]prompb.Label{
+				{{Name: "__name__", Value: "requests_with_a_long_name"}, {Name: "path", Value: "/short"}},
+				{{Name: "__name__", Value: "requests"}, {Name: "x", Value: "1"}},
+				{{Name: "__name__", Value: "requests"}, {Name: "a", Value: "1"}, {Name: "b", Value: "2"}},
+			},
+			wantTelemetry: Telemetry{DroppedLabels: 1},
+		},
+		{
+			name:   "drop series",
+			policy: LabelLimitDropSeries,
+			want: [][]prompb.Label{
+				{{Name: "__name__", Value: "requests_with_a_long_name"}, {Name: "path", Value: "/short"}},
+			},
+			wantTelemetry: Telemetry{DroppedSeries: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := Exporter{config: Config{MaxLabelsPerSeries: 3, MaxLabelValueLength: 7, LabelLimitPolicy: tt.policy}}
+			timeseries := exporter.trimSeriesLabels(series(), nil)
+
+			var got [][]prompb.Label
+			for _, ts := range timeseries {
+				got = append(got, ts.Labels)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantTelemetry, exporter.Telemetry())
+		})
+	}
+}
+
+func TestTruncateLabelValue(t *testing.T) {
+	assert.Equal(t, "abc", truncateLabelValue("abcdef", 3))
+	assert.Equal(t, "a", truncateLabelValue("aéb", 2), "a UTF-8 character is not split")
+	assert.Equal(t, "aé", truncateLabelValue("aéb", 3))
+}
@@ -0,0 +1,46 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// labelTemplatePattern matches the ${NAME} and ${NAME:-default} references of an ExternalLabels value.
+var labelTemplatePattern = regexp.MustCompile(`\$\{([^}:]+)(?::-([^}]*))?\}`)
+
+// expandLabelTemplate replaces the ${NAME} references of an ExternalLabels value with the value of the NAME
+// resource attribute, or else of the NAME environment variable, e.g. "${host.name}" or "${CLOUD_ZONE}". A
+// reference to an undefined name is replaced with its default, given as ${NAME:-default}, or else removed.
+func expandLabelTemplate(value string, res *resource.Resource) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+	return labelTemplatePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		match := labelTemplatePattern.FindStringSubmatch(reference)
+		if attr, ok := res.Set().Value(attribute.Key(match[1])); ok {
+			return attr.Emit()
+		}
+		if env, ok := os.LookupEnv(match[1]); ok && env != "" {
+			return env
+		}
+		return match[2]
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestExpandLabelTemplate(t *testing.T) {
+	t.Setenv("CLOUD_ZONE", "us-east-1a")
+	t.Setenv("EMPTY_ZONE", "")
+	res := resource.NewSchemaless(attribute.String("host.name", "web-1"))
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "static", want: "static"},
+		{value: "cost$", want: "cost$"},
+		{value: "${host.name}", want: "web-1"},
+		{value: "${CLOUD_ZONE}", want: "us-east-1a"},
+		{value: "${host.name}.${CLOUD_ZONE}", want: "web-1.us-east-1a"},
+		{value: "${MISSING_ZONE}", want: ""},
+		{value: "${MISSING_ZONE:-unknown}", want: "unknown"},
+		{value: "${EMPTY_ZONE:-unknown}", want: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, expandLabelTemplate(tt.value, res))
+		})
+	}
+
+	assert.Equal(t, "us-east-1a", expandLabelTemplate("${CLOUD_ZONE}", nil))
+}
+
+func TestGenerateGlobalLabelsTemplate(t *testing.T) {
+	res := resource.NewSchemaless(attribute.String("host.name", "web-1"))
+	labels := generateGlobalLabels(res, map[string]string{"host": "${host.name}"}, false)
+	assert.Equal(t, map[string]string{"host": "web-1"}, labels)
+}
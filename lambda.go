@@ -0,0 +1,40 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+)
+
+// WrapLambdaHandler wraps an AWS Lambda handler so that the metrics of the reader returned by
+// Exporter.NewManualReader are exported when each invocation ends, before the execution environment is frozen.
+// Background pushes are unreliable in Lambda, because the environment is frozen between invocations. Set Spool to
+// a directory under /tmp to keep the metrics that cannot be sent until a later invocation of the environment.
+// Export errors are logged and do not fail the invocation.
+func WrapLambdaHandler[In, Out any](e *Exporter, handler func(context.Context, In) (Out, error)) func(context.Context, In) (Out, error) {
+	return func(ctx context.Context, in In) (Out, error) {
+		out, err := handler(ctx, in)
+		// The invocation context may be cancelled once the handler returns, but its deadline still applies.
+		flushCtx, cancel := context.WithoutCancel(ctx), func() {}
+		if deadline, ok := ctx.Deadline(); ok {
+			flushCtx, cancel = context.WithDeadline(flushCtx, deadline)
+		}
+		defer cancel()
+		if flushErr := e.CollectAndExport(flushCtx); flushErr != nil {
+			e.logger().Printf("Logz.io metrics exporter: failed to export the metrics of the invocation: %v", flushErr)
+		}
+		return out, err
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestWrapLambdaHandler(t *testing.T) {
+	status := http.StatusNoContent
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		rw.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a", Logger: log.New(&logs, "", 0)})
+	require.NoError(t, err)
+	provider := metric.NewMeterProvider(metric.WithReader(exporter.NewManualReader()))
+	counter, err := provider.Meter("test").Int64Counter("invocations")
+	require.NoError(t, err)
+
+	errFailed := errors.New("failed")
+	handler := WrapLambdaHandler(exporter, func(ctx context.Context, name string) (string, error) {
+		counter.Add(ctx, 1)
+		if name == "" {
+			return "", errFailed
+		}
+		return "hello " + name, nil
+	})
+
+	out, err := handler(context.Background(), "world")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", out)
+	assert.Equal(t, 1, requests, "the metrics are exported when the invocation ends")
+
+	status = http.StatusBadRequest
+	_, err = handler(context.Background(), "")
+	assert.ErrorIs(t, err, errFailed, "the handler error is returned")
+	assert.Equal(t, 2, requests)
+	assert.Contains(t, logs.String(), "failed to export the metrics of the invocation")
+}
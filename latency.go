@@ -0,0 +1,105 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencyWindowSize is the number of most recent requests the send latency percentiles are computed from
+const latencyWindowSize = 100
+
+// SendLatency holds the send latency percentiles of the most recent requests to Logz.io.
+type SendLatency struct {
+	P50     time.Duration
+	P95     time.Duration
+	Samples int
+}
+
+// latencyTracker keeps the latencies of the most recent requests in a ring buffer.
+type latencyTracker struct {
+	mu        sync.Mutex
+	latencies [latencyWindowSize]time.Duration
+	next      int
+	count     int
+	// slow is whether the p95 latency exceeded SlowExportThreshold after the last request.
+	slow bool
+}
+
+// record adds a request latency, replacing the oldest one when the window is full.
+func (t *latencyTracker) record(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.latencies[t.next] = latency
+	t.next = (t.next + 1) % latencyWindowSize
+	if t.count < latencyWindowSize {
+		t.count++
+	}
+}
+
+// percentiles returns the p50 and p95 of the recorded latencies.
+func (t *latencyTracker) percentiles() SendLatency {
+	t.mu.Lock()
+	sorted := slices.Clone(t.latencies[:t.count])
+	t.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return SendLatency{}
+	}
+	slices.Sort(sorted)
+	return SendLatency{
+		P50:     nearestRank(sorted, 50),
+		P95:     nearestRank(sorted, 95),
+		Samples: len(sorted),
+	}
+}
+
+// updateSlow records whether the p95 latency exceeds the threshold, and reports whether it just started to.
+func (t *latencyTracker) updateSlow(slow bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	crossed := slow && !t.slow
+	t.slow = slow
+	return crossed
+}
+
+// nearestRank returns the percentile p of sorted latencies using the nearest-rank method.
+func nearestRank(sorted []time.Duration, p int) time.Duration {
+	return sorted[(len(sorted)*p+99)/100-1]
+}
+
+// SendLatency returns the p50 and p95 latency of the last requests sent to Logz.io.
+func (e *Exporter) SendLatency() SendLatency {
+	return e.latency.percentiles()
+}
+
+// recordSendLatency records a request latency and calls OnSlowExports when the p95 latency rises above
+// SlowExportThreshold. OnSlowExports is not called again until the p95 latency has dropped back to the threshold
+// or below, so a slow listener does not call it on every request.
+func (e *Exporter) recordSendLatency(latency time.Duration) {
+	e.latency.record(latency)
+
+	if e.config.OnSlowExports == nil || e.config.SlowExportThreshold <= 0 {
+		return
+	}
+	stats := e.latency.percentiles()
+	if e.latency.updateSlow(stats.P95 > e.config.SlowExportThreshold) {
+		e.config.OnSlowExports(stats)
+	}
+}
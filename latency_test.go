@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyTrackerPercentiles(t *testing.T) {
+	var tracker latencyTracker
+	assert.Equal(t, SendLatency{}, tracker.percentiles())
+
+	// Record more latencies than the window holds, so that the oldest ones are evicted.
+	for i := 1; i <= latencyWindowSize+50; i++ {
+		tracker.record(time.Duration(i) * time.Millisecond)
+	}
+
+	got := tracker.percentiles()
+	assert.Equal(t, latencyWindowSize, got.Samples)
+	assert.Equal(t, 100*time.Millisecond, got.P50)
+	assert.Equal(t, 145*time.Millisecond, got.P95)
+}
+
+func TestOnSlowExports(t *testing.T) {
+	var calls []SendLatency
+	exporter := Exporter{config: Config{
+		SlowExportThreshold: 50 * time.Millisecond,
+		OnSlowExports: func(latency SendLatency) {
+			calls = append(calls, latency)
+		},
+	}}
+
+	exporter.recordSendLatency(10 * time.Millisecond)
+	assert.Empty(t, calls)
+
+	exporter.recordSendLatency(100 * time.Millisecond)
+	assert.Len(t, calls, 1)
+	assert.Equal(t, 100*time.Millisecond, calls[0].P95)
+	assert.Equal(t, exporter.SendLatency(), calls[0])
+
+	// The p95 latency stays above the threshold, so OnSlowExports is not called again.
+	exporter.recordSendLatency(100 * time.Millisecond)
+	assert.Len(t, calls, 1)
+
+	// Once the p95 latency drops to the threshold, OnSlowExports is called when it rises above it again.
+	for i := 0; i < latencyWindowSize; i++ {
+		exporter.recordSendLatency(10 * time.Millisecond)
+	}
+	assert.Len(t, calls, 1)
+	for i := 0; i < 10; i++ {
+		exporter.recordSendLatency(100 * time.Millisecond)
+	}
+	assert.Len(t, calls, 2)
+}
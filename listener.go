@@ -0,0 +1,75 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	// metricsListenerHTTPPort and metricsListenerHTTPSPort are the ports of the Logz.io metrics listeners of all
+	// regions.
+	metricsListenerHTTPPort  = "8052"
+	metricsListenerHTTPSPort = "8053"
+)
+
+// logsListenerPorts are the ports of the Logz.io logs listeners, which are often pasted instead of the metrics
+// listener.
+var logsListenerPorts = map[string]bool{"8070": true, "8071": true}
+
+// isLogzioListener reports whether host is a regional Logz.io listener, e.g. listener.logz.io or
+// listener-eu.logz.io.
+func isLogzioListener(host string) bool {
+	return strings.HasPrefix(host, "listener") && strings.HasSuffix(host, ".logz.io")
+}
+
+// checkListenerPort returns an error wrapping ErrWrongListenerPort when a Logz.io listener URL does not use the
+// metrics listener port of its scheme, e.g. when it is the logs listener URL. With fix, it returns the URL with the
+// metrics listener port instead. Other URLs are returned unchanged.
+func checkListenerPort(listener string, fix bool) (string, error) {
+	u, err := url.Parse(listener)
+	if err != nil || !isLogzioListener(u.Hostname()) {
+		return listener, nil
+	}
+
+	want := metricsListenerHTTPSPort
+	if u.Scheme == "http" {
+		want = metricsListenerHTTPPort
+	}
+	port := u.Port()
+	if port == want {
+		return listener, nil
+	}
+	if fix {
+		u.Host = net.JoinHostPort(u.Hostname(), want)
+		return u.String(), nil
+	}
+
+	var problem string
+	switch {
+	case port == "":
+		problem = "has no port"
+	case logsListenerPorts[port]:
+		problem = fmt.Sprintf("uses port %s of the logs listener", port)
+	case port == metricsListenerHTTPPort || port == metricsListenerHTTPSPort:
+		problem = fmt.Sprintf("uses port %s, which does not accept %s", port, u.Scheme)
+	default:
+		problem = fmt.Sprintf("uses port %s", port)
+	}
+	return "", fmt.Errorf("%w: %s %s, use port %s with %s", ErrWrongListenerPort, u.Host, problem, want, u.Scheme)
+}
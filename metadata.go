@@ -0,0 +1,96 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"strings"
+
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// histogramSeriesSuffixes are the suffixes of the names of the series of a histogram other than its buckets.
+var histogramSeriesSuffixes = []string{histogramSumSuffix, histogramCountSuffix, histogramMinSuffix, histogramMaxSuffix}
+
+// metricMetadata is the type, unit and description of an exported metric.
+type metricMetadata struct {
+	dataType string
+	unit     string
+	help     string
+}
+
+// recordMetadata records the metadata of a metric exported as metricName, when it is sent with SendMetadata or
+// Remote Write 2.0. The description of the instrument is replaced by its MetricDescriptions entry, if any.
+func (e *Exporter) recordMetadata(metricName string, m metricdata.Metrics) {
+	if !e.config.SendMetadata && e.config.RemoteWriteProtocol != RemoteWrite2 {
+		return
+	}
+	help := m.Description
+	if description, ok := e.config.MetricDescriptions[m.Name]; ok {
+		help = description
+	}
+	e.metadata.Store(metricName, metricMetadata{dataType: metricType(m.Data), unit: m.Unit, help: help})
+}
+
+// lookupMetadata returns the name of the metric of a series and its metadata. The sum, count, minimum and maximum
+// series of a histogram belong to the histogram.
+func (e *Exporter) lookupMetadata(name string) (string, metricMetadata, bool) {
+	if metadata, ok := e.metadata.Load(name); ok {
+		return name, metadata.(metricMetadata), true
+	}
+	for _, suffix := range histogramSeriesSuffixes {
+		base, found := strings.CutSuffix(name, suffix)
+		if !found {
+			continue
+		}
+		if metadata, ok := e.metadata.Load(base); ok && metadata.(metricMetadata).dataType == "histogram" {
+			return base, metadata.(metricMetadata), true
+		}
+	}
+	return "", metricMetadata{}, false
+}
+
+// writeRequestMetadata returns the metadata of the metrics of the series, once per metric.
+func (e *Exporter) writeRequestMetadata(timeseries []prompb.TimeSeries) []prompb.MetricMetadata {
+	var result []prompb.MetricMetadata
+	seen := map[string]bool{}
+	for _, ts := range timeseries {
+		name, metadata, ok := e.lookupMetadata(seriesName(ts.Labels))
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, prompb.MetricMetadata{
+			Type:             metadata.metadataType(),
+			MetricFamilyName: name,
+			Help:             metadata.help,
+			Unit:             metadata.unit,
+		})
+	}
+	return result
+}
+
+// metadataType returns the type of the metric in the remote write metadata.
+func (m metricMetadata) metadataType() prompb.MetricMetadata_MetricType {
+	switch m.dataType {
+	case "counter":
+		return prompb.MetricMetadata_COUNTER
+	case "gauge":
+		return prompb.MetricMetadata_GAUGE
+	case "histogram":
+		return prompb.MetricMetadata_HISTOGRAM
+	}
+	return prompb.MetricMetadata_UNKNOWN
+}
@@ -0,0 +1,68 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestWriteRequestMetadata(t *testing.T) {
+	rm := getHistogramMetric(2, metricdata.NewExtrema[int64](4), metricdata.NewExtrema[int64](1), 5)
+	histogram := &rm.ScopeMetrics[0].Metrics[0]
+	histogram.Unit = "ms"
+	histogram.Description = "Request latency"
+	sum := getSumMetric(5).ScopeMetrics[0].Metrics[0]
+	sum.Description = "Requests"
+	rm.ScopeMetrics[0].Metrics = append(rm.ScopeMetrics[0].Metrics, sum)
+
+	exporter := Exporter{config: Config{SendMetadata: true}}
+	timeseries, err := exporter.convertToTimeSeries(rm, conversionExport)
+	require.NoError(t, err)
+
+	metadata := exporter.writeRequestMetadata(timeseries)
+	assert.ElementsMatch(t, []prompb.MetricMetadata{
+		{Type: prompb.MetricMetadata_HISTOGRAM, MetricFamilyName: "metric_histogram", Help: "Request latency", Unit: "ms"},
+		{Type: prompb.MetricMetadata_COUNTER, MetricFamilyName: "metric_sum", Help: "Requests"},
+	}, metadata)
+}
+
+func TestMetricDescriptions(t *testing.T) {
+	rm := getSumMetric(5)
+	rm.ScopeMetrics[0].Metrics[0].Description = "Poor description"
+	exporter := Exporter{config: Config{
+		SendMetadata:       true,
+		MetricDescriptions: map[string]string{"metric_sum": "Requests served", "other": "Unused"},
+	}}
+	timeseries, err := exporter.convertToTimeSeries(rm, conversionExport)
+	require.NoError(t, err)
+
+	assert.Equal(t, []prompb.MetricMetadata{
+		{Type: prompb.MetricMetadata_COUNTER, MetricFamilyName: "metric_sum", Help: "Requests served"},
+	}, exporter.writeRequestMetadata(timeseries))
+}
+
+func TestRecordMetadataDisabled(t *testing.T) {
+	exporter := Exporter{}
+	_, err := exporter.convertToTimeSeries(getSumMetric(5), conversionExport)
+	require.NoError(t, err)
+
+	_, _, ok := exporter.lookupMetadata("metric_sum")
+	assert.False(t, ok)
+}
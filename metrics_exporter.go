@@ -20,15 +20,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/hashicorp/go-multierror"
 	"maps"
 	"sync"
+	"sync/atomic"
 
+	m "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"net/http"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/golang/snappy"
@@ -46,6 +51,23 @@ var (
 	histogramMinSuffix        = "_min"
 	histogramCountSuffix      = "_count"
 	histogramLastBucketSuffix = "+inf" // Default for the last bucket
+	totalSuffix               = "_total"
+	scopeInfoMetricName       = "otel_scope_info"
+	buildInfoMetricName       = "logzio_exporter_build_info"
+	remoteWriteVersion        = "0.1.0"
+	scopeNameLabelName        = "otel_scope_name"
+	scopeVersionLabelName     = "otel_scope_version"
+	jobLabelName              = "job"
+	instanceLabelName         = "instance"
+	envLabelName              = "env"
+
+	// lowMemoryBatchSize is the number of timeseries sent per request in low-memory mode
+	lowMemoryBatchSize = 500
+
+	serviceNameAttributeKey       = attribute.Key("service.name")
+	serviceNamespaceAttributeKey  = attribute.Key("service.namespace")
+	serviceInstanceIDAttributeKey = attribute.Key("service.instance.id")
+	hostNameAttributeKey          = attribute.Key("host.name")
 )
 
 // Exporter forwards metrics to Logz.io
@@ -53,236 +75,708 @@ type Exporter struct {
 	clientMu     sync.Mutex
 	config       Config
 	shutdownOnce sync.Once
+	scheduleMu   sync.Mutex
+	lastSend     time.Time
+	queueMu      sync.RWMutex
+	queue        chan queuedMessage
+	queueClosed  bool
+	workerDone   chan struct{}
+	latency      latencyTracker
+	deltas       deltaAccumulator
+	stats        statsRecorder
+	telemetry    telemetryRecorder
+	telemetryReg m.Registration
+	globalLabels globalLabelCache
+	labelTrims   atomic.Uint64
+	zeroTimes    atomic.Uint64
+	budgets      budgetTracker
+	warnings     configWarnings
+	overBudget   atomic.Bool
+	noExemplars  atomic.Bool
+	sampleOrder  sampleOrderTracker
+	outage       outageTracker
+	ephemeral    ephemeralTracker
+	spool        messageSpool
+	filter       seriesFilter
+	oversized    atomic.Uint64
+	scopeWarned  sync.Map
+	metadata     sync.Map
+	rw2Rejected  atomic.Bool
+	flushReader  atomic.Pointer[metric.ManualReader]
+	globalKey    *globalKey
 }
 
 // New returns a Logzio Prometheus remote write Exporter.
 func New(config Config) (*Exporter, error) {
+	warnings := unusedConfigWarnings(config)
+	pushIntervalSet := config.PushInterval != 0
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 
 	exporter := Exporter{config: config}
+	// Validate checked that the filter compiles.
+	exporter.filter, _ = config.Filter.compile()
+	exporter.warnings.pushIntervalSet = pushIntervalSet
+	for _, warning := range warnings {
+		exporter.logger().Printf("Logz.io metrics exporter: %s", warning)
+	}
+	exporter.deltas.setMaxSeries(config.MaxTrackedSeries)
+	exporter.sampleOrder.setMaxSeries(config.MaxTrackedSeries)
+	exporter.ephemeral.setMaxSeries(config.MaxTrackedSeries)
+	if config.ExportOnStart {
+		if err := exporter.startupExport(); err != nil {
+			return nil, err
+		}
+	}
+	if config.AsyncQueueSize > 0 {
+		exporter.startWorker()
+	}
+	if config.TelemetryMeterProvider != nil {
+		if err := exporter.registerTelemetryInstruments(); err != nil {
+			if exporter.queue != nil {
+				_ = exporter.stopWorker(context.Background())
+			}
+			return nil, fmt.Errorf("failed to register telemetry instruments: %w", err)
+		}
+	}
 	return &exporter, nil
 }
 
-// Temporality returns CumulativeExporter so the Processor correctly aggregates data
-func (e *Exporter) Temporality(_ metric.InstrumentKind) metricdata.Temporality {
-	return metricdata.CumulativeTemporality
-}
-
 // Export forwards metrics to Logz.io from the SDK
-func (e *Exporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
-	timeseries, err := e.ConvertToTimeSeries(rm)
+func (e *Exporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	e.warnUnusedConfig(rm)
+	if !e.allowSend(time.Now()) {
+		// The delta metrics of a skipped export are added to the running totals, so that they are included in the
+		// next allowed export.
+		return e.convertMetrics(rm, nil, nil, conversionSkipped, func([]prompb.TimeSeries) {})
+	}
+
+	start := time.Now()
+	defer func() { e.checkExportTime(time.Since(start)) }()
+
+	exportLabels := exportLabelsFromContext(ctx)
+	if e.config.LowMemory {
+		return e.exportBatches(ctx, rm, exportLabels, time.Time{})
+	}
+	if e.splitExports() {
+		return e.exportBatches(ctx, rm, exportLabels, start.Add(e.config.exportTimeBudget()))
+	}
+
+	// The series of the export are only used until its message is built, so their labels and samples are
+	// allocated from an arena that is reused by the next export.
+	arena := getSeriesArena()
+	defer arena.release()
+
+	var timeseries []prompb.TimeSeries
+	err := e.convertMetrics(rm, exportLabels, arena, conversionExport, func(ts []prompb.TimeSeries) {
+		e.trackEphemeral(rm.Resource, ts)
+		timeseries = append(timeseries, ts...)
+	})
 	if err != nil {
 		return err
 	}
 
+	return e.sendTimeSeries(ctx, timeseries)
+}
+
+// exportBatches converts the metrics one at a time and sends a request whenever lowMemoryBatchSize
+// timeseries were converted, so that the whole batch is never held in memory. Once the deadline, if any, has
+// passed, the remaining batches are not sent.
+func (e *Exporter) exportBatches(ctx context.Context, rm *metricdata.ResourceMetrics, exportLabels map[string]string, deadline time.Time) error {
+	var result *multierror.Error
+	var skipped int
+	batch := make([]prompb.TimeSeries, 0, lowMemoryBatchSize)
+	send := func(batch []prompb.TimeSeries) {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			skipped += len(batch)
+			return
+		}
+		if err := e.sendTimeSeries(ctx, batch); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	err := e.convertMetrics(rm, exportLabels, nil, conversionExport, func(ts []prompb.TimeSeries) {
+		e.trackEphemeral(rm.Resource, ts)
+		batch = append(batch, ts...)
+		for len(batch) >= lowMemoryBatchSize {
+			send(batch[:lowMemoryBatchSize])
+			batch = append(batch[:0], batch[lowMemoryBatchSize:]...)
+		}
+	})
+	if err != nil {
+		result = multierror.Append(result, err)
+	}
+	if len(batch) > 0 {
+		send(batch)
+	}
+	if skipped > 0 {
+		result = multierror.Append(result, fmt.Errorf("%w: %d series not sent", ErrExportTimeBudgetExceeded, skipped))
+	}
+
+	return result.ErrorOrNil()
+}
+
+// sendTimeSeries builds a request from a slice of TimeSeries and sends it to Logz.io,
+// or queues it for the async worker when AsyncQueueSize is set. When the message is larger than
+// MaxRequestBodyBytes, the TimeSeries are split in halves that are sent separately. Nothing is sent once ctx is
+// done, and the requests are canceled when ctx is done.
+func (e *Exporter) sendTimeSeries(ctx context.Context, timeseries []prompb.TimeSeries) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	message, buildMessageErr := e.buildMessage(timeseries)
 	if buildMessageErr != nil {
 		return buildMessageErr
 	}
+	// A single series larger than the limit is sent as is, and left to the listener to accept or reject.
+	if limit := e.config.MaxRequestBodyBytes; limit > 0 && len(message) > limit && len(timeseries) > 1 {
+		half := len(timeseries) / 2
+		var result *multierror.Error
+		if err := e.sendTimeSeries(ctx, timeseries[:half]); err != nil {
+			result = multierror.Append(result, err)
+		}
+		if err := e.sendTimeSeries(ctx, timeseries[half:]); err != nil {
+			result = multierror.Append(result, err)
+		}
+		return result.ErrorOrNil()
+	}
+	e.recordStats(timeseries, len(message))
+
+	exemplars := e.exemplarsToProbe(timeseries)
+	if e.queue != nil {
+		return e.enqueue(message, len(timeseries), exemplars)
+	}
+	return e.deliver(ctx, message, len(timeseries), exemplars, time.Now())
+}
+
+// sendMessage sends a compressed message holding a number of exemplars to Logz.io. The message is marshaled
+// and compressed once by the caller, and a fresh request is built from it for every send attempt. Transient
+// failures are retried as configured by the Retry config, until ctx is done. When DryRun is set, the message is
+// written to it instead.
+func (e *Exporter) sendMessage(ctx context.Context, message []byte, exemplars int) error {
+	if e.config.DryRun != nil {
+		return e.writeDryRun(message)
+	}
+	body, protocol, err := e.encodeMessage(message)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		header, err := e.sendAttempt(ctx, body, protocol)
+		if err == nil {
+			e.probeExemplarSupport(header, exemplars)
+			return nil
+		}
+
+		// A listener that does not support Remote Write 2.0 is sent the message again with Remote Write 1.0
+		// right away, without spending an attempt.
+		var unsupported *unsupportedProtocolError
+		if protocol == RemoteWrite2 && errors.As(err, &unsupported) {
+			e.fallBackToRemoteWrite1()
+			if body, protocol, err = e.encodeMessage(message); err != nil {
+				return err
+			}
+			attempt--
+			continue
+		}
+
+		var retryable *retryableError
+		if !errors.As(err, &retryable) {
+			return err
+		}
+		if attempt >= e.config.Retry.MaxAttempts {
+			if attempt > 1 {
+				return fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return err
+		}
+		timer := time.NewTimer(e.config.retryBackoff(attempt, retryable.retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (retry canceled after %d attempts: %w)", err, attempt, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
 
-	request, buildRequestErr := e.buildRequest(message)
+// sendAttempt builds a request bound to ctx from a compressed message of a remote write protocol and sends it to
+// Logz.io once. It returns the headers of the response.
+func (e *Exporter) sendAttempt(ctx context.Context, message []byte, protocol RemoteWriteProtocol) (http.Header, error) {
+	if err := e.injectFault(FaultStageSend); err != nil {
+		return nil, err
+	}
+
+	request, buildRequestErr := e.buildRequest(ctx, message, protocol)
 	if buildRequestErr != nil {
-		return buildRequestErr
+		return nil, buildRequestErr
 	}
 
 	e.clientMu.Lock()
-	sendRequestErr := e.sendRequest(request)
+	start := time.Now()
+	header, sendRequestErr := e.sendRequest(request)
+	latency := time.Since(start)
 	e.clientMu.Unlock()
-	if sendRequestErr != nil {
-		return sendRequestErr
-	}
-
-	return nil
+	e.recordSendLatency(latency)
+	e.telemetry.recordRequest(latency)
+	return header, sendRequestErr
 }
 
+// conversionMode selects the state of the exporter a conversion updates.
+type conversionMode int
+
+const (
+	// conversionExport updates all the state the exporter keeps across exports.
+	conversionExport conversionMode = iota
+	// conversionInspect updates no state, so that the converted metrics can still be exported.
+	conversionInspect
+	// conversionSkipped only adds delta metrics to the running totals, for exports skipped by the send schedule.
+	// No TimeSeries are emitted.
+	conversionSkipped
+)
+
 // ConvertToTimeSeries converts a InstrumentationLibraryReader to a slice of TimeSeries pointers
 // Based on the aggregation type, ConvertToTimeSeries will call helper functions like
 // convertFromSum to generate the correct number of TimeSeries.
+// ConvertToTimeSeries does not update the state the exporter keeps across exports: delta metrics are added to a copy
+// of the running totals, the Budgets are neither spent nor enforced, and the sample order of Strict mode is not
+// checked, so the metrics can be inspected and then exported.
 func (e *Exporter) ConvertToTimeSeries(rm *metricdata.ResourceMetrics) ([]prompb.TimeSeries, error) {
+	return e.convertToTimeSeries(rm, conversionInspect)
+}
+
+// convertToTimeSeries converts the metrics to a slice of TimeSeries, updating the state of the conversion mode.
+func (e *Exporter) convertToTimeSeries(rm *metricdata.ResourceMetrics, mode conversionMode) ([]prompb.TimeSeries, error) {
 	var timeSeries []prompb.TimeSeries
-	var result *multierror.Error
 
-	labelsMap := generateGlobalLabels(rm.Resource, e.config.ExternalLabels)
+	err := e.convertMetrics(rm, nil, nil, mode, func(ts []prompb.TimeSeries) {
+		timeSeries = append(timeSeries, ts...)
+	})
+
+	return timeSeries, err
+}
+
+// convertMetrics converts the metrics one at a time and passes the TimeSeries of each metric to emit.
+// The exportLabels are added to every TimeSeries, and their labels and samples are allocated from the arena.
+// The mode selects the state of the exporter the conversion updates.
+func (e *Exporter) convertMetrics(rm *metricdata.ResourceMetrics, exportLabels map[string]string, arena *seriesArena, mode conversionMode, emit func([]prompb.TimeSeries)) error {
+	if err := e.injectFault(FaultStageConversion); err != nil {
+		return err
+	}
+	// Producers other than the SDK may pass no metrics at all.
+	if rm == nil {
+		return nil
+	}
+
+	var result *multierror.Error
+	exportTime := time.Now()
+	withExemplars := !e.config.LowMemory && e.includeExemplars()
+	exemplarLabels := e.config.exemplarLabelNames()
+	updateDeltas := mode != conversionInspect
+
+	metricTypes := map[string]string{}
+	labelsMap := e.cachedGlobalLabels(rm.Resource)
+	if len(exportLabels) > 0 {
+		labelsMap = maps.Clone(labelsMap)
+		maps.Copy(labelsMap, exportLabels)
+	}
+	if e.config.EmitBuildInfo && mode != conversionSkipped {
+		ts := []prompb.TimeSeries{convertBuildInfo(labelsMap, e.config.LabelNamespace, e.config.UTF8Names)}
+		e.filterSeriesLabels(ts)
+		ts = e.trimSeriesLabels(ts, generateLabelSources(labelsMap, labelsMap, e.config.UTF8Names))
+		emit(ts)
+	}
 
 	// Iterate over each record in the checkpoint set and convert to TimeSeries
 	for _, sm := range rm.ScopeMetrics {
-		maps.Copy(labelsMap, generateScopeLabels(sm.Scope))
+		scope := sm.Scope
+		if e.oversizedScope(scope) {
+			if e.config.OversizedScopePolicy == OversizedScopeDrop {
+				continue
+			}
+			scope.Attributes = *attribute.EmptySet()
+		}
 
-		for _, m := range sm.Metrics {
+		scopeLabels := maps.Clone(labelsMap)
+		if e.config.EmitScopeInfo {
+			// Scope attributes are carried once by otel_scope_info instead of on every series.
+			maps.Copy(scopeLabels, generateScopeIdentityLabels(scope, e.config.LabelNamespace))
+		} else {
+			maps.Copy(scopeLabels, generateScopeLabels(scope, e.config.LabelNamespace))
+		}
+		labelSources := generateLabelSources(labelsMap, scopeLabels, e.config.UTF8Names)
+		if e.config.EmitScopeInfo && scope.Attributes.Len() > 0 && mode != conversionSkipped {
+			ts := []prompb.TimeSeries{convertScopeInfo(scope, scopeLabels, e.config.UTF8Names)}
+			e.filterSeriesLabels(ts)
+			ts = e.trimSeriesLabels(ts, labelSources)
+			emit(ts)
+		}
+
+		for _, m := range mergeHistogramMetrics(sm.Metrics) {
+			m.Data = e.overrideMetricType(m.Name, m.Data)
 			metricName := m.Name
-			if e.config.AddMetricSuffixes && m.Unit != "" {
-				metricName = metricName + "_" + m.Unit
+			if e.config.AddMetricSuffixes {
+				metricName = metricNameWithUnit(metricName, m.Unit, e.config.InferUnits)
+			}
+			if isNonMonotonicSum(m.Data) {
+				metricName = e.nonMonotonicSumName(metricName)
 			}
 
-			switch data := m.Data.(type) {
-			case metricdata.Sum[int64]:
-				ts, err := convertFromSum(metricName, data, labelsMap)
-				if err != nil {
+			metricName, err := e.resolveMetricTypeConflict(metricName, m.Data, metricTypes)
+			if err != nil {
+				result = multierror.Append(result, err)
+				continue
+			}
+			if metricName == "" || !e.filter.keepMetric(metricName) {
+				continue
+			}
+			if e.config.Strict {
+				if err := checkStrict(metricName, scopeLabels, m.Data, e.config.UTF8Names); err != nil {
 					result = multierror.Append(result, err)
-				} else {
-					timeSeries = append(timeSeries, ts...)
+					continue
 				}
+			}
+			if mode == conversionExport {
+				e.recordMetadata(metricName, m)
+			}
+
+			var ts []prompb.TimeSeries
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				data = sumToCumulative(&e.deltas, sm.Scope, m.Name, data, updateDeltas)
+				ts, err = convertFromSum(metricName, data, scopeLabels, withExemplars, exemplarLabels, e.config.UTF8Names, arena)
 			case metricdata.Sum[float64]:
-				ts, err := convertFromSum(metricName, data, labelsMap)
-				if err != nil {
-					result = multierror.Append(result, err)
-				} else {
-					timeSeries = append(timeSeries, ts...)
-				}
+				data = sumToCumulative(&e.deltas, sm.Scope, m.Name, data, updateDeltas)
+				ts, err = convertFromSum(metricName, data, scopeLabels, withExemplars, exemplarLabels, e.config.UTF8Names, arena)
 			case metricdata.Gauge[int64]:
-				ts, err := convertFromGauge(metricName, data, labelsMap)
-				if err != nil {
-					result = multierror.Append(result, err)
-				} else {
-					timeSeries = append(timeSeries, ts...)
-				}
+				ts, err = convertFromGauge(metricName, data, scopeLabels, e.config.UTF8Names, arena)
 			case metricdata.Gauge[float64]:
-				ts, err := convertFromGauge(metricName, data, labelsMap)
-				if err != nil {
-					result = multierror.Append(result, err)
-				} else {
-					timeSeries = append(timeSeries, ts...)
-				}
+				ts, err = convertFromGauge(metricName, data, scopeLabels, e.config.UTF8Names, arena)
 			case metricdata.Histogram[int64]:
-				ts, err := convertFromHistogram(metricName, data, labelsMap)
-				if err != nil {
-					result = multierror.Append(result, err)
-				} else {
-					timeSeries = append(timeSeries, ts...)
-				}
+				data = histogramToCumulative(&e.deltas, sm.Scope, m.Name, mergeHistogramDataPoints(data), updateDeltas)
+				ts, err = convertHistogram(metricName, data, scopeLabels, e.config, arena)
 			case metricdata.Histogram[float64]:
-				ts, err := convertFromHistogram(metricName, data, labelsMap)
-				if err != nil {
-					result = multierror.Append(result, err)
-				} else {
-					timeSeries = append(timeSeries, ts...)
-				}
+				data = histogramToCumulative(&e.deltas, sm.Scope, m.Name, mergeHistogramDataPoints(data), updateDeltas)
+				ts, err = convertHistogram(metricName, data, scopeLabels, e.config, arena)
+			case metricdata.ExponentialHistogram[int64]:
+				ts, err = convertExponentialHistogram(metricName, data, scopeLabels, e.config, arena)
+			case metricdata.ExponentialHistogram[float64]:
+				ts, err = convertExponentialHistogram(metricName, data, scopeLabels, e.config, arena)
 			default:
-				result = multierror.Append(result, fmt.Errorf("Unsupported metric type: %T\n", data))
+				err = fmt.Errorf("Unsupported metric type: %T\n", data)
 			}
+			if err == nil && mode == conversionSkipped {
+				continue
+			}
+			if err == nil {
+				ts = e.guardTimestamps(ts, exportTime)
+			}
+			if err == nil && e.config.Strict && mode == conversionExport {
+				err = e.sampleOrder.check(metricName, ts)
+			}
+			if err != nil {
+				result = multierror.Append(result, err)
+			} else {
+				e.filterSeriesLabels(ts)
+				ts = e.trimSeriesLabels(ts, labelSources)
+				ts = append(ts, e.rollupSeries(metricName, ts)...)
+				if mode != conversionExport {
+					emit(e.sampleSeries(ts))
+					continue
+				}
+				kept := e.enforceBudgets(e.sampleSeries(ts), exportTime)
+				e.telemetry.recordDropped(len(ts) - len(kept))
+				emit(kept)
+			}
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// resolveMetricTypeConflict applies the configured DuplicateMetricTypePolicy when metricName was already exported
+// in the batch with a different metric type. It returns the name to export the metric with, or an empty name
+// when the metric should be dropped.
+func (e *Exporter) resolveMetricTypeConflict(metricName string, data metricdata.Aggregation, metricTypes map[string]string) (string, error) {
+	dataType := metricType(data)
+	seenType, seen := metricTypes[metricName]
+	if !seen {
+		metricTypes[metricName] = dataType
+		return metricName, nil
+	}
+	if seenType == dataType {
+		return metricName, nil
+	}
+
+	switch e.config.DuplicateMetricTypePolicy {
+	case DuplicateMetricTypeDrop:
+		return "", nil
+	case DuplicateMetricTypeError:
+		return "", fmt.Errorf("metric %q exported as both %s and %s", metricName, seenType, dataType)
+	default:
+		suffixedName := metricName + "_" + dataType
+		metricTypes[suffixedName] = dataType
+		return suffixedName, nil
+	}
+}
+
+// isNonMonotonicSum returns true when the data is a sum of an up-down counter, which is exported as a gauge.
+func isNonMonotonicSum(data metricdata.Aggregation) bool {
+	switch data := data.(type) {
+	case metricdata.Sum[int64]:
+		return !data.IsMonotonic
+	case metricdata.Sum[float64]:
+		return !data.IsMonotonic
+	default:
+		return false
+	}
+}
+
+// nonMonotonicSumName returns the name a non-monotonic sum is exported with. With UpDownCounterGaugeNames the
+// counter "_total" suffix is removed, and the NonMonotonicSumSuffix is appended unless the name already ends with
+// it, so up-down counters cannot be mistaken for counters.
+func (e *Exporter) nonMonotonicSumName(metricName string) string {
+	if e.config.UpDownCounterGaugeNames {
+		if trimmed := strings.TrimSuffix(metricName, totalSuffix); trimmed != "" {
+			metricName = trimmed
 		}
 	}
+	if suffix := e.config.NonMonotonicSumSuffix; suffix != "" && !strings.HasSuffix(metricName, suffix) {
+		metricName += suffix
+	}
+	return metricName
+}
 
-	return timeSeries, result.ErrorOrNil()
+// metricType returns the Prometheus metric type a metric data is exported as
+func metricType(data metricdata.Aggregation) string {
+	switch data := data.(type) {
+	case metricdata.Sum[int64]:
+		if data.IsMonotonic {
+			return "counter"
+		}
+		return "gauge"
+	case metricdata.Sum[float64]:
+		if data.IsMonotonic {
+			return "counter"
+		}
+		return "gauge"
+	case metricdata.Gauge[int64], metricdata.Gauge[float64]:
+		return "gauge"
+	case metricdata.Histogram[int64], metricdata.Histogram[float64]:
+		return "histogram"
+	case metricdata.ExponentialHistogram[int64], metricdata.ExponentialHistogram[float64]:
+		return "histogram"
+	default:
+		return "unknown"
+	}
 }
 
 // createTimeSeries is a helper function to create a timeseries from a value and attributes
-func createTimeSeries(value float64, ts time.Time, labels map[string]string, exemplars []prompb.Exemplar) prompb.TimeSeries {
+func createTimeSeries(value float64, ts time.Time, labels map[string]string, exemplars []prompb.Exemplar, utf8Names bool) prompb.TimeSeries {
+	return createTimeSeriesWithLabels(value, ts, createLabelSet(labels, utf8Names), exemplars)
+}
+
+// createTimeSeriesWithLabels creates a timeseries from a value and its label set
+func createTimeSeriesWithLabels(value float64, ts time.Time, labels []prompb.Label, exemplars []prompb.Exemplar) prompb.TimeSeries {
 	// We generate a sample per datapoint, because OTEL handles merging of datapoint with the same labels and name.
 	// Therefore, if there are multiple data points >> they necessarily have different Attributes >> meaning they are
 	// different timeseries.
 	sample := prompb.Sample{
 		Value:     value,
-		Timestamp: ts.UnixNano() / int64(time.Millisecond),
+		Timestamp: timestampMillis(ts),
 	}
 	return prompb.TimeSeries{
 		Samples:   []prompb.Sample{sample},
-		Labels:    createLabelSet(labels),
+		Labels:    labels,
 		Exemplars: exemplars,
 	}
 }
 
 // convertFromSum returns a single TimeSeries based on a Record with a Sum aggregation
-func convertFromSum[N int64 | float64](metricName string, sum metricdata.Sum[N], labels map[string]string) ([]prompb.TimeSeries, error) {
+func convertFromSum[N int64 | float64](metricName string, sum metricdata.Sum[N], labels map[string]string, withExemplars bool, exemplarLabels exemplarLabelNames, utf8Names bool, arena *seriesArena) ([]prompb.TimeSeries, error) {
 	var timeSeries []prompb.TimeSeries
-	var dpLabels map[string]string
+	b := getLabelBuilder(labels, utf8Names, arena)
+	defer b.release()
 
 	for _, dp := range sum.DataPoints {
 		var ex []prompb.Exemplar
-		dpLabels = generateDataPointLabels(metricName, labels, dp.Attributes)
+		b.setDataPoint(metricName, dp.Attributes)
 		// sum.IsMonotonic is true for prometheus.CounterValue, false for prometheus.GaugeValue
 		// GaugeValues don't support Exemplars at this time
 		// ref: https://github.com/prometheus/client_golang/blob/aef8aedb4b6e1fb8ac1c90790645169125594096/prometheus/metric.go#L199
-		if sum.IsMonotonic {
-			ex = generateExamplers(dp.Exemplars)
+		if sum.IsMonotonic && withExemplars {
+			ex = generateExamplers(dp.Exemplars, exemplarLabels, utf8Names)
 		}
 
 		// we take the Time and not StartTime, because the Timestamp should be the time when the datapoint was recorded
-		timeSeries = append(timeSeries, createTimeSeries(float64(dp.Value), dp.Time, dpLabels, ex))
+		timeSeries = append(timeSeries, b.series(float64(dp.Value), dp.Time, ex))
 	}
 
 	return timeSeries, nil
 }
 
 // convertFromGauge returns a TimeSeries based on a Record with a Gauge aggregation
-func convertFromGauge[N int64 | float64](metricName string, gauge metricdata.Gauge[N], labels map[string]string) ([]prompb.TimeSeries, error) {
+func convertFromGauge[N int64 | float64](metricName string, gauge metricdata.Gauge[N], labels map[string]string, utf8Names bool, arena *seriesArena) ([]prompb.TimeSeries, error) {
 	var timeSeries []prompb.TimeSeries
-	var dpLabels map[string]string
+	b := getLabelBuilder(labels, utf8Names, arena)
+	defer b.release()
 
 	for _, dp := range gauge.DataPoints {
-		dpLabels = generateDataPointLabels(metricName, labels, dp.Attributes)
+		b.setDataPoint(metricName, dp.Attributes)
 
 		// GaugeValues don't support Exemplars at this time
 		// ref: https://github.com/prometheus/client_golang/blob/aef8aedb4b6e1fb8ac1c90790645169125594096/prometheus/metric.go#L199
 		// also, we take the Time and not StartTime, because the Timestamp should be the time when the datapoint was recorded
-		timeSeries = append(timeSeries, createTimeSeries(float64(dp.Value), dp.Time, dpLabels, nil))
+		timeSeries = append(timeSeries, b.series(float64(dp.Value), dp.Time, nil))
 	}
 	return timeSeries, nil
 }
 
-// convertFromHistogram returns len(histogram.Buckets) timeseries for a histogram aggregation
-func convertFromHistogram[N int64 | float64](metricName string, histogram metricdata.Histogram[N], labels map[string]string) ([]prompb.TimeSeries, error) {
+// convertFromHistogram returns len(histogram.Buckets) timeseries for a histogram aggregation.
+// When withBuckets is false, only the max, min, sum and count timeseries are returned.
+func convertFromHistogram[N int64 | float64](metricName string, histogram metricdata.Histogram[N], labels map[string]string, withBuckets, withExemplars bool, exemplarLabels exemplarLabelNames, utf8Names bool, arena *seriesArena) ([]prompb.TimeSeries, error) {
 	var timeSeries []prompb.TimeSeries
-	var totalCount float64
+	b := getLabelBuilder(labels, utf8Names, arena)
+	defer b.release()
 
 	for _, dp := range histogram.DataPoints {
-		ex := generateExamplers(dp.Exemplars)
-
-		// configure labels for each datapoint
-		maxDpLabels := generateDataPointLabels(metricName+histogramMaxSuffix, labels, dp.Attributes)
-		minDpLabels := generateDataPointLabels(metricName+histogramMinSuffix, labels, dp.Attributes)
-		sumDpLabels := generateDataPointLabels(metricName+histogramSumSuffix, labels, dp.Attributes)
-		countDpLabels := generateDataPointLabels(metricName+histogramCountSuffix, labels, dp.Attributes)
-		boundDpLabels := generateDataPointLabels(metricName, labels, dp.Attributes)
+		var totalCount float64
+		var ex []prompb.Exemplar
+		if withExemplars {
+			ex = generateExamplers(dp.Exemplars, exemplarLabels, utf8Names)
+		}
 
-		// add time series for each datapoint
+		// add time series for each datapoint, with the labels of the datapoint and the name of each series
+		b.setDataPoint(metricName, dp.Attributes)
 		if maxVal, defined := dp.Max.Value(); defined {
-			timeSeries = append(timeSeries, createTimeSeries(float64(maxVal), dp.Time, maxDpLabels, ex))
+			b.set("__name__", metricName+histogramMaxSuffix)
+			timeSeries = append(timeSeries, b.series(float64(maxVal), dp.Time, ex))
 		}
 		if minVal, defined := dp.Min.Value(); defined {
-			timeSeries = append(timeSeries, createTimeSeries(float64(minVal), dp.Time, minDpLabels, ex))
+			b.set("__name__", metricName+histogramMinSuffix)
+			timeSeries = append(timeSeries, b.series(float64(minVal), dp.Time, ex))
+		}
+		b.set("__name__", metricName+histogramSumSuffix)
+		timeSeries = append(timeSeries, b.series(float64(dp.Sum), dp.Time, ex))
+		b.set("__name__", metricName+histogramCountSuffix)
+		timeSeries = append(timeSeries, b.series(float64(dp.Count), dp.Time, ex))
+		if !withBuckets {
+			continue
 		}
-		timeSeries = append(timeSeries, createTimeSeries(float64(dp.Sum), dp.Time, sumDpLabels, ex))
-		timeSeries = append(timeSeries, createTimeSeries(float64(dp.Count), dp.Time, countDpLabels, ex))
+		b.set("__name__", metricName)
 
-		// Handle histogram buckets
+		// Handle histogram buckets. Prometheus buckets are cumulative, so each bucket holds the count of all
+		// the buckets up to its bound. The last bucket count has no upper bound and is only part of the +inf bucket.
 		for i, bucketCount := range dp.BucketCounts {
-			boundDpLabels["le"] = fmt.Sprintf("%g", dp.Bounds[i])
-			totalCount += float64(dp.BucketCounts[i])
+			totalCount += float64(bucketCount)
+			if i >= len(dp.Bounds) {
+				continue
+			}
+			b.set("le", fmt.Sprintf("%g", dp.Bounds[i]))
 
 			// Create timeseries for the bucket
-			timeSeries = append(timeSeries, createTimeSeries(float64(bucketCount), dp.Time, boundDpLabels, ex))
+			timeSeries = append(timeSeries, b.series(totalCount, dp.Time, ex))
 		}
-		boundDpLabels["le"] = histogramLastBucketSuffix
-		timeSeries = append(timeSeries, createTimeSeries(totalCount, dp.Time, boundDpLabels, ex))
+		b.set("le", histogramLastBucketSuffix)
+		timeSeries = append(timeSeries, b.series(totalCount, dp.Time, ex))
 	}
 
 	return timeSeries, nil
 }
 
-// generateGlobalLabels returns global labels to add to all metrics based on the resource and the exporter settings
-func generateGlobalLabels(res *resource.Resource, exporterLabels map[string]string) map[string]string {
+// generateGlobalLabels returns global labels to add to all metrics based on the resource and the exporter settings.
+// When copyResourceAttributes is false, only the job and instance labels are derived from the resource. The
+// references of the exporter label values to resource attributes and environment variables are expanded.
+func generateGlobalLabels(res *resource.Resource, exporterLabels map[string]string, copyResourceAttributes bool) map[string]string {
 	globalLabels := map[string]string{}
 
-	for _, attr := range res.Attributes() {
-		globalLabels[string(attr.Key)] = attr.Value.Emit()
+	if copyResourceAttributes {
+		for _, attr := range res.Attributes() {
+			globalLabels[string(attr.Key)] = attr.Value.Emit()
+		}
+	} else {
+		maps.Copy(globalLabels, generateJobInstanceLabels(res))
+	}
+	for name, value := range exporterLabels {
+		globalLabels[name] = expandLabelTemplate(value, res)
 	}
-	maps.Copy(globalLabels, exporterLabels)
 	return globalLabels
 }
 
-// generateScopeLabels returns labels to add to a metric based on the scope and its attributes
-func generateScopeLabels(scope instrumentation.Scope) map[string]string {
-	scopeLabels := map[string]string{
-		"otel_scope_name":    scope.Name,
-		"otel_scope_version": scope.Version,
+// addDefaultInstanceLabel adds an instance label when the resource identifies neither the service instance nor the
+// host, so that series of different replicas do not collide. An existing instance label is kept.
+func addDefaultInstanceLabel(labels map[string]string, res *resource.Resource, instance string) {
+	if _, ok := labels[instanceLabelName]; ok || instance == "" {
+		return
+	}
+	if _, ok := res.Set().Value(serviceInstanceIDAttributeKey); ok {
+		return
+	}
+	if _, ok := res.Set().Value(hostNameAttributeKey); ok {
+		return
+	}
+	labels[instanceLabelName] = instance
+}
+
+// generateJobInstanceLabels returns the job and instance labels derived from the resource service attributes,
+// following the OpenTelemetry to Prometheus compatibility specification.
+func generateJobInstanceLabels(res *resource.Resource) map[string]string {
+	labels := map[string]string{}
+
+	if name, ok := res.Set().Value(serviceNameAttributeKey); ok {
+		job := name.Emit()
+		if namespace, ok := res.Set().Value(serviceNamespaceAttributeKey); ok {
+			job = namespace.Emit() + "/" + job
+		}
+		labels[jobLabelName] = job
+	}
+	if instanceID, ok := res.Set().Value(serviceInstanceIDAttributeKey); ok {
+		labels[instanceLabelName] = instanceID.Emit()
 	}
+	return labels
+}
+
+// generateScopeLabels returns labels to add to a metric based on the scope and its attributes
+func generateScopeLabels(scope instrumentation.Scope, namespace string) map[string]string {
+	scopeLabels := generateScopeIdentityLabels(scope, namespace)
 	maps.Copy(scopeLabels, generateAttributesLabels(scope.Attributes))
 	return scopeLabels
 }
 
+// generateScopeIdentityLabels returns the scope name and version labels that identify a scope
+func generateScopeIdentityLabels(scope instrumentation.Scope, namespace string) map[string]string {
+	return map[string]string{
+		namespacedLabelName(namespace, scopeNameLabelName):    scope.Name,
+		namespacedLabelName(namespace, scopeVersionLabelName): scope.Version,
+	}
+}
+
+// convertScopeInfo returns an otel_scope_info timeseries with value 1 carrying the scope attributes
+func convertScopeInfo(scope instrumentation.Scope, labels map[string]string, utf8Names bool) prompb.TimeSeries {
+	infoLabels := generateDataPointLabels(scopeInfoMetricName, labels, scope.Attributes)
+	return createTimeSeries(1, time.Now(), infoLabels, nil, utf8Names)
+}
+
+// convertBuildInfo returns a logzio_exporter_build_info timeseries with value 1 carrying the exporter version,
+// the Go version, and the remote write protocol version
+func convertBuildInfo(labels map[string]string, namespace string, utf8Names bool) prompb.TimeSeries {
+	infoLabels := addMetricName(buildInfoMetricName, labels)
+	infoLabels[namespacedLabelName(namespace, "version")] = Version()
+	infoLabels[namespacedLabelName(namespace, "go_version")] = runtime.Version()
+	infoLabels[namespacedLabelName(namespace, "protocol")] = remoteWriteVersion
+	return createTimeSeries(1, time.Now(), infoLabels, nil, utf8Names)
+}
+
+// namespacedLabelName returns the name of a label added by the exporter, prefixed with the LabelNamespace if any.
+func namespacedLabelName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "_" + name
+}
+
 // generateAttributesLabels returns a map of labels from a set of attributes
 func generateAttributesLabels(as attribute.Set) map[string]string {
 	labels := map[string]string{}
@@ -301,13 +795,14 @@ func addMetricName(metricName string, labels map[string]string) map[string]strin
 	return result
 }
 
-// generateExamplers returns a slice of prompb.Exemplar from a slice of metricdata.Exemplar
-func generateExamplers[N int64 | float64](exemplars []metricdata.Exemplar[N]) []prompb.Exemplar {
-	labels := map[string]string{}
-	result := make([]prompb.Exemplar, 0, len(exemplars))
+// generateExamplers returns a slice of prompb.Exemplar from a slice of metricdata.Exemplar, with the trace and
+// span IDs in the given labels
+func generateExamplers[N int64 | float64](exemplars []metricdata.Exemplar[N], exemplarLabels exemplarLabelNames, utf8Names bool) []prompb.Exemplar {
+	result := make([]prompb.Exemplar, len(exemplars))
 	for i, ex := range exemplars {
-		labels[traceIdLabelName] = hex.EncodeToString(ex.TraceID[:])
-		labels[spanIdLabelName] = hex.EncodeToString(ex.SpanID[:])
+		labels := map[string]string{}
+		labels[exemplarLabels.traceID] = hex.EncodeToString(ex.TraceID[:])
+		labels[exemplarLabels.spanID] = hex.EncodeToString(ex.SpanID[:])
 
 		for _, attr := range ex.FilteredAttributes {
 			labels[string(attr.Key)] = attr.Value.Emit()
@@ -316,7 +811,7 @@ func generateExamplers[N int64 | float64](exemplars []metricdata.Exemplar[N]) []
 		result[i] = prompb.Exemplar{
 			Value:     float64(ex.Value),
 			Timestamp: ex.Time.UnixNano() / int64(time.Millisecond),
-			Labels:    createLabelSet(labels),
+			Labels:    createLabelSet(labels, utf8Names),
 		}
 	}
 	return result
@@ -329,37 +824,53 @@ func generateDataPointLabels(metricName string, labels map[string]string, attrib
 	return result
 }
 
+// exemplarLabelNames holds the names of the exemplar labels carrying the trace and span IDs.
+type exemplarLabelNames struct {
+	traceID string
+	spanID  string
+}
+
 // createLabelSet combines attributes from a Record, resource, and extra attributes to create a
 // slice of prompb.Label.
-func createLabelSet(labels map[string]string) []prompb.Label {
-	res := make([]prompb.Label, 0, len(labels))
-
-	for l := range labels {
-		res = append(res, prompb.Label{
-			Name:  sanitize(l),
-			Value: labels[l],
-		})
-	}
-
-	return res
+// The labels are sorted by name as the remote write protocol requires. Labels with an empty value are
+// omitted, and the values of keys that are sanitized to the same label name are joined with ";".
+func createLabelSet(labels map[string]string, utf8Names bool) []prompb.Label {
+	b := getLabelBuilder(labels, utf8Names, nil)
+	defer b.release()
+	return b.build()
 }
 
 // Aggregation returns the default Aggregation to use for an instrument kind.
-// Currently unused in this exporter, as it returns old sdk types. Therefore, in metric processing
-// we directly inspects the metric data type.
-// Retained for consistency with other OpenTelemetry exporters.
+// Histograms use an explicit bucket histogram with the configured HistogramBoundaries. Bucket boundaries
+// advised by an instrument, e.g. with metric.WithExplicitBucketBoundaries, take precedence over them.
+// Metric processing does not depend on the aggregation, as it directly inspects the metric data type.
 func (e *Exporter) Aggregation(k metric.InstrumentKind) metric.Aggregation {
+	e.warnings.aggregationUsed.Store(true)
+	if k == metric.InstrumentKindHistogram && len(e.config.HistogramBoundaries) > 0 {
+		// The SDK only applies instrument advice to explicit bucket histograms.
+		return metric.AggregationExplicitBucketHistogram{Boundaries: e.config.HistogramBoundaries}
+	}
 	return metric.DefaultAggregationSelector(k)
 }
 
-// addHeaders adds required headers, an Authorization header, and all headers in the
+// addHeaders adds required headers for the remote write protocol, an Authorization header, and all headers in the
 // Config Headers map to a http request.
-func (e *Exporter) addHeaders(req *http.Request) error {
-	// Logz.io expects Snappy-compressed protobuf messages. These three headers are
-	// hard-coded as they should be on every request.
-	req.Header.Add("X-Prometheus-Remote-Write-Version", "0.1.0")
-	req.Header.Add("Content-Encoding", "snappy")
-	req.Header.Set("Content-Type", "application/x-protobuf")
+func (e *Exporter) addHeaders(req *http.Request, protocol RemoteWriteProtocol) error {
+	// Add the headers from Config.Headers first, so they cannot override the required headers.
+	for name, value := range e.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// Logz.io expects compressed protobuf messages. These three headers are
+	// set on every request.
+	req.Header.Set("Content-Encoding", e.config.Compression.contentEncoding())
+	if protocol == RemoteWrite2 {
+		req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWrite2Version)
+		req.Header.Set("Content-Type", remoteWrite2ContentType)
+	} else {
+		req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+		req.Header.Set("Content-Type", "application/x-protobuf")
+	}
 	req.Header.Set("User-Agent", "logzio-go-sdk-metrics")
 
 	// Add Authorization header
@@ -369,12 +880,21 @@ func (e *Exporter) addHeaders(req *http.Request) error {
 	return nil
 }
 
-// buildMessage creates a Snappy-compressed protobuf message from a slice of TimeSeries.
+// buildMessage creates a Snappy-compressed protobuf message from a slice of TimeSeries, which are sorted in place.
 func (e *Exporter) buildMessage(timeseries []prompb.TimeSeries) ([]byte, error) {
+	if err := e.injectFault(FaultStageCompression); err != nil {
+		return nil, err
+	}
+
+	sortTimeSeries(timeseries)
+
 	// Wrap the TimeSeries as a WriteRequest since Logz.io requires it.
 	writeRequest := &prompb.WriteRequest{
 		Timeseries: timeseries,
 	}
+	if e.config.SendMetadata {
+		writeRequest.Metadata = e.writeRequestMetadata(timeseries)
+	}
 
 	// Convert the struct to a slice of bytes and then compress it.
 	message := make([]byte, writeRequest.Size())
@@ -388,69 +908,151 @@ func (e *Exporter) buildMessage(timeseries []prompb.TimeSeries) ([]byte, error)
 	return compressed, nil
 }
 
-// buildRequest creates http POST request with a Snappy-compressed protocol buffer
-// message as the body and with all the headers attached.
-func (e *Exporter) buildRequest(message []byte) (*http.Request, error) {
-	req, err := http.NewRequest(
+// buildRequest creates http POST request bound to ctx with a compressed protocol buffer
+// message of a remote write protocol as the body and with all the headers attached. The message is not modified,
+// and the request body can be re-read through the request GetBody.
+func (e *Exporter) buildRequest(ctx context.Context, message []byte, protocol RemoteWriteProtocol) (*http.Request, error) {
+	listenerURL, err := e.config.listenerURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var encoding string
+	if e.config.PayloadTransformer != nil {
+		message, encoding, err = e.config.PayloadTransformer.TransformPayload(message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform payload: %w", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
-		e.config.LogzioMetricsListener,
-		bytes.NewBuffer(message),
+		listenerURL,
+		bytes.NewReader(message),
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	// Add the required headers and the headers from Config.Headers.
-	err = e.addHeaders(req)
+	err = e.addHeaders(req, protocol)
 	if err != nil {
 		return nil, err
 	}
 
+	if encoding != "" {
+		req.Header.Set(payloadTransformHeader, encoding)
+	}
+
+	if e.config.BatchIDHeader != "" {
+		req.Header.Set(e.config.BatchIDHeader, newBatchID(time.Now()))
+	}
+
+	if e.config.RequestSigner != nil {
+		if err := e.config.RequestSigner.SignRequest(req, message); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+
 	return req, nil
 }
 
 // sendRequest sends http request using the Exporter's http Client.
-func (e *Exporter) sendRequest(req *http.Request) error {
-	// Set a client if there is no client.
-	if e.config.client == nil {
-		e.config.client = &http.Client{
-			Transport: http.DefaultTransport,
-			Timeout:   e.config.RemoteTimeout,
+func (e *Exporter) sendRequest(req *http.Request) (http.Header, error) {
+	var res *http.Response
+	var err error
+	if shared := e.config.SharedTransport; shared != nil {
+		// The shared client has no timeout, so the timeout of this exporter is applied to the request.
+		ctx, cancel := context.WithTimeout(req.Context(), e.config.RemoteTimeout)
+		defer cancel()
+		res, err = shared.do(ctx, req)
+	} else {
+		// Set a client if there is no client.
+		if e.config.client == nil {
+			e.config.client = &http.Client{
+				Transport:     e.config.transport(),
+				Timeout:       e.config.RemoteTimeout,
+				CheckRedirect: checkRedirect(e.config.RedirectPolicy),
+			}
 		}
-	}
 
-	// Attempt to send request.
-	res, err := e.config.client.Do(req)
+		// Attempt to send request.
+		res, err = e.config.client.Do(req)
+	}
 	if err != nil {
-		return err
+		e.telemetry.recordFailedRequest(0)
+		// Requests canceled by the caller, or past the caller deadline, are not retried.
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		// Network errors and timeouts are transient.
+		return nil, &retryableError{err: err}
 	}
 	defer res.Body.Close()
 
-	// The response should have a status code of 200.
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("%v", res.Status)
+	// The response should have a 2xx status code, as defined by the remote write protocol.
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		e.telemetry.recordFailedRequest(res.StatusCode)
+		err := responseError(res)
+		if e.config.BatchIDHeader != "" {
+			err = fmt.Errorf("%w (batch %s)", err, req.Header.Get(e.config.BatchIDHeader))
+		}
+		if isRetryableStatus(res.StatusCode) {
+			return nil, &retryableError{err: err, retryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now())}
+		}
+		// Listeners reject the content types of the protocols they do not support with 415 Unsupported Media Type.
+		if res.StatusCode == http.StatusUnsupportedMediaType {
+			return nil, &unsupportedProtocolError{err: err}
+		}
+		return nil, err
 	}
-	return nil
+	return res.Header, nil
 }
 
 // ForceFlush flushes any metric data held by an exporter.
 func (e *Exporter) ForceFlush(ctx context.Context) error {
+	if e.queue != nil {
+		return e.flushQueue(ctx)
+	}
 	// The exporter and client hold no state, nothing to flush.
 	return ctx.Err()
 }
 
 // Shutdown flushes all metric data held by an exporter and releases any held computational resources.
+// Shutdown does nothing for an exporter returned by Global, which is shared: it is shut down by Release.
 func (e *Exporter) Shutdown(ctx context.Context) error {
+	if e.globalKey != nil {
+		return nil
+	}
+	return e.shutdown(ctx)
+}
+
+// shutdown shuts the exporter down, see Shutdown.
+func (e *Exporter) shutdown(ctx context.Context) error {
 	err := fmt.Errorf("HTTP exporter is shutdown")
 	e.shutdownOnce.Do(func() {
-		err = e.ForceFlush(ctx)
+		// The markers are queued before the worker stops, so they are sent after the last export.
+		markersErr := e.sendStalenessMarkers(ctx, time.Now())
+		if e.queue != nil {
+			err = e.stopWorker(ctx)
+		} else {
+			err = e.ForceFlush(ctx)
+		}
+		if markersErr != nil {
+			err = multierror.Append(err, markersErr).ErrorOrNil()
+		}
+		if e.telemetryReg != nil {
+			if unregisterErr := e.telemetryReg.Unregister(); unregisterErr != nil {
+				err = multierror.Append(err, unregisterErr).ErrorOrNil()
+			}
+		}
 
+		e.clientMu.Lock()
 		if e.config.client != nil {
-			e.clientMu.Lock()
 			e.config.client.CloseIdleConnections()
 			e.config.client = nil
-			e.clientMu.Unlock()
 		}
+		e.clientMu.Unlock()
 	})
 	return err
 }
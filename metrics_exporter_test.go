@@ -15,12 +15,17 @@
 package metrics_exporter
 
 import (
+	"context"
 	"fmt"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"testing"
 	"time"
 
@@ -185,7 +190,7 @@ func TestBuildRequest(t *testing.T) {
 	exporter := Exporter{config: validConfig}
 
 	// Create the http request.
-	req, err := exporter.buildRequest(testMessage)
+	req, err := exporter.buildRequest(context.Background(), testMessage, RemoteWrite1)
 	require.NoError(t, err)
 
 	// Verify the http method, url, and body.
@@ -323,11 +328,11 @@ func TestSendRequest(t *testing.T) {
 			require.NoError(t, err)
 
 			// Create a http POST request with the compressed message.
-			req, err := exporter.buildRequest(msg)
+			req, err := exporter.buildRequest(context.Background(), msg, RemoteWrite1)
 			require.NoError(t, err)
 
 			// Send the request to the test server and verify the error.
-			err = exporter.sendRequest(req)
+			_, err = exporter.sendRequest(req)
 			if err != nil {
 				errorString := err.Error()
 				require.Equal(t, errorString, test.expectedError.Error())
@@ -337,3 +342,540 @@ func TestSendRequest(t *testing.T) {
 		})
 	}
 }
+
+// TestConvertToTimeSeriesScopeInfo tests that scope attributes are moved to a single
+// otel_scope_info series when EmitScopeInfo is set.
+func TestConvertToTimeSeriesScopeInfo(t *testing.T) {
+	exporter := Exporter{config: Config{EmitScopeInfo: true}}
+	rm := getSumMetric(5)
+	rm.ScopeMetrics[0].Scope.Attributes = attribute.NewSet(attribute.String("scope.attr", "value"))
+
+	got, err := exporter.ConvertToTimeSeries(rm)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	labels := make(map[string]map[string]string)
+	for _, ts := range got {
+		l := make(map[string]string)
+		for _, label := range ts.Labels {
+			l[label.Name] = label.Value
+		}
+		labels[l["__name__"]] = l
+	}
+
+	require.Contains(t, labels, "otel_scope_info")
+	assert.Equal(t, "value", labels["otel_scope_info"]["scope_attr"])
+	assert.Equal(t, "test-meter", labels["otel_scope_info"]["otel_scope_name"])
+	assert.Equal(t, float64(1), got[0].Samples[0].Value)
+
+	require.Contains(t, labels, "metric_sum")
+	assert.NotContains(t, labels["metric_sum"], "scope_attr")
+	assert.Equal(t, "0.0.1", labels["metric_sum"]["otel_scope_version"])
+}
+
+// TestConvertToTimeSeriesBuildInfo tests that a logzio_exporter_build_info series is emitted when EmitBuildInfo is set.
+func TestConvertToTimeSeriesBuildInfo(t *testing.T) {
+	exporter := Exporter{config: Config{EmitBuildInfo: true, ExternalLabels: map[string]string{"env": "prod"}}}
+
+	got, err := exporter.ConvertToTimeSeries(getSumMetric(5))
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	labels := make(map[string]string)
+	for _, label := range got[0].Labels {
+		labels[label.Name] = label.Value
+	}
+	assert.Equal(t, "logzio_exporter_build_info", labels["__name__"])
+	assert.Equal(t, Version(), labels["version"])
+	assert.Equal(t, runtime.Version(), labels["go_version"])
+	assert.Equal(t, "0.1.0", labels["protocol"])
+	assert.Equal(t, "prod", labels["env"])
+	assert.Equal(t, float64(1), got[0].Samples[0].Value)
+}
+
+// TestConvertToTimeSeriesLabelNamespace tests that the labels added by the exporter are prefixed with the
+// LabelNamespace, so they do not collide with attributes of the same name.
+func TestConvertToTimeSeriesLabelNamespace(t *testing.T) {
+	exporter := Exporter{config: Config{EmitBuildInfo: true, LabelNamespace: "logzio"}}
+	rm := getSumMetric(5)
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	sum.DataPoints[0].Attributes = attribute.NewSet(attribute.String("version", "v2"))
+
+	got, err := exporter.ConvertToTimeSeries(rm)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	buildInfo := make(map[string]string)
+	for _, label := range got[0].Labels {
+		buildInfo[label.Name] = label.Value
+	}
+	assert.Equal(t, Version(), buildInfo["logzio_version"])
+	assert.Equal(t, remoteWriteVersion, buildInfo["logzio_protocol"])
+	assert.NotContains(t, buildInfo, "version")
+
+	labels := make(map[string]string)
+	for _, label := range got[1].Labels {
+		labels[label.Name] = label.Value
+	}
+	assert.Equal(t, "test-meter", labels["logzio_otel_scope_name"])
+	assert.Equal(t, "0.0.1", labels["logzio_otel_scope_version"])
+	assert.Equal(t, "v2", labels["version"])
+	assert.NotContains(t, labels, "otel_scope_name")
+}
+
+// TestConvertToTimeSeriesWithoutResourceAttributes tests that only job and instance labels
+// are derived from the resource when CopyResourceAttributes is false.
+func TestConvertToTimeSeriesWithoutResourceAttributes(t *testing.T) {
+	copyResourceAttributes := false
+	exporter := Exporter{config: Config{
+		CopyResourceAttributes: &copyResourceAttributes,
+		ExternalLabels:         map[string]string{"label": "value"},
+	}}
+	rm := getSumMetric(5)
+	rm.Resource = resource.NewSchemaless(
+		attribute.String("service.name", "test"),
+		attribute.String("service.namespace", "ns"),
+		attribute.String("service.instance.id", "instance-1"),
+		attribute.String("host.name", "host"),
+	)
+
+	got, err := exporter.ConvertToTimeSeries(rm)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+
+	labels := make(map[string]string)
+	for _, label := range got[0].Labels {
+		labels[label.Name] = label.Value
+	}
+	assert.Equal(t, "ns/test", labels["job"])
+	assert.Equal(t, "instance-1", labels["instance"])
+	assert.Equal(t, "value", labels["label"])
+	assert.NotContains(t, labels, "service_name")
+	assert.NotContains(t, labels, "host_name")
+}
+
+// TestConvertToTimeSeriesDuplicateMetricType tests that each DuplicateMetricTypePolicy is applied when two
+// metrics with the same name have different types.
+func TestConvertToTimeSeriesDuplicateMetricType(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    DuplicateMetricTypePolicy
+		wantNames []string
+		wantError bool
+	}{
+		{
+			name:      "suffix",
+			policy:    DuplicateMetricTypeSuffix,
+			wantNames: []string{"requests", "requests_gauge"},
+		},
+		{
+			name:      "drop",
+			policy:    DuplicateMetricTypeDrop,
+			wantNames: []string{"requests"},
+		},
+		{
+			name:      "error",
+			policy:    DuplicateMetricTypeError,
+			wantNames: []string{"requests"},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := Exporter{config: Config{DuplicateMetricTypePolicy: tt.policy}}
+			rm := getSumMetric(5)
+			gauge := getGaugeMetric(3).ScopeMetrics[0].Metrics[0]
+			gauge.Name = "requests"
+			rm.ScopeMetrics[0].Metrics[0].Name = "requests"
+			rm.ScopeMetrics[0].Metrics = append(rm.ScopeMetrics[0].Metrics, gauge)
+
+			got, err := exporter.ConvertToTimeSeries(rm)
+			if tt.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var gotNames []string
+			for _, ts := range got {
+				for _, label := range ts.Labels {
+					if label.Name == "__name__" {
+						gotNames = append(gotNames, label.Value)
+					}
+				}
+			}
+			assert.Equal(t, tt.wantNames, gotNames)
+		})
+	}
+}
+
+// TestConvertToTimeSeriesNonMonotonicSumName tests that only non-monotonic sums are renamed with the
+// NonMonotonicSumSuffix and UpDownCounterGaugeNames.
+func TestConvertToTimeSeriesNonMonotonicSumName(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		monotonic  bool
+		metricName string
+		wantName   string
+	}{
+		{name: "monotonic", config: Config{NonMonotonicSumSuffix: "_gauge"}, monotonic: true, metricName: "requests", wantName: "requests"},
+		{name: "non-monotonic", config: Config{NonMonotonicSumSuffix: "_gauge"}, monotonic: false, metricName: "active_requests", wantName: "active_requests_gauge"},
+		{name: "existing suffix", config: Config{NonMonotonicSumSuffix: "_gauge"}, monotonic: false, metricName: "queue_gauge", wantName: "queue_gauge"},
+		{name: "gauge naming", config: Config{UpDownCounterGaugeNames: true}, monotonic: false, metricName: "connections_total", wantName: "connections"},
+		{name: "gauge naming of a counter", config: Config{UpDownCounterGaugeNames: true}, monotonic: true, metricName: "requests_total", wantName: "requests_total"},
+		{name: "gauge naming with suffix", config: Config{UpDownCounterGaugeNames: true, NonMonotonicSumSuffix: "_gauge"}, monotonic: false, metricName: "connections_total", wantName: "connections_gauge"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := Exporter{config: tt.config}
+			rm := getSumMetric(5)
+			sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+			sum.IsMonotonic = tt.monotonic
+			rm.ScopeMetrics[0].Metrics[0].Data = sum
+			rm.ScopeMetrics[0].Metrics[0].Name = tt.metricName
+
+			got, err := exporter.ConvertToTimeSeries(rm)
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			assert.Contains(t, got[0].Labels, prompb.Label{Name: "__name__", Value: tt.wantName})
+		})
+	}
+}
+
+// TestExportLowMemory tests that low-memory mode splits the export into requests of at most
+// lowMemoryBatchSize timeseries.
+func TestExportLowMemory(t *testing.T) {
+	var requests, series int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		uncompressed, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		wr := &prompb.WriteRequest{}
+		require.NoError(t, wr.Unmarshal(uncompressed))
+
+		requests++
+		series += len(wr.Timeseries)
+		assert.LessOrEqual(t, len(wr.Timeseries), lowMemoryBatchSize)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		LowMemory:             true,
+	})
+	require.NoError(t, err)
+
+	rm := getSumMetric(5)
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	dp := sum.DataPoints[0]
+	sum.DataPoints = nil
+	for i := 0; i < 2*lowMemoryBatchSize+1; i++ {
+		dp.Attributes = attribute.NewSet(attribute.Int("index", i))
+		sum.DataPoints = append(sum.DataPoints, dp)
+	}
+	rm.ScopeMetrics[0].Metrics[0].Data = sum
+
+	require.NoError(t, exporter.Export(context.Background(), rm))
+	assert.Equal(t, len(sum.DataPoints), series)
+	assert.Equal(t, 3, requests)
+}
+
+// TestSendRequestDialNetwork tests that requests are sent over the configured dial network.
+func TestSendRequestDialNetwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	assert.Equal(t, http.DefaultTransport, (&Config{}).transport())
+
+	exporter := Exporter{config: Config{
+		LogzioMetricsListener: server.URL,
+		RemoteTimeout:         time.Second,
+		DialNetwork:           "tcp4",
+		DialFallbackDelay:     -1,
+	}}
+	req, err := exporter.buildRequest(context.Background(), []byte{}, RemoteWrite1)
+	require.NoError(t, err)
+	_, err = exporter.sendRequest(req)
+	require.NoError(t, err)
+	assert.NotSame(t, http.DefaultTransport, exporter.config.client.Transport)
+}
+
+// TestEffectiveConfigDuringExport checks that the effective config can be read while the first request creates
+// the client of the exporter.
+func TestEffectiveConfigDuringExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a"})
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- exporter.Export(context.Background(), getSumMetric(1))
+	}()
+	assert.Equal(t, server.URL, exporter.EffectiveConfig().LogzioMetricsListener)
+	require.NoError(t, <-done)
+}
+
+// TestBuildRequestHeaders tests that the configured headers are added without overriding the required headers.
+func TestBuildRequestHeaders(t *testing.T) {
+	exporter := Exporter{config: validConfig}
+	exporter.config.Headers = map[string]string{"X-Feature": "exemplars", "Content-Encoding": "gzip"}
+
+	req, err := exporter.buildRequest(context.Background(), []byte{}, RemoteWrite1)
+	require.NoError(t, err)
+	assert.Equal(t, "exemplars", req.Header.Get("X-Feature"))
+	assert.Equal(t, []string{"snappy"}, req.Header.Values("Content-Encoding"))
+}
+
+// TestBuildRequestListenerURL tests that the path and query of the listener are kept, and that the
+// ListenerQueryParams are added to the query.
+func TestBuildRequestListenerURL(t *testing.T) {
+	exporter := Exporter{config: Config{
+		LogzioMetricsListener: "https://listener.logz.io:8053/api/v1/write?region=eu",
+		ListenerQueryParams:   map[string]string{"account": "42"},
+	}}
+
+	req, err := exporter.buildRequest(context.Background(), []byte{}, RemoteWrite1)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/write", req.URL.Path)
+	assert.Equal(t, "eu", req.URL.Query().Get("region"))
+	assert.Equal(t, "42", req.URL.Query().Get("account"))
+}
+
+// TestBuildRequestReusesMessage tests that requests built from the same message have
+// identical, re-readable bodies.
+func TestBuildRequestReusesMessage(t *testing.T) {
+	exporter := Exporter{config: validConfig}
+	message, err := exporter.buildMessage([]prompb.TimeSeries{})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		req, err := exporter.buildRequest(context.Background(), message, RemoteWrite1)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.Equal(t, message, body)
+
+		require.NotNil(t, req.GetBody)
+		rereadBody, err := req.GetBody()
+		require.NoError(t, err)
+		reread, err := io.ReadAll(rereadBody)
+		require.NoError(t, err)
+		require.Equal(t, message, reread)
+	}
+}
+
+// TestAddDefaultInstanceLabel tests that the instance label is only added when the resource has no
+// service.instance.id or host.name and no instance label is set.
+// TestConvertToTimeSeriesProducerData tests that data of producers other than the SDK, such as bridges, is
+// converted without panics or zero timestamps.
+func TestConvertToTimeSeriesProducerData(t *testing.T) {
+	tests := []struct {
+		name  string
+		input func() *metricdata.ResourceMetrics
+		want  int
+	}{
+		{
+			name:  "nil resource metrics",
+			input: func() *metricdata.ResourceMetrics { return nil },
+			want:  0,
+		},
+		{
+			name: "nil resource",
+			input: func() *metricdata.ResourceMetrics {
+				rm := getSumMetric(5)
+				rm.Resource = nil
+				return rm
+			},
+			want: 1,
+		},
+		{
+			name: "empty scopes",
+			input: func() *metricdata.ResourceMetrics {
+				rm := getSumMetric(5)
+				rm.ScopeMetrics = append(rm.ScopeMetrics, metricdata.ScopeMetrics{})
+				return rm
+			},
+			want: 1,
+		},
+		{
+			name: "zero timestamps",
+			input: func() *metricdata.ResourceMetrics {
+				rm := getSumMetric(5)
+				sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+				sum.DataPoints[0].Time = time.Time{}
+				sum.DataPoints[0].StartTime = time.Time{}
+				return rm
+			},
+			want: 1,
+		},
+		{
+			name: "delta sum without start time",
+			input: func() *metricdata.ResourceMetrics {
+				rm := getSumMetric(5)
+				sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+				sum.Temporality = metricdata.DeltaTemporality
+				sum.DataPoints[0].StartTime = time.Time{}
+				rm.ScopeMetrics[0].Metrics[0].Data = sum
+				return rm
+			},
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := Exporter{}
+			start := time.Now().UnixMilli()
+
+			got, err := exporter.ConvertToTimeSeries(tt.input())
+			require.NoError(t, err)
+			require.Len(t, got, tt.want)
+
+			for _, ts := range got {
+				require.Len(t, ts.Samples, 1)
+				assert.GreaterOrEqual(t, ts.Samples[0].Timestamp, start)
+				assert.Equal(t, float64(5), ts.Samples[0].Value)
+			}
+		})
+	}
+}
+
+func TestAddDefaultInstanceLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource *resource.Resource
+		labels   map[string]string
+		want     string
+	}{
+		{
+			name:     "no instance attributes",
+			resource: getResource(),
+			labels:   map[string]string{},
+			want:     "replica-1",
+		},
+		{
+			name:     "service instance id",
+			resource: resource.NewSchemaless(attribute.String("service.instance.id", "id")),
+			labels:   map[string]string{},
+			want:     "",
+		},
+		{
+			name:     "host name",
+			resource: resource.NewSchemaless(attribute.String("host.name", "host")),
+			labels:   map[string]string{},
+			want:     "",
+		},
+		{
+			name:     "existing instance label",
+			resource: getResource(),
+			labels:   map[string]string{"instance": "external"},
+			want:     "external",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addDefaultInstanceLabel(tt.labels, tt.resource, "replica-1")
+			assert.Equal(t, tt.want, tt.labels["instance"])
+		})
+	}
+}
+
+func TestGenerateExemplarsLabelNames(t *testing.T) {
+	exemplars := []metricdata.Exemplar[int64]{{
+		Value:   1,
+		Time:    time.Unix(1, 0),
+		TraceID: []byte{1, 2},
+		SpanID:  []byte{3},
+	}}
+
+	config := Config{}
+	got := generateExamplers(exemplars, config.exemplarLabelNames(), false)
+	assert.Equal(t, []prompb.Label{{Name: "span_id", Value: "03"}, {Name: "trace_id", Value: "0102"}}, got[0].Labels)
+
+	config = Config{ExemplarTraceIDLabel: "traceID", ExemplarSpanIDLabel: "spanID"}
+	got = generateExamplers(exemplars, config.exemplarLabelNames(), false)
+	assert.Equal(t, []prompb.Label{{Name: "spanID", Value: "03"}, {Name: "traceID", Value: "0102"}}, got[0].Labels)
+}
+
+// BenchmarkConvertToTimeSeries measures the allocations of converting a sum with 100k series.
+func BenchmarkConvertToTimeSeries(b *testing.B) {
+	rm := getSumMetricWithSeries(100_000)
+	exporter := Exporter{config: Config{ExternalLabels: map[string]string{"env": "prod"}}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := exporter.ConvertToTimeSeries(rm); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkConvertToTimeSeriesHistogram measures the allocations of converting a histogram with 100k series.
+func BenchmarkConvertToTimeSeriesHistogram(b *testing.B) {
+	rm := getHistogramMetric(1, metricdata.NewExtrema[int64](2), metricdata.NewExtrema[int64](2), 2)
+	histogram := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram[int64])
+	dp := histogram.DataPoints[0]
+	histogram.DataPoints = nil
+	for i := 0; i < 100_000; i++ {
+		dp.Attributes = attribute.NewSet(attribute.Int("index", i), attribute.String("method", "GET"))
+		histogram.DataPoints = append(histogram.DataPoints, dp)
+	}
+	rm.ScopeMetrics[0].Metrics[0].Data = histogram
+	exporter := Exporter{config: Config{ExternalLabels: map[string]string{"env": "prod"}}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := exporter.ConvertToTimeSeries(rm); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSendTimeSeriesMaxRequestBodyBytes(t *testing.T) {
+	var sizes []int
+	var series int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		sizes = append(sizes, len(compressed))
+		uncompressed, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		wr := &prompb.WriteRequest{}
+		require.NoError(t, wr.Unmarshal(uncompressed))
+		series += len(wr.Timeseries)
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	timeseries := make([]prompb.TimeSeries, 100)
+	for i := range timeseries {
+		timeseries[i] = prompb.TimeSeries{
+			Labels:  []prompb.Label{{Name: "__name__", Value: fmt.Sprintf("metric_%d", i)}},
+			Samples: []prompb.Sample{{Value: float64(i), Timestamp: int64(i)}},
+		}
+	}
+	exporter := Exporter{config: Config{LogzioMetricsListener: server.URL, MaxRequestBodyBytes: 512}}
+	require.NoError(t, exporter.sendTimeSeries(context.Background(), timeseries))
+
+	assert.Greater(t, len(sizes), 1, "the series are split across requests")
+	for _, size := range sizes {
+		assert.LessOrEqual(t, size, 512)
+	}
+	assert.Equal(t, len(timeseries), series)
+}
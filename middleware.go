@@ -0,0 +1,53 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// The Exporter is used through the metric.Exporter interface, so it can be decorated like any other exporter.
+var _ metric.Exporter = (*Exporter)(nil)
+
+// ExportFunc exports a batch of metrics.
+type ExportFunc func(ctx context.Context, rm *metricdata.ResourceMetrics) error
+
+// Middleware decorates the Export calls of an exporter, e.g. to copy the metrics to a local file or to
+// measure the exports. It returns an ExportFunc that is expected to call next.
+type Middleware func(next ExportFunc) ExportFunc
+
+// middlewareExporter is a metric.Exporter that passes Export calls through a chain of middlewares.
+type middlewareExporter struct {
+	metric.Exporter
+	export ExportFunc
+}
+
+// Export calls the middleware chain.
+func (m *middlewareExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	return m.export(ctx, rm)
+}
+
+// WithMiddleware returns a metric.Exporter that passes every Export call through the middlewares before
+// the exporter. The first middleware is the outermost one. All other methods are called on the exporter.
+func WithMiddleware(exporter metric.Exporter, middlewares ...Middleware) metric.Exporter {
+	export := ExportFunc(exporter.Export)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		export = middlewares[i](export)
+	}
+	return &middlewareExporter{Exporter: exporter, export: export}
+}
@@ -0,0 +1,57 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestWithMiddleware(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a"})
+	require.NoError(t, err)
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next ExportFunc) ExportFunc {
+			return func(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+				calls = append(calls, name)
+				return next(ctx, rm)
+			}
+		}
+	}
+
+	wrapped := WithMiddleware(exporter, record("outer"), record("inner"))
+	require.NoError(t, wrapped.Export(context.Background(), getSumMetric(5)))
+
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, metricdata.CumulativeTemporality, wrapped.Temporality(metric.InstrumentKindCounter))
+	require.NoError(t, wrapped.Shutdown(context.Background()))
+}
@@ -0,0 +1,129 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"log"
+	"maps"
+	"net/http"
+	"time"
+)
+
+// Option sets fields of the Config of an Exporter created by NewWithOptions.
+type Option func(*Config)
+
+// NewWithOptions returns a Logzio Prometheus remote write Exporter configured by the options, which are applied in
+// order. Fields that no option sets keep their defaults, as with New.
+func NewWithOptions(opts ...Option) (*Exporter, error) {
+	var config Config
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return New(config)
+}
+
+// WithConfig sets every field of the Config, e.g. to set fields that have no option. It replaces the fields set by
+// earlier options, so it should come first.
+func WithConfig(config Config) Option {
+	return func(c *Config) {
+		// The client is not part of the public Config and is kept.
+		client := c.client
+		*c = config
+		c.client = client
+	}
+}
+
+// WithListener sets the Logz.io metrics listener URL.
+func WithListener(listener string) Option {
+	return func(c *Config) {
+		c.LogzioMetricsListener = listener
+	}
+}
+
+// WithToken sets the Logz.io metrics token.
+func WithToken(token string) Option {
+	return func(c *Config) {
+		c.LogzioMetricsToken = token
+	}
+}
+
+// WithTimeout sets the timeout of the requests to the listener.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.RemoteTimeout = timeout
+	}
+}
+
+// WithHeaders adds headers to the requests to the listener.
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Config) {
+		c.Headers = mergeOptionLabels(c.Headers, headers)
+	}
+}
+
+// WithExternalLabels adds labels to every series.
+func WithExternalLabels(labels map[string]string) Option {
+	return func(c *Config) {
+		c.ExternalLabels = mergeOptionLabels(c.ExternalLabels, labels)
+	}
+}
+
+// WithHTTPClient sets the client that sends the requests to the listener, unless a SharedTransport is set. The
+// timeout, transport and redirect policy of the client are used instead of RemoteTimeout, DialNetwork and
+// RedirectPolicy.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) {
+		c.client = client
+	}
+}
+
+// WithPushInterval sets the interval of the periodic reader returned by Exporter.NewPeriodicReader.
+func WithPushInterval(interval time.Duration) Option {
+	return func(c *Config) {
+		c.PushInterval = interval
+	}
+}
+
+// WithRetry sets the retries of transient send failures.
+func WithRetry(retry RetryConfig) Option {
+	return func(c *Config) {
+		c.Retry = retry
+	}
+}
+
+// WithAsyncQueueSize sends the requests asynchronously through a queue of the given size.
+func WithAsyncQueueSize(size int) Option {
+	return func(c *Config) {
+		c.AsyncQueueSize = size
+	}
+}
+
+// WithLogger sets the logger of the configuration warnings and send failures.
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// mergeOptionLabels returns a copy of current with the added entries, so options do not share the maps of
+// the caller.
+func mergeOptionLabels(current, added map[string]string) map[string]string {
+	merged := maps.Clone(current)
+	if merged == nil {
+		merged = make(map[string]string, len(added))
+	}
+	maps.Copy(merged, added)
+	return merged
+}
@@ -0,0 +1,84 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	labels := map[string]string{"env": "prod"}
+	exporter, err := NewWithOptions(
+		WithConfig(Config{PushInterval: 30 * time.Second, AddMetricSuffixes: true}),
+		WithListener("https://listener.example.com:8053"),
+		WithToken("token"),
+		WithTimeout(5*time.Second),
+		WithExternalLabels(labels),
+		WithExternalLabels(map[string]string{"region": "us"}),
+		WithHeaders(map[string]string{"X-Team": "payments"}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://listener.example.com:8053", exporter.config.LogzioMetricsListener)
+	assert.Equal(t, "token", exporter.config.LogzioMetricsToken)
+	assert.Equal(t, 5*time.Second, exporter.config.RemoteTimeout)
+	assert.Equal(t, 30*time.Second, exporter.config.PushInterval)
+	assert.True(t, exporter.config.AddMetricSuffixes)
+	assert.Equal(t, map[string]string{"env": "prod", "region": "us"}, exporter.config.ExternalLabels)
+	assert.Equal(t, map[string]string{"X-Team": "payments"}, exporter.config.Headers)
+	assert.Equal(t, map[string]string{"env": "prod"}, labels, "the labels of the caller are not modified")
+}
+
+func TestNewWithOptionsValidates(t *testing.T) {
+	_, err := NewWithOptions(WithListener("https://listener.example.com:8053"))
+	assert.ErrorIs(t, err, ErrNoLogzioMetricsToken)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	exporter, err := NewWithOptions(WithToken("token"), WithListener(server.URL), WithHTTPClient(client), WithConfig(Config{}))
+	require.Error(t, err, "WithConfig replaces the token")
+	assert.Nil(t, exporter)
+
+	exporter, err = NewWithOptions(WithHTTPClient(client), WithConfig(Config{LogzioMetricsToken: "token", LogzioMetricsListener: server.URL}))
+	require.NoError(t, err)
+	req, err := exporter.buildRequest(context.Background(), []byte{}, RemoteWrite1)
+	require.NoError(t, err)
+	_, err = exporter.sendRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
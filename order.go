@@ -0,0 +1,77 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"hash/fnv"
+	"sort"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// sortTimeSeries orders the series by metric name and then by the hash of their labels, so that the same series
+// are sent in the same order. Series of a metric next to each other compress better, and reproducible payloads
+// can be compared.
+func sortTimeSeries(timeseries []prompb.TimeSeries) {
+	order := seriesOrder{
+		timeseries: timeseries,
+		names:      make([]string, len(timeseries)),
+		hashes:     make([]uint64, len(timeseries)),
+	}
+	for i, ts := range timeseries {
+		order.names[i], order.hashes[i] = seriesNameAndHash(ts.Labels)
+	}
+	sort.Stable(order)
+}
+
+// seriesNameAndHash returns the metric name of the labels and the FNV-1a hash of all the labels.
+func seriesNameAndHash(labels []prompb.Label) (string, uint64) {
+	var name string
+	h := fnv.New64a()
+	for _, label := range labels {
+		if label.Name == "__name__" {
+			name = label.Value
+		}
+		h.Write([]byte(label.Name))
+		h.Write([]byte{0xff})
+		h.Write([]byte(label.Value))
+		h.Write([]byte{0xff})
+	}
+	return name, h.Sum64()
+}
+
+// seriesOrder sorts series by the precomputed metric names and label hashes.
+type seriesOrder struct {
+	timeseries []prompb.TimeSeries
+	names      []string
+	hashes     []uint64
+}
+
+func (o seriesOrder) Len() int {
+	return len(o.timeseries)
+}
+
+func (o seriesOrder) Less(i, j int) bool {
+	if o.names[i] != o.names[j] {
+		return o.names[i] < o.names[j]
+	}
+	return o.hashes[i] < o.hashes[j]
+}
+
+func (o seriesOrder) Swap(i, j int) {
+	o.timeseries[i], o.timeseries[j] = o.timeseries[j], o.timeseries[i]
+	o.names[i], o.names[j] = o.names[j], o.names[i]
+	o.hashes[i], o.hashes[j] = o.hashes[j], o.hashes[i]
+}
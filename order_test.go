@@ -0,0 +1,63 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestSortTimeSeries(t *testing.T) {
+	newTimeSeries := func(name, route string) prompb.TimeSeries {
+		return prompb.TimeSeries{Labels: []prompb.Label{{Name: "__name__", Value: name}, {Name: "route", Value: route}}}
+	}
+	timeseries := []prompb.TimeSeries{
+		newTimeSeries("requests", "/b"),
+		newTimeSeries("latency", "/a"),
+		newTimeSeries("requests", "/a"),
+		newTimeSeries("latency", "/b"),
+	}
+	reversed := []prompb.TimeSeries{timeseries[3], timeseries[2], timeseries[1], timeseries[0]}
+
+	sortTimeSeries(timeseries)
+	sortTimeSeries(reversed)
+
+	assert.Equal(t, timeseries, reversed, "the order does not depend on the input order")
+	var names []string
+	for _, ts := range timeseries {
+		names = append(names, ts.Labels[0].Value)
+	}
+	assert.Equal(t, []string{"latency", "latency", "requests", "requests"}, names)
+}
+
+func TestBuildMessageIsReproducible(t *testing.T) {
+	exporter := Exporter{}
+	timeseries, err := exporter.ConvertToTimeSeries(getHistogramMetric(1, metricdata.NewExtrema[int64](4), metricdata.NewExtrema[int64](4), 4))
+	require.NoError(t, err)
+	shuffled := append([]prompb.TimeSeries(nil), timeseries...)
+	for i, j := 0, len(shuffled)-1; i < j; i, j = i+1, j-1 {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+
+	message, err := exporter.buildMessage(timeseries)
+	require.NoError(t, err)
+	shuffledMessage, err := exporter.buildMessage(shuffled)
+	require.NoError(t, err)
+	assert.Equal(t, message, shuffledMessage)
+}
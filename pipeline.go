@@ -0,0 +1,61 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+// NewExportPipeline returns a meter provider that exports to a new Exporter every PushInterval, through the reader
+// returned by Exporter.NewPeriodicReader. Options passed in, e.g. metric.WithResource, are added to the meter
+// provider. Shutting down the meter provider shuts down the exporter.
+func NewExportPipeline(config Config, opts ...metric.Option) (*metric.MeterProvider, error) {
+	provider, _, err := newExportPipeline(config, opts)
+	return provider, err
+}
+
+// InstallExportPipeline returns a meter provider built by NewExportPipeline and registers it as the global meter
+// provider, so that otel.Meter and the instrumentation libraries using the global meter provider export to Logz.io.
+// The meter provider is shut down, exporting the metrics collected since the last push, when ctx is done. Pass
+// context.Background() to shut it down yourself instead.
+func InstallExportPipeline(ctx context.Context, config Config, opts ...metric.Option) (*metric.MeterProvider, error) {
+	provider, exporter, err := newExportPipeline(config, opts)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetMeterProvider(provider)
+
+	context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), exporter.config.RemoteTimeout)
+		defer cancel()
+		if err := provider.Shutdown(shutdownCtx); err != nil {
+			exporter.logger().Printf("Logz.io metrics exporter: failed to shut down the meter provider: %v", err)
+		}
+	})
+	return provider, nil
+}
+
+// newExportPipeline returns a meter provider exporting to a new Exporter, and the exporter.
+func newExportPipeline(config Config, opts []metric.Option) (*metric.MeterProvider, *Exporter, error) {
+	exporter, err := New(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append([]metric.Option{metric.WithReader(exporter.NewPeriodicReader())}, opts...)
+	return metric.NewMeterProvider(opts...), exporter, nil
+}
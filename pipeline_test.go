@@ -0,0 +1,61 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestInstallExportPipeline(t *testing.T) {
+	requests := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests <- struct{}{}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	config := Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a", PushInterval: time.Hour}
+	provider, err := InstallExportPipeline(ctx, config, metric.WithResource(resource.Empty()))
+	require.NoError(t, err)
+	assert.Same(t, provider, otel.GetMeterProvider())
+
+	counter, err := otel.Meter("test").Int64Counter("requests")
+	require.NoError(t, err)
+	counter.Add(context.Background(), 1)
+
+	// Canceling the context shuts the meter provider down, which pushes the metrics.
+	cancel()
+	select {
+	case <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the metrics were not exported when the context was canceled")
+	}
+}
+
+func TestNewExportPipelineInvalidConfig(t *testing.T) {
+	_, err := NewExportPipeline(Config{})
+	assert.ErrorIs(t, err, ErrNoLogzioMetricsToken)
+}
@@ -0,0 +1,140 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// labelValueEscaper escapes label values as in the Prometheus exposition format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Preview converts the metrics as Export would and renders the series in a text format like the Prometheus
+// exposition format, one sample per line with its timestamp in milliseconds, sorted by series. Native histograms are
+// rendered with their count and sum, and exemplars follow their sample after a #. Nothing is sent to Logz.io, so
+// Preview is meant for debugging and documentation snapshots. Like ConvertToTimeSeries, it does not update the state
+// the exporter keeps across exports, so the metrics can be previewed and then exported.
+func (e *Exporter) Preview(rm *metricdata.ResourceMetrics) (string, error) {
+	timeseries, err := e.ConvertToTimeSeries(rm)
+	if err != nil {
+		return "", err
+	}
+	return previewTimeSeries(timeseries), nil
+}
+
+// previewTimeSeries renders the samples and histograms of the series as Preview does.
+func previewTimeSeries(timeseries []prompb.TimeSeries) string {
+	series := make([]string, len(timeseries))
+	for i, ts := range timeseries {
+		series[i] = previewSeries(ts.Labels)
+	}
+	order := make([]int, len(timeseries))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return strings.Compare(series[a], series[b])
+	})
+
+	var sb strings.Builder
+	for _, i := range order {
+		ts := timeseries[i]
+		for _, sample := range ts.Samples {
+			sb.WriteString(series[i])
+			sb.WriteByte(' ')
+			sb.WriteString(formatPreviewValue(sample.Value))
+			sb.WriteByte(' ')
+			sb.WriteString(strconv.FormatInt(sample.Timestamp, 10))
+			for _, exemplar := range ts.Exemplars {
+				sb.WriteString(" # ")
+				sb.WriteString(previewLabels(exemplar.Labels))
+				sb.WriteByte(' ')
+				sb.WriteString(formatPreviewValue(exemplar.Value))
+				sb.WriteByte(' ')
+				sb.WriteString(strconv.FormatInt(exemplar.Timestamp, 10))
+			}
+			sb.WriteByte('\n')
+		}
+		for _, histogram := range ts.Histograms {
+			sb.WriteString(series[i])
+			sb.WriteString(" {count:")
+			if count, ok := histogram.Count.(*prompb.Histogram_CountFloat); ok {
+				sb.WriteString(formatPreviewValue(count.CountFloat))
+			} else {
+				sb.WriteString(strconv.FormatUint(histogram.GetCountInt(), 10))
+			}
+			sb.WriteString(", sum:")
+			sb.WriteString(formatPreviewValue(histogram.Sum))
+			sb.WriteString("} ")
+			sb.WriteString(strconv.FormatInt(histogram.Timestamp, 10))
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// previewSeries renders the name and labels of a series. Names that are not valid classic names are quoted inside
+// the braces, as Prometheus renders UTF-8 names.
+func previewSeries(labels []prompb.Label) string {
+	name := seriesName(labels)
+	var others []prompb.Label
+	for _, label := range labels {
+		if label.Name != "__name__" {
+			others = append(others, label)
+		}
+	}
+	if name != "" && !IsValidMetricName(name) {
+		rendered := previewLabels(others)
+		if len(others) == 0 {
+			return "{" + strconv.Quote(name) + "}"
+		}
+		return "{" + strconv.Quote(name) + "," + rendered[1:]
+	}
+	return name + previewLabels(others)
+}
+
+// previewLabels renders labels in braces, or an empty string when there are no labels.
+func previewLabels(labels []prompb.Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, label := range labels {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		if IsValidLabelName(label.Name) {
+			sb.WriteString(label.Name)
+		} else {
+			sb.WriteString(strconv.Quote(label.Name))
+		}
+		sb.WriteString(`="`)
+		sb.WriteString(labelValueEscaper.Replace(label.Value))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// formatPreviewValue formats a sample value as in the Prometheus exposition format.
+func formatPreviewValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
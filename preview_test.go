@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestPreview(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	rm := &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{
+				{Name: "requests", Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{
+					{Attributes: attribute.NewSet(attribute.String("path", `/a"b`)), Time: now, Value: 2},
+					{Attributes: attribute.NewSet(attribute.String("path", "/"), attribute.String("method", "GET")), Time: now, Value: 1},
+				}}},
+				{Name: "http.latency", Data: metricdata.Gauge[float64]{DataPoints: []metricdata.DataPoint[float64]{
+					{Time: now, Value: 0.5},
+				}}},
+			},
+		}},
+	}
+	exporter := Exporter{config: Config{}}
+
+	preview, err := exporter.Preview(rm)
+	require.NoError(t, err)
+	ts := strconv.FormatInt(now.UnixMilli(), 10)
+	assert.Equal(t, `requests{method="GET",path="/"} 1 `+ts+"\n"+
+		`requests{path="/a\"b"} 2 `+ts+"\n"+
+		`{"http.latency"} 0.5 `+ts+"\n", preview)
+}
+
+func TestPreviewLabels(t *testing.T) {
+	assert.Equal(t, "", previewLabels(nil))
+	assert.Equal(t, `{"http.route"="/",le="+Inf"}`, previewLabels([]prompb.Label{{Name: "http.route", Value: "/"}, {Name: "le", Value: "+Inf"}}))
+	assert.Equal(t, `{"a.b",le="1"}`, previewSeries([]prompb.Label{{Name: "__name__", Value: "a.b"}, {Name: "le", Value: "1"}}))
+}
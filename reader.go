@@ -0,0 +1,92 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// NewPeriodicReader returns a metric.PeriodicReader that exports to the Exporter every PushInterval.
+// When PushJitter is set, a random duration up to PushJitter is added to the interval, so that instances
+// started at the same time do not push to the listener at the same time. Options passed in override the
+// interval.
+func (e *Exporter) NewPeriodicReader(opts ...metric.PeriodicReaderOption) *metric.PeriodicReader {
+	e.warnings.readerCreated.Store(true)
+	if e.config.JobMode {
+		e.logger().Printf("Logz.io metrics exporter: the periodic reader pushes in the background in JobMode, use Exporter.NewManualReader and Exporter.FinishJob")
+	}
+	opts = append([]metric.PeriodicReaderOption{metric.WithInterval(e.config.jitteredPushInterval())}, opts...)
+	return metric.NewPeriodicReader(e, opts...)
+}
+
+// NewManualReader returns a metric.ManualReader that CollectAndExport and FlushMetric collect from. Register it
+// with the meter provider, next to the periodic reader if any. It uses the Aggregation of the Exporter and cumulative temporality, so the
+// metrics it flushes do not add to the deltas the periodic reader exports. Options passed in override them.
+func (e *Exporter) NewManualReader(opts ...metric.ManualReaderOption) *metric.ManualReader {
+	opts = append([]metric.ManualReaderOption{metric.WithAggregationSelector(e.Aggregation)}, opts...)
+	reader := metric.NewManualReader(opts...)
+	e.flushReader.Store(reader)
+	return reader
+}
+
+// CollectAndExport collects all the metrics from the reader returned by NewManualReader and exports them, e.g.
+// for CLIs and batch jobs that push once at exit. It returns ErrNoManualReader when NewManualReader was not called.
+func (e *Exporter) CollectAndExport(ctx context.Context) error {
+	rm, err := e.collectManual(ctx)
+	if err != nil {
+		return err
+	}
+	return e.Export(ctx, rm)
+}
+
+// FlushMetric collects the metrics from the reader returned by NewManualReader and exports the ones with the
+// instrument name right away, e.g. to push business-critical counters at transaction time instead of waiting for
+// the push interval. It returns ErrNoManualReader when NewManualReader was not called.
+func (e *Exporter) FlushMetric(ctx context.Context, name string) error {
+	rm, err := e.collectManual(ctx)
+	if err != nil {
+		return err
+	}
+
+	flushed := metricdata.ResourceMetrics{Resource: rm.Resource}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				flushed.ScopeMetrics = append(flushed.ScopeMetrics, metricdata.ScopeMetrics{Scope: sm.Scope, Metrics: []metricdata.Metrics{m}})
+			}
+		}
+	}
+	// A metric without recorded values is not collected.
+	if len(flushed.ScopeMetrics) == 0 {
+		return nil
+	}
+	return e.Export(ctx, &flushed)
+}
+
+// collectManual collects the metrics from the reader returned by NewManualReader.
+func (e *Exporter) collectManual(ctx context.Context) (*metricdata.ResourceMetrics, error) {
+	reader := e.flushReader.Load()
+	if reader == nil {
+		return nil, ErrNoManualReader
+	}
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		return nil, err
+	}
+	return &rm, nil
+}
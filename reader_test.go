@@ -0,0 +1,154 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	m "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestJitteredPushInterval(t *testing.T) {
+	config := Config{PushInterval: 10 * time.Second}
+	assert.Equal(t, 10*time.Second, config.jitteredPushInterval())
+
+	config.PushJitter = 2 * time.Second
+	for i := 0; i < 100; i++ {
+		interval := config.jitteredPushInterval()
+		assert.GreaterOrEqual(t, interval, 10*time.Second)
+		assert.Less(t, interval, 12*time.Second)
+	}
+
+	config = Config{PushInterval: 200 * time.Millisecond, PushJitter: 50 * time.Millisecond}
+	for i := 0; i < 100; i++ {
+		interval := config.jitteredPushInterval()
+		assert.GreaterOrEqual(t, interval, 200*time.Millisecond)
+		assert.Less(t, interval, 250*time.Millisecond)
+	}
+}
+
+func TestNewPeriodicReader(t *testing.T) {
+	exporter, err := New(Config{LogzioMetricsToken: "123456789a", PushJitter: time.Second})
+	require.NoError(t, err)
+
+	reader := exporter.NewPeriodicReader()
+	require.NotNil(t, reader)
+	require.NoError(t, reader.Shutdown(context.Background()))
+}
+
+// TestHistogramBoundaries tests that the configured histogram boundaries are used by the reader, and that
+// bucket boundaries advised by an instrument take precedence over them.
+func TestHistogramBoundaries(t *testing.T) {
+	exporter, err := New(Config{LogzioMetricsToken: "123456789a", HistogramBoundaries: []float64{1, 10}})
+	require.NoError(t, err)
+	reader := exporter.NewPeriodicReader()
+	provider := metric.NewMeterProvider(metric.WithReader(reader))
+	meter := provider.Meter("test")
+
+	configured, err := meter.Float64Histogram("configured")
+	require.NoError(t, err)
+	configured.Record(context.Background(), 5)
+	advised, err := meter.Float64Histogram("advised", m.WithExplicitBucketBoundaries(2, 4, 8))
+	require.NoError(t, err)
+	advised.Record(context.Background(), 5)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	bounds := map[string][]float64{}
+	for _, metrics := range rm.ScopeMetrics[0].Metrics {
+		bounds[metrics.Name] = metrics.Data.(metricdata.Histogram[float64]).DataPoints[0].Bounds
+	}
+	assert.Equal(t, []float64{1, 10}, bounds["configured"])
+	assert.Equal(t, []float64{2, 4, 8}, bounds["advised"])
+}
+
+func TestFlushMetric(t *testing.T) {
+	var names []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		uncompressed, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		wr := &prompb.WriteRequest{}
+		require.NoError(t, wr.Unmarshal(uncompressed))
+		for _, ts := range wr.Timeseries {
+			names = append(names, seriesName(ts.Labels))
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, exporter.FlushMetric(context.Background(), "payments"), ErrNoManualReader)
+
+	reader := exporter.NewManualReader()
+	provider := metric.NewMeterProvider(metric.WithReader(reader))
+	meter := provider.Meter("test")
+	payments, err := meter.Int64Counter("payments")
+	require.NoError(t, err)
+	other, err := meter.Int64Counter("other")
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.FlushMetric(context.Background(), "payments"))
+	assert.Empty(t, names, "nothing is sent before the metric is recorded")
+
+	payments.Add(context.Background(), 1)
+	other.Add(context.Background(), 1)
+	require.NoError(t, exporter.FlushMetric(context.Background(), "payments"))
+	assert.Equal(t, []string{"payments"}, names)
+}
+
+func TestCollectAndExport(t *testing.T) {
+	var series int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		uncompressed, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		wr := &prompb.WriteRequest{}
+		require.NoError(t, wr.Unmarshal(uncompressed))
+		series += len(wr.Timeseries)
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, exporter.CollectAndExport(context.Background()), ErrNoManualReader)
+
+	provider := metric.NewMeterProvider(metric.WithReader(exporter.NewManualReader()))
+	meter := provider.Meter("test")
+	for _, name := range []string{"processed", "failed"} {
+		counter, err := meter.Int64Counter(name)
+		require.NoError(t, err)
+		counter.Add(context.Background(), 1)
+	}
+
+	require.NoError(t, exporter.CollectAndExport(context.Background()))
+	assert.Equal(t, 2, series)
+}
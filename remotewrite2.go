@@ -0,0 +1,190 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+)
+
+const (
+	remoteWrite2Version     = "2.0.0"
+	remoteWrite2ContentType = "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+)
+
+// RemoteWriteProtocol selects the version of the remote write protocol of the requests.
+type RemoteWriteProtocol int
+
+const (
+	// RemoteWrite1 sends Remote Write 1.0 requests.
+	RemoteWrite1 RemoteWriteProtocol = iota
+	// RemoteWrite2 sends Remote Write 2.0 requests, with a symbol table, native histograms and metric metadata.
+	// When the listener rejects them as unsupported, the exporter falls back to RemoteWrite1.
+	RemoteWrite2
+)
+
+// unsupportedProtocolError is a send error caused by a listener that does not accept the protocol of the request.
+type unsupportedProtocolError struct {
+	err error
+}
+
+func (e *unsupportedProtocolError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unsupportedProtocolError) Unwrap() error {
+	return e.err
+}
+
+// RemoteWrite2Rejected reports whether the listener rejected the Remote Write 2.0 requests, so that the exporter
+// fell back to Remote Write 1.0.
+func (e *Exporter) RemoteWrite2Rejected() bool {
+	return e.rw2Rejected.Load()
+}
+
+// fallBackToRemoteWrite1 sends the next requests with Remote Write 1.0, and logs it once.
+func (e *Exporter) fallBackToRemoteWrite1() {
+	if e.rw2Rejected.CompareAndSwap(false, true) {
+		e.logger().Printf("Logz.io metrics exporter: the listener does not accept Remote Write 2.0, falling back to Remote Write 1.0")
+	}
+}
+
+// protocol returns the remote write protocol of the next requests.
+func (e *Exporter) protocol() RemoteWriteProtocol {
+	if e.config.RemoteWriteProtocol == RemoteWrite2 && !e.rw2Rejected.Load() {
+		return RemoteWrite2
+	}
+	return RemoteWrite1
+}
+
+// toRemoteWrite2 converts an uncompressed Remote Write 1.0 message to an uncompressed Remote Write 2.0 message.
+func (e *Exporter) toRemoteWrite2(uncompressed []byte) ([]byte, error) {
+	var request prompb.WriteRequest
+	if err := request.Unmarshal(uncompressed); err != nil {
+		return nil, err
+	}
+
+	symbols := writev2.NewSymbolTable()
+	timeseries := make([]writev2.TimeSeries, len(request.Timeseries))
+	for i, ts := range request.Timeseries {
+		series := writev2.TimeSeries{
+			LabelsRefs: symbolizeLabels(&symbols, ts.Labels),
+			Samples:    make([]writev2.Sample, len(ts.Samples)),
+			Metadata:   e.seriesMetadata(ts, &symbols),
+		}
+		for j, sample := range ts.Samples {
+			series.Samples[j] = writev2.Sample{Value: sample.Value, Timestamp: sample.Timestamp}
+		}
+		for _, exemplar := range ts.Exemplars {
+			series.Exemplars = append(series.Exemplars, writev2.Exemplar{
+				LabelsRefs: symbolizeLabels(&symbols, exemplar.Labels),
+				Value:      exemplar.Value,
+				Timestamp:  exemplar.Timestamp,
+			})
+		}
+		for _, histogram := range ts.Histograms {
+			series.Histograms = append(series.Histograms, toRemoteWrite2Histogram(histogram))
+		}
+		timeseries[i] = series
+	}
+
+	written := &writev2.Request{Symbols: symbols.Symbols(), Timeseries: timeseries}
+	return written.Marshal()
+}
+
+// symbolizeLabels returns the references of the label names and values in the symbol table.
+func symbolizeLabels(symbols *writev2.SymbolsTable, labels []prompb.Label) []uint32 {
+	refs := make([]uint32, 0, 2*len(labels))
+	for _, label := range labels {
+		refs = append(refs, symbols.Symbolize(label.Name), symbols.Symbolize(label.Value))
+	}
+	return refs
+}
+
+// toRemoteWrite2Histogram converts a native histogram to Remote Write 2.0.
+func toRemoteWrite2Histogram(h prompb.Histogram) writev2.Histogram {
+	histogram := writev2.Histogram{
+		Sum:            h.Sum,
+		Schema:         h.Schema,
+		ZeroThreshold:  h.ZeroThreshold,
+		NegativeSpans:  toRemoteWrite2Spans(h.NegativeSpans),
+		NegativeDeltas: h.NegativeDeltas,
+		NegativeCounts: h.NegativeCounts,
+		PositiveSpans:  toRemoteWrite2Spans(h.PositiveSpans),
+		PositiveDeltas: h.PositiveDeltas,
+		PositiveCounts: h.PositiveCounts,
+		ResetHint:      writev2.Histogram_ResetHint(h.ResetHint),
+		Timestamp:      h.Timestamp,
+	}
+	switch count := h.Count.(type) {
+	case *prompb.Histogram_CountInt:
+		histogram.Count = &writev2.Histogram_CountInt{CountInt: count.CountInt}
+	case *prompb.Histogram_CountFloat:
+		histogram.Count = &writev2.Histogram_CountFloat{CountFloat: count.CountFloat}
+	}
+	switch zeroCount := h.ZeroCount.(type) {
+	case *prompb.Histogram_ZeroCountInt:
+		histogram.ZeroCount = &writev2.Histogram_ZeroCountInt{ZeroCountInt: zeroCount.ZeroCountInt}
+	case *prompb.Histogram_ZeroCountFloat:
+		histogram.ZeroCount = &writev2.Histogram_ZeroCountFloat{ZeroCountFloat: zeroCount.ZeroCountFloat}
+	}
+	return histogram
+}
+
+// toRemoteWrite2Spans converts the bucket spans of a native histogram to Remote Write 2.0.
+func toRemoteWrite2Spans(spans []prompb.BucketSpan) []writev2.BucketSpan {
+	if len(spans) == 0 {
+		return nil
+	}
+	converted := make([]writev2.BucketSpan, len(spans))
+	for i, span := range spans {
+		converted[i] = writev2.BucketSpan{Offset: span.Offset, Length: span.Length}
+	}
+	return converted
+}
+
+// seriesMetadata returns the metadata of a series, with its help and unit in the symbol table. The buckets, sum and
+// count of a histogram are histogram series, while the minimum, maximum and quantiles computed from its buckets are
+// gauges.
+func (e *Exporter) seriesMetadata(ts prompb.TimeSeries, symbols *writev2.SymbolsTable) writev2.Metadata {
+	name := seriesName(ts.Labels)
+	family, metadata, ok := e.lookupMetadata(name)
+	result := writev2.Metadata{Type: writev2.Metadata_METRIC_TYPE_UNSPECIFIED}
+	if ok {
+		result.HelpRef = symbols.Symbolize(metadata.help)
+		result.UnitRef = symbols.Symbolize(metadata.unit)
+		switch metadata.dataType {
+		case "counter":
+			result.Type = writev2.Metadata_METRIC_TYPE_COUNTER
+		case "gauge":
+			result.Type = writev2.Metadata_METRIC_TYPE_GAUGE
+		case "histogram":
+			result.Type = writev2.Metadata_METRIC_TYPE_HISTOGRAM
+		}
+	}
+
+	switch {
+	case len(ts.Histograms) > 0:
+		result.Type = writev2.Metadata_METRIC_TYPE_HISTOGRAM
+	case slices.ContainsFunc(ts.Labels, func(l prompb.Label) bool { return l.Name == quantileLabelName }):
+		result.Type = writev2.Metadata_METRIC_TYPE_GAUGE
+	case family != name && (strings.HasSuffix(name, histogramMinSuffix) || strings.HasSuffix(name, histogramMaxSuffix)):
+		result.Type = writev2.Metadata_METRIC_TYPE_GAUGE
+	}
+	return result
+}
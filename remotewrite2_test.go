@@ -0,0 +1,82 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteWrite2(t *testing.T) {
+	var received writev2.Request
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, remoteWrite2ContentType, req.Header.Get("Content-Type"))
+		assert.Equal(t, remoteWrite2Version, req.Header.Get("X-Prometheus-Remote-Write-Version"))
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		body, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		require.NoError(t, received.Unmarshal(body))
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a", RemoteWriteProtocol: RemoteWrite2})
+	require.NoError(t, err)
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+
+	require.Len(t, received.Timeseries, 1)
+	series := received.Timeseries[0]
+	assert.Equal(t, writev2.Metadata_METRIC_TYPE_COUNTER, series.Metadata.Type)
+	require.Len(t, series.Samples, 1)
+	assert.Equal(t, 5.0, series.Samples[0].Value)
+
+	labels := map[string]string{}
+	for i := 0; i+1 < len(series.LabelsRefs); i += 2 {
+		labels[received.Symbols[series.LabelsRefs[i]]] = received.Symbols[series.LabelsRefs[i+1]]
+	}
+	assert.Equal(t, "metric_sum", labels["__name__"])
+	assert.Empty(t, received.Symbols[0], "the first symbol is the empty string")
+	assert.False(t, exporter.RemoteWrite2Rejected())
+}
+
+func TestRemoteWrite2Fallback(t *testing.T) {
+	var contentTypes []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		contentTypes = append(contentTypes, req.Header.Get("Content-Type"))
+		if req.Header.Get("Content-Type") == remoteWrite2ContentType {
+			rw.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a", RemoteWriteProtocol: RemoteWrite2})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+	assert.True(t, exporter.RemoteWrite2Rejected())
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+	assert.Equal(t, []string{remoteWrite2ContentType, "application/x-protobuf", "application/x-protobuf"}, contentTypes)
+}
@@ -0,0 +1,111 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrRedirected occurs when the listener, or a proxy in front of it, responds with a redirect that is not followed.
+var ErrRedirected = fmt.Errorf("logz.io metrics listener redirected the request, update the listener URL")
+
+const (
+	// maxRedirects is the number of redirects followed before a request fails.
+	maxRedirects = 10
+	// maxErrorBodySize is the number of bytes of an error response body included in the error.
+	maxErrorBodySize = 512
+)
+
+// RedirectPolicy controls how redirect responses of the listener, e.g. from a proxy in front of it, are handled.
+type RedirectPolicy int
+
+const (
+	// RedirectFollow follows the 307 and 308 redirects, which resend the request as is, and fails with
+	// ErrRedirected on the other redirects, which would resend it as a GET request without the metrics.
+	RedirectFollow RedirectPolicy = iota
+	// RedirectFail fails with ErrRedirected on all redirects.
+	RedirectFail
+)
+
+// checkRedirect returns the http.Client CheckRedirect function of the policy. A redirect that is not followed
+// returns the redirect response, which is turned into ErrRedirected by responseError.
+func checkRedirect(policy RedirectPolicy) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if policy == RedirectFail || req.Method != via[0].Method {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+}
+
+// statusError is the error of a response without a 2xx status code.
+type statusError struct {
+	statusCode int
+	err        error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// responseError returns the error of a response without a 2xx status code, including the redirect location or
+// the beginning of the response body.
+func responseError(res *http.Response) error {
+	var err error
+	if res.StatusCode >= 300 && res.StatusCode < 400 {
+		err = fmt.Errorf("%w: %v to %s", ErrRedirected, res.Status, res.Header.Get("Location"))
+	} else if body := readErrorBody(res); body != "" {
+		err = fmt.Errorf("%v: %s", res.Status, body)
+	} else {
+		err = fmt.Errorf("%v", res.Status)
+	}
+	return &statusError{statusCode: res.StatusCode, err: err}
+}
+
+// readErrorBody returns the beginning of an error response body, decompressed when proxies respond with a gzip
+// body the transport did not decompress, or an empty string when the body cannot be read.
+func readErrorBody(res *http.Response) string {
+	// A compressed body holds more than maxErrorBodySize bytes of text.
+	raw, _ := io.ReadAll(io.LimitReader(res.Body, 8*maxErrorBodySize))
+	var body []byte
+	if res.Header.Get("Content-Encoding") == "gzip" || bytes.HasPrefix(raw, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return ""
+		}
+		// A body cut by the limit is an unexpected EOF, the text read until then is kept.
+		body, _ = io.ReadAll(io.LimitReader(gz, maxErrorBodySize+1))
+	} else {
+		body = raw
+	}
+
+	text := strings.Join(strings.Fields(string(body)), " ")
+	if len(body) > maxErrorBodySize {
+		text = text[:min(len(text), maxErrorBodySize)] + "..."
+	}
+	return text
+}
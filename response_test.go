@@ -0,0 +1,114 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gzipBody returns the gzip compressed text.
+func gzipBody(t *testing.T, text string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(text))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestReadErrorBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		want   string
+	}{
+		{name: "plain", body: []byte("invalid token\n"), want: "invalid token"},
+		{name: "gzip", header: http.Header{"Content-Encoding": {"gzip"}}, body: gzipBody(t, "<h1>Bad Gateway</h1>"), want: "<h1>Bad Gateway</h1>"},
+		{name: "gzip without header", body: gzipBody(t, "quota exceeded"), want: "quota exceeded"},
+		{name: "long", body: []byte(strings.Repeat("a", 1000)), want: strings.Repeat("a", maxErrorBodySize) + "..."},
+		{name: "empty", body: nil, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{Header: tt.header, Body: io.NopCloser(bytes.NewReader(tt.body))}
+			if res.Header == nil {
+				res.Header = http.Header{}
+			}
+			assert.Equal(t, tt.want, readErrorBody(res))
+		})
+	}
+}
+
+func TestSendRequestRedirects(t *testing.T) {
+	var posts int
+	listener := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			posts++
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer listener.Close()
+	proxy := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		status := http.StatusTemporaryRedirect
+		if req.URL.Path == "/moved" {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(rw, req, listener.URL, status)
+	}))
+	defer proxy.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		policy    RedirectPolicy
+		wantPosts int
+		wantError bool
+	}{
+		{name: "follow 307", path: "/", policy: RedirectFollow, wantPosts: 1},
+		{name: "follow 301", path: "/moved", policy: RedirectFollow, wantError: true},
+		{name: "fail", path: "/", policy: RedirectFail, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts = 0
+			exporter := Exporter{config: Config{LogzioMetricsListener: proxy.URL + tt.path, LogzioMetricsToken: "123456789a", RedirectPolicy: tt.policy}}
+			message, err := exporter.buildMessage([]prompb.TimeSeries{})
+			require.NoError(t, err)
+			req, err := exporter.buildRequest(context.Background(), message, RemoteWrite1)
+			require.NoError(t, err)
+
+			_, err = exporter.sendRequest(req)
+			if tt.wantError {
+				require.ErrorIs(t, err, ErrRedirected)
+				assert.Contains(t, err.Error(), listener.URL)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantPosts, posts)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRetryInitialBackoff is the RetryConfig InitialBackoff used when it is not set.
+	DefaultRetryInitialBackoff = 500 * time.Millisecond
+	// DefaultRetryMaxBackoff is the RetryConfig MaxBackoff used when it is not set.
+	DefaultRetryMaxBackoff = 10 * time.Second
+)
+
+// RetryConfig configures the retries of the requests that failed with a transient error: a network error, a
+// 429 Too Many Requests response or a 5xx response. Other responses, e.g. a 400 for invalid data, are not retried.
+type RetryConfig struct {
+	// MaxAttempts is the number of attempts to send a request, including the first one. 0 and 1 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, which doubles for every retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait before a retry, including the wait requested by a Retry-After response header.
+	MaxBackoff time.Duration
+	// Jitter is the upper bound of a random duration added to every wait, so exporters do not retry in lockstep.
+	Jitter time.Duration
+}
+
+// retryableError is a send error caused by a transient failure, which is retried.
+type retryableError struct {
+	err error
+	// retryAfter is the wait requested by the Retry-After header of the response, if any.
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// isRetryableStatus reports whether a response with the status code is retried.
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// parseRetryAfter returns the wait of a Retry-After header, given in seconds or as an HTTP date, or 0 when the
+// header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
+// retryBackoff returns the wait before retrying a request that failed attempt times: the exponential backoff, or
+// the wait requested by the listener when it is longer, capped to the MaxBackoff and with a random jitter added.
+func (c *Config) retryBackoff(attempt int, retryAfter time.Duration) time.Duration {
+	initial := c.Retry.InitialBackoff
+	if initial == 0 {
+		initial = DefaultRetryInitialBackoff
+	}
+	maxBackoff := c.Retry.MaxBackoff
+	if maxBackoff == 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+
+	backoff := maxBackoff
+	// Shifting by more than 30 overflows for common backoffs, which are capped anyway.
+	if shift := attempt - 1; shift < 30 {
+		backoff = min(initial<<shift, maxBackoff)
+	}
+	backoff = min(max(backoff, retryAfter), maxBackoff)
+	if c.Retry.Jitter > 0 {
+		backoff += rand.N(c.Retry.Jitter)
+	}
+	return backoff
+}
@@ -0,0 +1,192 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxAttempts  int
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "retries transient failures", statuses: []int{503, 429, 204}, maxAttempts: 3, wantRequests: 3},
+		{name: "gives up after max attempts", statuses: []int{500, 500, 500}, maxAttempts: 2, wantRequests: 2, wantErr: true},
+		{name: "does not retry invalid data", statuses: []int{400, 204}, maxAttempts: 3, wantRequests: 1, wantErr: true},
+		{name: "disabled", statuses: []int{503, 204}, wantRequests: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(tt.statuses[requests])
+				requests++
+			}))
+			defer server.Close()
+
+			exporter, err := New(Config{
+				LogzioMetricsListener: server.URL,
+				LogzioMetricsToken:    "123456789a",
+				Retry:                 RetryConfig{MaxAttempts: tt.maxAttempts, InitialBackoff: time.Millisecond},
+			})
+			require.NoError(t, err)
+
+			err = exporter.Export(context.Background(), getSumMetric(5))
+			assert.Equal(t, tt.wantErr, err != nil, "error %v", err)
+			assert.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var requests []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, time.Now())
+		if len(requests) == 1 {
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		Retry:                 RetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+	require.Len(t, requests, 2)
+	assert.GreaterOrEqual(t, requests[1].Sub(requests[0]), time.Second)
+}
+
+func TestExportContextDeadline(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		select {
+		case <-req.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		RemoteTimeout:         10 * time.Second,
+		Retry:                 RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = exporter.Export(ctx, getSumMetric(5))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, IsRetryable(err))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestExportContextCanceled(t *testing.T) {
+	t.Run("before sending", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			requests++
+			rw.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		exporter, err := New(Config{
+			LogzioMetricsListener: server.URL,
+			LogzioMetricsToken:    "123456789a",
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, exporter.Export(ctx, getSumMetric(5)), context.Canceled)
+		assert.Zero(t, requests)
+	})
+
+	t.Run("during retry backoff", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			requests++
+			cancel()
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		exporter, err := New(Config{
+			LogzioMetricsListener: server.URL,
+			LogzioMetricsToken:    "123456789a",
+			Retry:                 RetryConfig{MaxAttempts: 3, InitialBackoff: time.Minute},
+		})
+		require.NoError(t, err)
+
+		err = exporter.Export(ctx, getSumMetric(5))
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, requests)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5", now))
+	assert.Equal(t, time.Minute, parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter("-5", now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+	assert.Zero(t, parseRetryAfter("", now))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	config := Config{Retry: RetryConfig{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}}
+	assert.Equal(t, time.Second, config.retryBackoff(1, 0))
+	assert.Equal(t, 2*time.Second, config.retryBackoff(2, 0))
+	assert.Equal(t, 4*time.Second, config.retryBackoff(3, 0))
+	assert.Equal(t, 5*time.Second, config.retryBackoff(4, 0))
+	assert.Equal(t, 5*time.Second, config.retryBackoff(100, 0))
+	assert.Equal(t, 3*time.Second, config.retryBackoff(1, 3*time.Second), "the Retry-After wait is honored")
+	assert.Equal(t, 5*time.Second, config.retryBackoff(1, time.Hour), "the Retry-After wait is capped")
+
+	config.Retry.Jitter = time.Second
+	backoff := config.retryBackoff(1, 0)
+	assert.GreaterOrEqual(t, backoff, time.Second)
+	assert.Less(t, backoff, 2*time.Second)
+
+	assert.Equal(t, DefaultRetryInitialBackoff, (&Config{}).retryBackoff(1, 0))
+}
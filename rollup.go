@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// Rollup configures the additional series of a metric aggregated over some of its labels, e.g. the per-service
+// totals of a per-pod metric, so the common aggregations do not need recording rules.
+type Rollup struct {
+	// Metric is the exported name of the metric to roll up, e.g. "http_requests_total".
+	Metric string
+	// Name is the name of the rolled-up metric, e.g. "http_requests_by_service_total". The series of a histogram
+	// keep their suffixes, e.g. "_sum" and "_count".
+	Name string
+	// Without are the exported names of the labels the series are aggregated over, e.g. "k8s_pod_name".
+	Without []string
+}
+
+// rollupKeptLabelNames are the labels that cannot be rolled up, as the series cannot be interpreted without them.
+var rollupKeptLabelNames = []string{"__name__", "le", quantileLabelName}
+
+// rollupSeries returns the rolled-up series of the Rollups of a metric. The values of the series with the same
+// labels once the Without labels are removed are added up, except for the minimum and maximum of histograms,
+// which keep the lowest and highest values. The quantiles of histograms cannot be added up and are not rolled up.
+func (e *Exporter) rollupSeries(metricName string, timeseries []prompb.TimeSeries) []prompb.TimeSeries {
+	var rolledUp []prompb.TimeSeries
+	for _, rollup := range e.config.Rollups {
+		if rollup.Metric != metricName {
+			continue
+		}
+
+		index := map[string]int{}
+		for _, ts := range timeseries {
+			if len(ts.Samples) != 1 || slices.ContainsFunc(ts.Labels, func(l prompb.Label) bool { return l.Name == quantileLabelName }) {
+				continue
+			}
+			suffix := strings.TrimPrefix(seriesName(ts.Labels), metricName)
+			labels := make([]prompb.Label, 0, len(ts.Labels))
+			for _, label := range ts.Labels {
+				switch {
+				case label.Name == "__name__":
+					labels = append(labels, prompb.Label{Name: "__name__", Value: rollup.Name + suffix})
+				case !slices.Contains(rollup.Without, label.Name):
+					labels = append(labels, label)
+				}
+			}
+
+			sample := ts.Samples[0]
+			key := labelsKey(labels)
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(rolledUp)
+				rolledUp = append(rolledUp, prompb.TimeSeries{Labels: labels, Samples: []prompb.Sample{sample}})
+				continue
+			}
+			total := &rolledUp[i].Samples[0]
+			switch suffix {
+			case histogramMinSuffix:
+				total.Value = min(total.Value, sample.Value)
+			case histogramMaxSuffix:
+				total.Value = max(total.Value, sample.Value)
+			default:
+				total.Value += sample.Value
+			}
+			total.Timestamp = max(total.Timestamp, sample.Timestamp)
+		}
+	}
+	return rolledUp
+}
@@ -0,0 +1,71 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollupSeries(t *testing.T) {
+	series := func(name, pod, service, le string, value float64, timestamp int64) prompb.TimeSeries {
+		labels := []prompb.Label{{Name: "__name__", Value: name}}
+		if le != "" {
+			labels = append(labels, prompb.Label{Name: "le", Value: le})
+		}
+		labels = append(labels, prompb.Label{Name: "pod", Value: pod}, prompb.Label{Name: "service", Value: service})
+		return prompb.TimeSeries{Labels: labels, Samples: []prompb.Sample{{Value: value, Timestamp: timestamp}}}
+	}
+	rolledUp := func(name, service, le string, value float64, timestamp int64) prompb.TimeSeries {
+		labels := []prompb.Label{{Name: "__name__", Value: name}}
+		if le != "" {
+			labels = append(labels, prompb.Label{Name: "le", Value: le})
+		}
+		labels = append(labels, prompb.Label{Name: "service", Value: service})
+		return prompb.TimeSeries{Labels: labels, Samples: []prompb.Sample{{Value: value, Timestamp: timestamp}}}
+	}
+
+	exporter := Exporter{config: Config{Rollups: []Rollup{
+		{Metric: "latency", Name: "service_latency", Without: []string{"pod"}},
+		{Metric: "other", Name: "other_by_service", Without: []string{"pod"}},
+	}}}
+	got := exporter.rollupSeries("latency", []prompb.TimeSeries{
+		series("latency", "a", "api", "1", 2, 100),
+		series("latency", "b", "api", "1", 3, 200),
+		series("latency", "c", "worker", "1", 4, 100),
+		series("latency_sum", "a", "api", "", 10, 100),
+		series("latency_sum", "b", "api", "", 20, 100),
+		series("latency_min", "a", "api", "", 0.5, 100),
+		series("latency_min", "b", "api", "", 0.2, 100),
+		series("latency_max", "a", "api", "", 7, 100),
+		series("latency_max", "b", "api", "", 9, 100),
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "latency"}, {Name: "pod", Value: "a"}, {Name: "quantile", Value: "0.5"}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 100}},
+		},
+	})
+
+	assert.Equal(t, []prompb.TimeSeries{
+		rolledUp("service_latency", "api", "1", 5, 200),
+		rolledUp("service_latency", "worker", "1", 4, 100),
+		rolledUp("service_latency_sum", "api", "", 30, 100),
+		rolledUp("service_latency_min", "api", "", 0.2, 100),
+		rolledUp("service_latency_max", "api", "", 9, 100),
+	}, got)
+
+	assert.Empty(t, exporter.rollupSeries("requests_total", []prompb.TimeSeries{series("requests_total", "a", "api", "", 1, 100)}))
+}
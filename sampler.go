@@ -0,0 +1,59 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// SeriesSampler decides which series are exported, e.g. to shed load based on the quota responses of the
+// backend. It is called for every converted series before the series are batched into requests, with the
+// metric name and the sorted labels of the series, which must not be modified. It must be safe for
+// concurrent use.
+type SeriesSampler interface {
+	Keep(name string, labels []prompb.Label) bool
+}
+
+// SeriesSamplerFunc is an adapter to use a function as a SeriesSampler.
+type SeriesSamplerFunc func(name string, labels []prompb.Label) bool
+
+// Keep calls f(name, labels).
+func (f SeriesSamplerFunc) Keep(name string, labels []prompb.Label) bool {
+	return f(name, labels)
+}
+
+// sampleSeries drops the series the SeriesSampler does not keep.
+func (e *Exporter) sampleSeries(timeseries []prompb.TimeSeries) []prompb.TimeSeries {
+	if e.config.SeriesSampler == nil {
+		return timeseries
+	}
+	kept := timeseries[:0]
+	for _, ts := range timeseries {
+		if e.config.SeriesSampler.Keep(seriesName(ts.Labels), ts.Labels) {
+			kept = append(kept, ts)
+		}
+	}
+	return kept
+}
+
+// seriesName returns the value of the __name__ label.
+func seriesName(labels []prompb.Label) string {
+	for _, label := range labels {
+		if label.Name == "__name__" {
+			return label.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestSeriesSampler(t *testing.T) {
+	var names []string
+	exporter := Exporter{config: Config{
+		SeriesSampler: SeriesSamplerFunc(func(name string, labels []prompb.Label) bool {
+			names = append(names, name)
+			return !strings.HasSuffix(name, "_min") && !strings.HasSuffix(name, "_max")
+		}),
+	}}
+
+	got, err := exporter.ConvertToTimeSeries(getHistogramMetric(1, metricdata.NewExtrema[int64](2), metricdata.NewExtrema[int64](2), 2))
+	require.NoError(t, err)
+
+	assert.Len(t, got, 5)
+	assert.Len(t, names, 7)
+	for _, ts := range got {
+		name := seriesName(ts.Labels)
+		assert.NotContains(t, []string{"metric_histogram_min", "metric_histogram_max"}, name)
+	}
+}
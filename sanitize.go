@@ -17,8 +17,97 @@ package metrics_exporter
 import (
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
+// SanitizeLabelName returns the label name the exporter sends for the given attribute key, as a valid Prometheus
+// label name. The exporter keeps the letters and digits of other scripts, as OpenTelemetry does, while
+// SanitizeLabelName replaces them with underscores, so that its result always passes IsValidLabelName.
+func SanitizeLabelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if isLabelNameRune(r) {
+			return r
+		}
+		return '_'
+	}, sanitize(name))
+}
+
+// SanitizeMetricName replaces characters that are not allowed in a Prometheus metric name with underscores,
+// and prefixes names starting with a digit.
+func SanitizeMetricName(name string) string {
+	if len(name) == 0 {
+		return name
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if isMetricNameRune(r) {
+			return r
+		}
+		return '_'
+	}, name)
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// IsValidMetricName reports whether name matches the Prometheus metric name format [a-zA-Z_:][a-zA-Z0-9_:]*.
+func IsValidMetricName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i, r := range name {
+		if !isMetricNameRune(r) || (i == 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidLabelName reports whether name matches the Prometheus label name format [a-zA-Z_][a-zA-Z0-9_]*.
+func IsValidLabelName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i, r := range name {
+		if !isLabelNameRune(r) || (i == 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// labelName returns the label name the exporter sends for a label or attribute key. UTF-8 names are kept as they
+// are, except for invalid UTF-8 and the "__" prefix reserved for internal labels.
+func labelName(name string, utf8Names bool) string {
+	if !utf8Names {
+		return sanitize(name)
+	}
+	name = strings.ToValidUTF8(name, "_")
+	if strings.HasPrefix(name, "__") && name != "__name__" {
+		return "key" + name
+	}
+	return name
+}
+
+// isValidName reports whether name is a valid metric name, or a valid UTF-8 name when utf8Names is set.
+func isValidName(name string, utf8Names bool) bool {
+	if utf8Names {
+		return name != "" && utf8.ValidString(name)
+	}
+	return IsValidMetricName(name)
+}
+
+// isLabelNameRune reports whether r is allowed in a Prometheus label name
+func isLabelNameRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
+}
+
+// isMetricNameRune reports whether r is allowed in a Prometheus metric name
+func isMetricNameRune(r rune) bool {
+	return isLabelNameRune(r) || r == ':'
+}
+
 // This is a copy of opentelemetry-go/sdk/internal/sanitize.go
 
 // sanitize replaces non-alphanumeric characters with underscores
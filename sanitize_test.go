@@ -16,6 +16,7 @@ package metrics_exporter
 
 import (
 	"testing"
+	"testing/quick"
 )
 
 func TestSanitize(t *testing.T) {
@@ -44,6 +45,11 @@ func TestSanitize(t *testing.T) {
 			input: "/0123456789",
 			want:  "key_0123456789",
 		},
+		{
+			name:  "keep letters of other scripts",
+			input: "café",
+			want:  "café",
+		},
 		{
 			name:  "valid input",
 			input: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz_0123456789",
@@ -64,3 +70,74 @@ func TestSanitize(t *testing.T) {
 		})
 	}
 }
+
+func TestSanitizeMetricName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "http.server.duration", want: "http_server_duration"},
+		{input: "ns:requests_total", want: "ns:requests_total"},
+		{input: "1xx_responses", want: "_1xx_responses"},
+		{input: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := SanitizeMetricName(tt.input)
+			if got != tt.want {
+				t.Errorf("SanitizeMetricName() = %q; want %q", got, tt.want)
+			}
+			if got != "" && !IsValidMetricName(got) {
+				t.Errorf("IsValidMetricName(%q) = false; want true", got)
+			}
+		})
+	}
+}
+
+func TestIsValidName(t *testing.T) {
+	tests := []struct {
+		input       string
+		validMetric bool
+		validLabel  bool
+	}{
+		{input: "requests_total", validMetric: true, validLabel: true},
+		{input: "ns:requests", validMetric: true, validLabel: false},
+		{input: "_private", validMetric: true, validLabel: true},
+		{input: "0abc", validMetric: false, validLabel: false},
+		{input: "a-b", validMetric: false, validLabel: false},
+		{input: "", validMetric: false, validLabel: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := IsValidMetricName(tt.input); got != tt.validMetric {
+				t.Errorf("IsValidMetricName(%q) = %v; want %v", tt.input, got, tt.validMetric)
+			}
+			if got := IsValidLabelName(tt.input); got != tt.validLabel {
+				t.Errorf("IsValidLabelName(%q) = %v; want %v", tt.input, got, tt.validLabel)
+			}
+			if tt.input != "" && !IsValidLabelName(SanitizeLabelName(tt.input)) {
+				t.Errorf("SanitizeLabelName(%q) returned an invalid label name", tt.input)
+			}
+		})
+	}
+}
+
+// TestSanitizeLabelNameIsValid checks that every sanitized label name is a valid Prometheus label name.
+func TestSanitizeLabelNameIsValid(t *testing.T) {
+	property := func(name string) bool {
+		return name == "" || IsValidLabelName(SanitizeLabelName(name))
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Error(err)
+	}
+	for _, name := range []string{"_", "__", "__name__", "_private", "0", "é", "\xff", "a:b"} {
+		if !property(name) {
+			t.Errorf("SanitizeLabelName(%q) = %q, which IsValidLabelName rejects", name, SanitizeLabelName(name))
+		}
+	}
+	if got := SanitizeLabelName("café"); got != "caf_" {
+		t.Errorf("SanitizeLabelName(%q) = %q; want %q", "café", got, "caf_")
+	}
+}
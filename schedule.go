@@ -0,0 +1,63 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"time"
+)
+
+// SendWindow is a daily time window, in UTC, during which the exporter is allowed to send requests.
+// Start and End are offsets from midnight. A window with Start after End spans midnight.
+type SendWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// contains reports whether the time of day of t is within the window.
+func (w SendWindow) contains(t time.Time) bool {
+	t = t.UTC()
+	offset := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// allowSend reports whether an export may be sent at now, according to SendWindows and MinSendInterval,
+// and records now as the last send time when it may. Exports that are not allowed are skipped. Since the
+// exporter sends cumulative data, and the delta metrics of skipped exports are still added to the running totals,
+// the skipped values are included in the next allowed export.
+func (e *Exporter) allowSend(now time.Time) bool {
+	if len(e.config.SendWindows) > 0 {
+		inWindow := false
+		for _, window := range e.config.SendWindows {
+			if window.contains(now) {
+				inWindow = true
+				break
+			}
+		}
+		if !inWindow {
+			return false
+		}
+	}
+
+	e.scheduleMu.Lock()
+	defer e.scheduleMu.Unlock()
+	if e.config.MinSendInterval > 0 && !e.lastSend.IsZero() && now.Sub(e.lastSend) < e.config.MinSendInterval {
+		return false
+	}
+	e.lastSend = now
+	return true
+}
@@ -0,0 +1,117 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestSendWindowContains(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		window SendWindow
+		at     time.Duration
+		want   bool
+	}{
+		{name: "inside", window: SendWindow{Start: 2 * time.Hour, End: 4 * time.Hour}, at: 3 * time.Hour, want: true},
+		{name: "end is exclusive", window: SendWindow{Start: 2 * time.Hour, End: 4 * time.Hour}, at: 4 * time.Hour, want: false},
+		{name: "outside", window: SendWindow{Start: 2 * time.Hour, End: 4 * time.Hour}, at: time.Hour, want: false},
+		{name: "spans midnight before", window: SendWindow{Start: 22 * time.Hour, End: 2 * time.Hour}, at: 23 * time.Hour, want: true},
+		{name: "spans midnight after", window: SendWindow{Start: 22 * time.Hour, End: 2 * time.Hour}, at: time.Hour, want: true},
+		{name: "spans midnight outside", window: SendWindow{Start: 22 * time.Hour, End: 2 * time.Hour}, at: 12 * time.Hour, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.window.contains(day.Add(tt.at)))
+		})
+	}
+}
+
+func TestAllowSend(t *testing.T) {
+	now := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)
+	exporter := Exporter{config: Config{
+		MinSendInterval: time.Minute,
+		SendWindows:     []SendWindow{{Start: 2 * time.Hour, End: 4 * time.Hour}},
+	}}
+
+	assert.False(t, exporter.allowSend(now.Add(-2*time.Hour)), "outside of the send window")
+	assert.True(t, exporter.allowSend(now))
+	assert.False(t, exporter.allowSend(now.Add(30*time.Second)), "within the minimum send interval")
+	assert.True(t, exporter.allowSend(now.Add(time.Minute)))
+}
+
+func TestAllowSendSubSecond(t *testing.T) {
+	now := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)
+	exporter := Exporter{config: Config{MinSendInterval: 200 * time.Millisecond}}
+
+	assert.True(t, exporter.allowSend(now))
+	assert.False(t, exporter.allowSend(now.Add(100*time.Millisecond)), "within the minimum send interval")
+	assert.True(t, exporter.allowSend(now.Add(200*time.Millisecond)))
+}
+
+// TestExportSkippedDelta tests that the delta values of the exports skipped by MinSendInterval are included in
+// the next allowed export.
+func TestExportSkippedDelta(t *testing.T) {
+	var values []float64
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		body, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		var writeRequest prompb.WriteRequest
+		require.NoError(t, writeRequest.Unmarshal(body))
+		require.Len(t, writeRequest.Timeseries, 1)
+		values = append(values, writeRequest.Timeseries[0].Samples[0].Value)
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		MinSendInterval:       time.Hour,
+	})
+	require.NoError(t, err)
+
+	delta := func(value int64) *metricdata.ResourceMetrics {
+		rm := getSumMetric(value)
+		sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+		sum.Temporality = metricdata.DeltaTemporality
+		rm.ScopeMetrics[0].Metrics[0].Data = sum
+		return rm
+	}
+	require.NoError(t, exporter.Export(context.Background(), delta(5)))
+	require.NoError(t, exporter.Export(context.Background(), delta(3)), "skipped by the minimum send interval")
+	require.NoError(t, exporter.Export(context.Background(), delta(1)), "skipped by the minimum send interval")
+
+	exporter.scheduleMu.Lock()
+	exporter.lastSend = time.Time{}
+	exporter.scheduleMu.Unlock()
+	require.NoError(t, exporter.Export(context.Background(), delta(2)))
+	assert.Equal(t, []float64{5, 11}, values)
+}
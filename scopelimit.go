@@ -0,0 +1,61 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+)
+
+// OversizedScopePolicy controls how the metrics of a scope whose attributes exceed MaxScopeAttributeBytes are
+// exported.
+type OversizedScopePolicy int
+
+const (
+	// OversizedScopeDropAttributes exports the metrics of the scope without the scope attributes.
+	OversizedScopeDropAttributes OversizedScopePolicy = iota
+	// OversizedScopeDrop drops the metrics of the scope.
+	OversizedScopeDrop
+)
+
+// OversizedScopes returns the number of scopes, once per export, whose attributes exceeded
+// MaxScopeAttributeBytes.
+func (e *Exporter) OversizedScopes() uint64 {
+	return e.oversized.Load()
+}
+
+// oversizedScope reports whether the attributes of the scope exceed MaxScopeAttributeBytes, and logs a warning the
+// first time a scope of the name is oversized.
+func (e *Exporter) oversizedScope(scope instrumentation.Scope) bool {
+	if e.config.MaxScopeAttributeBytes == 0 || scopeAttributeBytes(scope.Attributes) <= e.config.MaxScopeAttributeBytes {
+		return false
+	}
+	e.oversized.Add(1)
+	if _, warned := e.scopeWarned.LoadOrStore(scope.Name, true); !warned {
+		e.logger().Printf("Logz.io metrics exporter: the attributes of scope %q exceed MaxScopeAttributeBytes", scope.Name)
+	}
+	return true
+}
+
+// scopeAttributeBytes returns the size of the labels of the attributes, i.e. of their keys and values.
+func scopeAttributeBytes(attributes attribute.Set) int {
+	size := 0
+	iter := attributes.Iter()
+	for iter.Next() {
+		attr := iter.Attribute()
+		size += len(attr.Key) + len(attr.Value.Emit())
+	}
+	return size
+}
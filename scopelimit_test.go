@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestOversizedScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     OversizedScopePolicy
+		wantSeries int
+	}{
+		{name: "drop attributes", policy: OversizedScopeDropAttributes, wantSeries: 1},
+		{name: "drop scope", policy: OversizedScopeDrop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			exporter, err := New(Config{
+				LogzioMetricsToken:     "123456789a",
+				MaxScopeAttributeBytes: 64,
+				OversizedScopePolicy:   tt.policy,
+				Logger:                 log.New(&logs, "", 0),
+			})
+			require.NoError(t, err)
+
+			rm := getSumMetric(5)
+			rm.ScopeMetrics[0].Scope.Attributes = attribute.NewSet(attribute.String("config", strings.Repeat("x", 100)))
+			for i := 0; i < 2; i++ {
+				got, err := exporter.ConvertToTimeSeries(rm)
+				require.NoError(t, err)
+				require.Len(t, got, tt.wantSeries)
+				for _, ts := range got {
+					for _, label := range ts.Labels {
+						assert.NotEqual(t, "config", label.Name)
+					}
+				}
+			}
+			assert.Equal(t, uint64(2), exporter.OversizedScopes())
+			assert.Equal(t, 1, strings.Count(logs.String(), "exceed MaxScopeAttributeBytes"), "the warning is logged once")
+		})
+	}
+}
+
+func TestScopeAttributesWithinLimit(t *testing.T) {
+	exporter, err := New(Config{LogzioMetricsToken: "123456789a", MaxScopeAttributeBytes: 64})
+	require.NoError(t, err)
+
+	rm := getSumMetric(5)
+	rm.ScopeMetrics[0].Scope.Attributes = attribute.NewSet(attribute.String("config", "small"))
+	got, err := exporter.ConvertToTimeSeries(rm)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Contains(t, got[0].Labels, prompb.Label{Name: "config", Value: "small"})
+	assert.Zero(t, exporter.OversizedScopes())
+}
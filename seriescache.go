@@ -0,0 +1,84 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"container/list"
+)
+
+// seriesCache is a map of per-series state that evicts the least recently used series once it holds more
+// than max series. A max of 0 keeps all series. It is not safe for concurrent use.
+type seriesCache[K comparable, V any] struct {
+	max   int
+	items map[K]*list.Element
+	order list.List
+}
+
+// seriesCacheEntry is a series kept in a seriesCache.
+type seriesCacheEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// get returns the value of a series and marks it as the most recently used.
+func (c *seriesCache[K, V]) get(key K) (V, bool) {
+	element, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*seriesCacheEntry[K, V]).value, true
+}
+
+// put sets the value of a series, marks it as the most recently used, and evicts the least recently used
+// series when the cache is full.
+func (c *seriesCache[K, V]) put(key K, value V) {
+	if element, ok := c.items[key]; ok {
+		element.Value.(*seriesCacheEntry[K, V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+
+	if c.items == nil {
+		c.items = map[K]*list.Element{}
+	}
+	c.items[key] = c.order.PushFront(&seriesCacheEntry[K, V]{key: key, value: value})
+	if c.max > 0 && len(c.items) > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*seriesCacheEntry[K, V]).key)
+	}
+}
+
+// values returns the values of all the series, from the most to the least recently used.
+func (c *seriesCache[K, V]) values() []V {
+	values := make([]V, 0, len(c.items))
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		values = append(values, element.Value.(*seriesCacheEntry[K, V]).value)
+	}
+	return values
+}
+
+// len returns the number of series in the cache.
+func (c *seriesCache[K, V]) len() int {
+	return len(c.items)
+}
+
+// clear removes all the series from the cache.
+func (c *seriesCache[K, V]) clear() {
+	c.items = nil
+	c.order.Init()
+}
@@ -0,0 +1,55 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesCache(t *testing.T) {
+	cache := seriesCache[string, int]{max: 2}
+	cache.put("a", 1)
+	cache.put("b", 2)
+
+	value, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	// b is the least recently used series.
+	cache.put("c", 3)
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+
+	cache.put("a", 4)
+	value, _ = cache.get("a")
+	assert.Equal(t, 4, value)
+	assert.Equal(t, []int{4, 3}, cache.values())
+
+	cache.clear()
+	assert.Equal(t, 0, cache.len())
+	_, ok = cache.get("a")
+	assert.False(t, ok)
+}
+
+func TestSeriesCacheUnbounded(t *testing.T) {
+	var cache seriesCache[int, int]
+	for i := 0; i < 100; i++ {
+		cache.put(i, i)
+	}
+	assert.Equal(t, 100, cache.len())
+}
@@ -0,0 +1,54 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+)
+
+// SharedTransport is shared by the exporters of a process, e.g. exporters of different accounts, so they reuse
+// the same connections and send at most a common number of concurrent requests.
+type SharedTransport struct {
+	client *http.Client
+	sends  chan struct{}
+}
+
+// NewSharedTransport returns a SharedTransport sending the requests of its exporters with client, or with a
+// client using http.DefaultTransport and the RedirectFollow policy when client is nil. A maxConcurrentSends of 0
+// does not limit the concurrent requests. The RemoteTimeout of every exporter applies to its own requests.
+func NewSharedTransport(client *http.Client, maxConcurrentSends int) *SharedTransport {
+	if client == nil {
+		client = &http.Client{Transport: http.DefaultTransport, CheckRedirect: checkRedirect(RedirectFollow)}
+	}
+	shared := &SharedTransport{client: client}
+	if maxConcurrentSends > 0 {
+		shared.sends = make(chan struct{}, maxConcurrentSends)
+	}
+	return shared
+}
+
+// do sends a request once fewer than the maximum concurrent requests are in flight, or fails when ctx is done.
+func (s *SharedTransport) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if s.sends != nil {
+		select {
+		case s.sends <- struct{}{}:
+			defer func() { <-s.sends }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return s.client.Do(req.WithContext(ctx))
+}
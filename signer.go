@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestSigner signs the requests to Logz.io, e.g. for egress proxies that authenticate the payload integrity.
+// It is called after the headers are set and before every send attempt.
+type RequestSigner interface {
+	SignRequest(req *http.Request, body []byte) error
+}
+
+// RequestSignerFunc is an adapter to use a function as a RequestSigner.
+type RequestSignerFunc func(req *http.Request, body []byte) error
+
+// SignRequest calls f(req, body).
+func (f RequestSignerFunc) SignRequest(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+// HMACRequestSigner sets a header to the hex-encoded HMAC-SHA256 of the request body with a shared secret.
+type HMACRequestSigner struct {
+	Header string
+	Secret []byte
+}
+
+// SignRequest sets the signature header of the request.
+func (s HMACRequestSigner) SignRequest(req *http.Request, body []byte) error {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write(body)
+	req.Header.Set(s.Header, hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
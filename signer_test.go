@@ -0,0 +1,57 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMACRequestSigner(t *testing.T) {
+	exporter := Exporter{config: Config{
+		LogzioMetricsListener: "https://listener.logz.io:8053",
+		RequestSigner:         HMACRequestSigner{Header: "X-Signature", Secret: []byte("secret")},
+	}}
+	message := []byte("message")
+
+	req, err := exporter.buildRequest(context.Background(), message, RemoteWrite1)
+	require.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(message)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Signature"))
+	assert.Equal(t, "snappy", req.Header.Get("Content-Encoding"))
+}
+
+func TestRequestSignerError(t *testing.T) {
+	signErr := errors.New("no key")
+	exporter := Exporter{config: Config{
+		LogzioMetricsListener: "https://listener.logz.io:8053",
+		RequestSigner: RequestSignerFunc(func(*http.Request, []byte) error {
+			return signErr
+		}),
+	}}
+
+	_, err := exporter.buildRequest(context.Background(), []byte("message"), RemoteWrite1)
+	assert.ErrorIs(t, err, signErr)
+}
@@ -0,0 +1,319 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// spoolFileSuffix is the suffix of the spooled message files. A file holds a header followed by the
+	// Snappy-compressed WriteRequest.
+	spoolFileSuffix = ".spool"
+	// legacySpoolFileSuffix is the suffix of the files of the first spool format, which hold the Snappy-compressed
+	// WriteRequest without a header. They are still sent, so that upgrades do not discard the spooled messages.
+	legacySpoolFileSuffix = ".snappy"
+
+	// spoolFormatVersion is the version of the spool file format written by the exporter.
+	spoolFormatVersion = 1
+	// spoolHeaderSize is the size of the header of a spool file: the spoolMagic, the format version and the
+	// big-endian CRC-32C of the message.
+	spoolHeaderSize = len(spoolMagic) + 1 + 4
+)
+
+// spoolMagic starts the header of the spool files.
+const spoolMagic = "LZSP"
+
+// spoolCRCTable is the Castagnoli table of the checksums of the spooled messages.
+var spoolCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrSpoolMessageTooLarge occurs when a message that could not be sent is larger than the MaxBytes of the spool.
+var ErrSpoolMessageTooLarge = fmt.Errorf("message is larger than the spool max bytes")
+
+// SpoolConfig configures the persistent queue of the messages that could not be sent because the listener was
+// unreachable. The spooled messages are sent, oldest first, before the next messages.
+type SpoolConfig struct {
+	// Dir is the directory of the spooled messages. The spool is disabled when Dir is empty.
+	Dir string
+	// MaxBytes is the maximum size of the spooled messages. The oldest messages are removed to make room for new
+	// ones. 0 does not limit the size.
+	MaxBytes int64
+	// Retention is the maximum age of the spooled messages. Older messages are removed instead of being sent.
+	// 0 keeps messages until they are sent.
+	Retention time.Duration
+}
+
+// messageSpool stores the spooled messages in the spool directory, one file per message named after the time the
+// message was built, so that the files sort in the order the messages were built.
+type messageSpool struct {
+	mu sync.Mutex
+}
+
+// spooledMessage is a message file in the spool directory.
+type spooledMessage struct {
+	path   string
+	built  time.Time
+	size   int64
+	legacy bool
+}
+
+// spoolMessage writes a message that could not be sent to the spool, removing the oldest messages when the spool
+// would exceed MaxBytes. A message larger than MaxBytes is not spooled. The message is written to a temporary file
+// that is renamed once complete, so that a crash does not leave a truncated message in the spool.
+func (e *Exporter) spoolMessage(message []byte, built time.Time) error {
+	e.spool.mu.Lock()
+	defer e.spool.mu.Unlock()
+
+	data := encodeSpoolFile(message)
+	maxBytes := e.config.Spool.MaxBytes
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		e.telemetry.recordSpoolRemovals(0, 0, 1)
+		return fmt.Errorf("%w: %d > %d bytes", ErrSpoolMessageTooLarge, len(data), maxBytes)
+	}
+	dir := e.config.Spool.Dir
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if maxBytes > 0 {
+		if err := e.evictSpooledMessages(maxBytes - int64(len(data))); err != nil {
+			return err
+		}
+	}
+
+	name := fmt.Sprintf("%020d-%010d%s", built.UnixNano(), deadLetterSeq.Add(1), spoolFileSuffix)
+	return writeFileAtomic(filepath.Join(dir, name), data)
+}
+
+// encodeSpoolFile returns the content of the spool file of a message.
+func encodeSpoolFile(message []byte) []byte {
+	data := make([]byte, 0, spoolHeaderSize+len(message))
+	data = append(data, spoolMagic...)
+	data = append(data, spoolFormatVersion)
+	data = binary.BigEndian.AppendUint32(data, crc32.Checksum(message, spoolCRCTable))
+	return append(data, message...)
+}
+
+// decodeSpoolFile returns the message of a spool file, and an error when the header or the checksum of the file
+// is invalid. legacy is set for the files of the first spool format, which have no header.
+func decodeSpoolFile(data []byte, legacy bool) ([]byte, error) {
+	if legacy {
+		return data, nil
+	}
+	if len(data) < spoolHeaderSize || string(data[:len(spoolMagic)]) != spoolMagic {
+		return nil, fmt.Errorf("invalid spool file header")
+	}
+	if version := data[len(spoolMagic)]; version != spoolFormatVersion {
+		return nil, fmt.Errorf("unsupported spool file version %d", version)
+	}
+	message := data[spoolHeaderSize:]
+	if crc32.Checksum(message, spoolCRCTable) != binary.BigEndian.Uint32(data[len(spoolMagic)+1:]) {
+		return nil, fmt.Errorf("spool file checksum mismatch")
+	}
+	return message, nil
+}
+
+// evictSpooledMessages removes the oldest spooled messages until the spool holds at most size bytes.
+func (e *Exporter) evictSpooledMessages(size int64) error {
+	messages, err := e.spooledMessages()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, m := range messages {
+		total += m.size
+	}
+	evicted := 0
+	defer func() { e.telemetry.recordSpoolRemovals(evicted, 0, 0) }()
+	for _, m := range messages {
+		if total <= size {
+			break
+		}
+		if err := os.Remove(m.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		evicted++
+		total -= m.size
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path, and renames it to path once synced.
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// drainSpool sends the spooled messages, oldest first, and removes them once sent. It stops at the first message
+// that fails to be sent because the listener is unreachable or ctx is done, and returns the error. Messages the
+// listener rejects are passed to the DeadLetterSink instead, and messages older than the Retention, or corrupt,
+// are removed.
+func (e *Exporter) drainSpool(ctx context.Context, now time.Time) error {
+	e.spool.mu.Lock()
+	defer e.spool.mu.Unlock()
+
+	messages, err := e.spooledMessages()
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if e.config.Spool.Retention > 0 && now.Sub(m.built) > e.config.Spool.Retention {
+			if err := os.Remove(m.path); err != nil {
+				return err
+			}
+			e.telemetry.recordSpoolRemovals(0, 1, 0)
+			continue
+		}
+
+		data, err := os.ReadFile(m.path)
+		if err != nil {
+			return err
+		}
+		message, err := decodeSpoolFile(data, m.legacy)
+		if err != nil {
+			// A corrupt message cannot be sent, so it is removed instead of blocking the spool.
+			e.logger().Printf("Logz.io metrics exporter: dropped the corrupt spooled message %s: %v", m.path, err)
+			if err := os.Remove(m.path); err != nil {
+				return err
+			}
+			e.telemetry.recordSpoolRemovals(0, 0, 1)
+			continue
+		}
+		if err := e.sendMessage(ctx, message, 0); err != nil {
+			if isUnreachable(err) {
+				return err
+			}
+			err = e.writeDeadLetter(DeadLetter{Message: message, Err: err, Time: m.built})
+			e.logger().Printf("Logz.io metrics exporter: dropped a spooled message: %v", err)
+		}
+		if err := os.Remove(m.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isUnreachable reports whether a send error is a transient failure, or the send was interrupted because the
+// context of the export is done. The message is spooled in both cases.
+func isUnreachable(err error) bool {
+	var retryable *retryableError
+	return errors.As(err, &retryable) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// SpoolFile describes a message file of a spool directory.
+type SpoolFile struct {
+	Path  string
+	Built time.Time
+	Size  int64
+	// Version is the format version of the file. 0 is the first format, without a header.
+	Version int
+	// Err is set when the file is corrupt. Corrupt files are removed when the spool is drained.
+	Err error
+}
+
+// InspectSpool returns the message files of a spool directory, oldest first, and checks their header and checksum,
+// so that operators can find the corrupt files of a spool without running an exporter.
+func InspectSpool(dir string) ([]SpoolFile, error) {
+	messages, err := listSpool(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]SpoolFile, 0, len(messages))
+	for _, m := range messages {
+		file := SpoolFile{Path: m.path, Built: m.built, Size: m.size}
+		data, err := os.ReadFile(m.path)
+		if err != nil {
+			return nil, err
+		}
+		if !m.legacy && len(data) > len(spoolMagic) {
+			file.Version = int(data[len(spoolMagic)])
+		}
+		_, file.Err = decodeSpoolFile(data, m.legacy)
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// spooledMessages returns the messages in the spool directory, oldest first.
+func (e *Exporter) spooledMessages() ([]spooledMessage, error) {
+	return listSpool(e.config.Spool.Dir)
+}
+
+// listSpool returns the messages in a spool directory, oldest first.
+func listSpool(dir string) ([]spooledMessage, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []spooledMessage
+	for _, entry := range entries {
+		var nanos int64
+		legacy := strings.HasSuffix(entry.Name(), legacySpoolFileSuffix)
+		if entry.IsDir() || (!legacy && !strings.HasSuffix(entry.Name(), spoolFileSuffix)) {
+			continue
+		}
+		if _, err := fmt.Sscanf(entry.Name(), "%d-", &nanos); err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			// The message was sent or removed since the directory was read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, spooledMessage{
+			path:   filepath.Join(dir, entry.Name()),
+			built:  time.Unix(0, nanos),
+			size:   info.Size(),
+			legacy: legacy,
+		})
+	}
+	// The names start with the zero-padded build time, so they sort by age.
+	slices.SortFunc(messages, func(a, b spooledMessage) int {
+		return strings.Compare(a.path, b.path)
+	})
+	return messages, nil
+}
@@ -0,0 +1,233 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpool(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	var received [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if status == http.StatusOK {
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			received = append(received, body)
+		}
+		rw.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "spool")
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a", Spool: SpoolConfig{Dir: dir}})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)), "the message is spooled")
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(6)), "the message is spooled")
+	spooled, err := exporter.spooledMessages()
+	require.NoError(t, err)
+	require.Len(t, spooled, 2)
+	data, err := os.ReadFile(spooled[0].path)
+	require.NoError(t, err)
+	first, err := decodeSpoolFile(data, false)
+	require.NoError(t, err)
+
+	status = http.StatusOK
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(7)))
+	require.Len(t, received, 3)
+	assert.Equal(t, first, received[0], "the spooled messages are sent first")
+	spooled, err = exporter.spooledMessages()
+	require.NoError(t, err)
+	assert.Empty(t, spooled)
+}
+
+func TestSpoolMaxBytes(t *testing.T) {
+	exporter := Exporter{config: Config{Spool: SpoolConfig{Dir: t.TempDir(), MaxBytes: int64(2*spoolHeaderSize + 10)}}}
+	now := time.Now()
+	require.NoError(t, exporter.spoolMessage([]byte("first"), now))
+	require.NoError(t, exporter.spoolMessage([]byte("second"), now.Add(time.Second)))
+
+	spooled, err := exporter.spooledMessages()
+	require.NoError(t, err)
+	require.Len(t, spooled, 1, "the oldest message is removed")
+	assert.Equal(t, uint64(1), exporter.Telemetry().EvictedSpoolMessages)
+	data, err := os.ReadFile(spooled[0].path)
+	require.NoError(t, err)
+	content, err := decodeSpoolFile(data, false)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+}
+
+func TestSpoolMessageTooLarge(t *testing.T) {
+	dir := t.TempDir()
+	exporter := Exporter{config: Config{Spool: SpoolConfig{Dir: dir, MaxBytes: int64(spoolHeaderSize + 10)}}}
+	now := time.Now()
+	require.NoError(t, exporter.spoolMessage([]byte("first"), now))
+	require.ErrorIs(t, exporter.spoolMessage([]byte("larger than max"), now.Add(time.Second)), ErrSpoolMessageTooLarge)
+
+	spooled, err := exporter.spooledMessages()
+	require.NoError(t, err)
+	require.Len(t, spooled, 1, "the spooled messages are kept")
+	assert.Zero(t, exporter.Telemetry().EvictedSpoolMessages)
+	assert.Equal(t, uint64(1), exporter.Telemetry().DroppedSpoolMessages)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left")
+}
+
+func TestSpoolFileFormat(t *testing.T) {
+	data := encodeSpoolFile([]byte("message"))
+	assert.Equal(t, spoolMagic, string(data[:len(spoolMagic)]))
+	message, err := decodeSpoolFile(data, false)
+	require.NoError(t, err)
+	assert.Equal(t, "message", string(message))
+
+	legacy, err := decodeSpoolFile([]byte("message"), true)
+	require.NoError(t, err)
+	assert.Equal(t, "message", string(legacy), "the files of the first format are the message")
+
+	corrupt := slices.Clone(data)
+	corrupt[len(corrupt)-1] ^= 1
+	_, err = decodeSpoolFile(corrupt, false)
+	assert.ErrorContains(t, err, "checksum mismatch")
+	_, err = decodeSpoolFile(data[:spoolHeaderSize-1], false)
+	assert.ErrorContains(t, err, "invalid spool file header")
+	future := slices.Clone(data)
+	future[len(spoolMagic)] = spoolFormatVersion + 1
+	_, err = decodeSpoolFile(future, false)
+	assert.ErrorContains(t, err, "unsupported spool file version")
+}
+
+func TestDrainSpoolCorruptAndLegacyFiles(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		received = append(received, string(body))
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	exporter := Exporter{config: Config{LogzioMetricsListener: server.URL, Spool: SpoolConfig{Dir: dir}}}
+	now := time.Now()
+	legacyName := fmt.Sprintf("%020d-%010d%s", now.Add(-time.Minute).UnixNano(), 1, legacySpoolFileSuffix)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, legacyName), []byte("legacy"), 0o600))
+	require.NoError(t, exporter.spoolMessage([]byte("corrupt"), now.Add(-time.Second)))
+	require.NoError(t, exporter.spoolMessage([]byte("valid"), now))
+	spooled, err := exporter.spooledMessages()
+	require.NoError(t, err)
+	require.Len(t, spooled, 3)
+	data, err := os.ReadFile(spooled[1].path)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 1
+	require.NoError(t, os.WriteFile(spooled[1].path, data, 0o600))
+
+	files, err := InspectSpool(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Equal(t, 0, files[0].Version, "the legacy file has the first format")
+	assert.Equal(t, spoolFormatVersion, files[1].Version)
+	assert.NoError(t, files[0].Err)
+	assert.ErrorContains(t, files[1].Err, "checksum mismatch")
+	assert.NoError(t, files[2].Err)
+
+	require.NoError(t, exporter.drainSpool(context.Background(), now))
+	assert.Equal(t, []string{"legacy", "valid"}, received, "the corrupt message is not sent")
+	spooled, err = exporter.spooledMessages()
+	require.NoError(t, err)
+	assert.Empty(t, spooled, "the corrupt message is removed")
+	assert.Equal(t, uint64(1), exporter.Telemetry().DroppedSpoolMessages)
+}
+
+func TestDrainSpool(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		rw.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var letters []DeadLetter
+	exporter := Exporter{config: Config{
+		LogzioMetricsListener: server.URL,
+		Spool:                 SpoolConfig{Dir: t.TempDir(), Retention: time.Hour},
+		DeadLetterSink: DeadLetterSinkFunc(func(_ context.Context, letter DeadLetter) error {
+			letters = append(letters, letter)
+			return nil
+		}),
+	}}
+	now := time.Now()
+	require.NoError(t, exporter.spoolMessage([]byte("expired"), now.Add(-2*time.Hour)))
+	require.NoError(t, exporter.spoolMessage([]byte("rejected"), now.Add(-time.Minute)))
+
+	require.NoError(t, exporter.drainSpool(context.Background(), now))
+	assert.Equal(t, 1, requests, "the expired message is not sent")
+	require.Len(t, letters, 1, "the rejected message is passed to the DeadLetterSink")
+	assert.Equal(t, "rejected", string(letters[0].Message))
+	assert.Equal(t, uint64(1), exporter.Telemetry().ExpiredSpoolMessages)
+	spooled, err := exporter.spooledMessages()
+	require.NoError(t, err)
+	assert.Empty(t, spooled)
+}
+
+func TestDrainSpoolContextDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var letters atomic.Int32
+	exporter, err := New(Config{
+		LogzioMetricsListener: server.URL,
+		LogzioMetricsToken:    "123456789a",
+		Spool:                 SpoolConfig{Dir: t.TempDir()},
+		DeadLetterSink: DeadLetterSinkFunc(func(context.Context, DeadLetter) error {
+			letters.Add(1)
+			return nil
+		}),
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, exporter.spoolMessage([]byte("first"), now.Add(-time.Minute)))
+	require.NoError(t, exporter.spoolMessage([]byte("second"), now.Add(-time.Second)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.NoError(t, exporter.Export(ctx, getSumMetric(5)), "the message is spooled")
+
+	spooled, err := exporter.spooledMessages()
+	require.NoError(t, err)
+	assert.Len(t, spooled, 3, "the spooled messages are kept and the new message is spooled")
+	assert.Zero(t, letters.Load())
+}
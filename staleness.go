@@ -0,0 +1,93 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// ephemeralTracker keeps the labels of the series exported for ephemeral jobs, which are marked stale on shutdown.
+type ephemeralTracker struct {
+	mu     sync.Mutex
+	series seriesCache[string, []prompb.Label]
+}
+
+// setMaxSeries bounds the number of series whose labels are kept.
+func (t *ephemeralTracker) setMaxSeries(maxSeries int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.series.max = maxSeries
+}
+
+// isEphemeral reports whether the series of the resource belong to a short-lived job: the exporter is configured
+// as EphemeralJob, or the EphemeralAttribute of the resource is true.
+func (e *Exporter) isEphemeral(res *resource.Resource) bool {
+	if e.config.EphemeralJob {
+		return true
+	}
+	if e.config.EphemeralAttribute == "" {
+		return false
+	}
+	v, ok := res.Set().Value(attribute.Key(e.config.EphemeralAttribute))
+	return ok && (v.AsBool() || v.Emit() == "true")
+}
+
+// trackEphemeral keeps the labels of the series when the resource belongs to a short-lived job.
+func (e *Exporter) trackEphemeral(res *resource.Resource, timeseries []prompb.TimeSeries) {
+	if !e.isEphemeral(res) {
+		return
+	}
+
+	e.ephemeral.mu.Lock()
+	defer e.ephemeral.mu.Unlock()
+	for _, ts := range timeseries {
+		key := labelsKey(ts.Labels)
+		if _, ok := e.ephemeral.series.get(key); !ok {
+			// The labels of the series may be reused by the next export.
+			e.ephemeral.series.put(key, slices.Clone(ts.Labels))
+		}
+	}
+}
+
+// sendStalenessMarkers sends a staleness marker for every tracked series of short-lived jobs, so they stop
+// showing as alive as soon as the job ends instead of after the lookback period of the queries. The series evicted
+// by MaxTrackedSeries or cleared by ResetState are not marked.
+func (e *Exporter) sendStalenessMarkers(ctx context.Context, now time.Time) error {
+	e.ephemeral.mu.Lock()
+	series := e.ephemeral.series.values()
+	e.ephemeral.series.clear()
+	e.ephemeral.mu.Unlock()
+	if len(series) == 0 {
+		return nil
+	}
+
+	timeseries := make([]prompb.TimeSeries, 0, len(series))
+	for _, labels := range series {
+		timeseries = append(timeseries, prompb.TimeSeries{
+			Labels:  labels,
+			Samples: []prompb.Sample{{Value: math.Float64frombits(value.StaleNaN), Timestamp: now.UnixMilli()}},
+		})
+	}
+	return e.sendTimeSeries(ctx, timeseries)
+}
@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestStalenessMarkers(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		resource    *resource.Resource
+		wantMarkers bool
+	}{
+		{name: "not ephemeral", resource: getResource()},
+		{name: "ephemeral job", config: Config{EphemeralJob: true}, resource: getResource(), wantMarkers: true},
+		{
+			name:        "ephemeral attribute",
+			config:      Config{EphemeralAttribute: "job.ephemeral"},
+			resource:    resource.NewSchemaless(attribute.Bool("job.ephemeral", true)),
+			wantMarkers: true,
+		},
+		{
+			name:     "ephemeral attribute false",
+			config:   Config{EphemeralAttribute: "job.ephemeral"},
+			resource: resource.NewSchemaless(attribute.Bool("job.ephemeral", false)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []*prompb.WriteRequest
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				compressed, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				uncompressed, err := snappy.Decode(nil, compressed)
+				require.NoError(t, err)
+				wr := &prompb.WriteRequest{}
+				require.NoError(t, wr.Unmarshal(uncompressed))
+				requests = append(requests, wr)
+				rw.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			config := tt.config
+			config.LogzioMetricsListener = server.URL
+			config.LogzioMetricsToken = "123456789a"
+			exporter, err := New(config)
+			require.NoError(t, err)
+
+			rm := getSumMetric(5)
+			rm.Resource = tt.resource
+			require.NoError(t, exporter.Export(context.Background(), rm))
+			require.NoError(t, exporter.Export(context.Background(), rm))
+			require.NoError(t, exporter.Shutdown(context.Background()))
+
+			if !tt.wantMarkers {
+				assert.Len(t, requests, 2)
+				return
+			}
+			require.Len(t, requests, 3)
+			markers := requests[2].Timeseries
+			require.Len(t, markers, 1, "a single marker per series")
+			assert.Equal(t, requests[1].Timeseries[0].Labels, markers[0].Labels)
+			require.Len(t, markers[0].Samples, 1)
+			assert.True(t, value.IsStaleNaN(markers[0].Samples[0].Value))
+			assert.False(t, math.IsNaN(requests[1].Timeseries[0].Samples[0].Value))
+			assert.GreaterOrEqual(t, markers[0].Samples[0].Timestamp, requests[1].Timeseries[0].Samples[0].Timestamp)
+		})
+	}
+}
+
+func TestStalenessMarkersMaxTrackedSeries(t *testing.T) {
+	exporter := Exporter{}
+	exporter.ephemeral.setMaxSeries(2)
+	res := resource.NewSchemaless(attribute.String("job", "batch"))
+	for _, name := range []string{"first", "second", "third"} {
+		ts := prompb.TimeSeries{Labels: []prompb.Label{{Name: "__name__", Value: name}}}
+		exporter.trackEphemeral(res, []prompb.TimeSeries{ts})
+	}
+	assert.Equal(t, 0, exporter.State().EphemeralSeries, "not an ephemeral job")
+
+	exporter.config.EphemeralJob = true
+	for _, name := range []string{"first", "second", "third"} {
+		ts := prompb.TimeSeries{Labels: []prompb.Label{{Name: "__name__", Value: name}}}
+		exporter.trackEphemeral(res, []prompb.TimeSeries{ts})
+	}
+	// The least recently exported series is evicted.
+	assert.Equal(t, 2, exporter.State().EphemeralSeries)
+	assert.Equal(t, [][]prompb.Label{
+		{{Name: "__name__", Value: "third"}},
+		{{Name: "__name__", Value: "second"}},
+	}, exporter.ephemeral.series.values())
+}
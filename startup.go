@@ -0,0 +1,43 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// ErrStartupExport occurs when the startup export of an exporter with ExportOnStart fails.
+var ErrStartupExport = fmt.Errorf("startup export to logz.io failed")
+
+// startupExport sends the build info series synchronously, so that a wrong listener, token or network setup is
+// reported by New instead of by the first periodic export.
+func (e *Exporter) startupExport() error {
+	labels := generateGlobalLabels(resource.Empty(), e.config.ExternalLabels, e.config.copyResourceAttributes())
+	ts := []prompb.TimeSeries{convertBuildInfo(labels, e.config.LabelNamespace, e.config.UTF8Names)}
+	e.filterSeriesLabels(ts)
+
+	message, err := e.buildMessage(ts)
+	if err == nil {
+		err = e.sendMessage(context.Background(), message, 0)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStartupExport, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportOnStart(t *testing.T) {
+	var mu sync.Mutex
+	status := http.StatusUnauthorized
+	var received prompb.WriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		compressed, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		body, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		// Unmarshal appends to the series, so every request is decoded into a new WriteRequest.
+		var writeRequest prompb.WriteRequest
+		require.NoError(t, writeRequest.Unmarshal(body))
+
+		mu.Lock()
+		defer mu.Unlock()
+		received = writeRequest
+		rw.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	config := Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a", ExportOnStart: true}
+	_, err := New(config)
+	assert.ErrorIs(t, err, ErrStartupExport)
+
+	mu.Lock()
+	status = http.StatusNoContent
+	mu.Unlock()
+	exporter, err := New(config)
+	require.NoError(t, err)
+	require.NotNil(t, exporter)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received.Timeseries, 1)
+	assert.Equal(t, buildInfoMetricName, seriesName(received.Timeseries[0].Labels))
+}
@@ -0,0 +1,62 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+// State describes the per-series state the exporter keeps across exports.
+type State struct {
+	// DeltaSeries is the number of series whose running totals are kept to convert delta data to cumulative data.
+	DeltaSeries int
+	// StrictSeries is the number of series whose last sample time is kept to detect out-of-order samples in
+	// strict mode.
+	StrictSeries int
+	// EphemeralSeries is the number of series of short-lived jobs whose labels are kept to mark them stale on
+	// shutdown.
+	EphemeralSeries int
+}
+
+// State returns the number of series the exporter keeps state for.
+func (e *Exporter) State() State {
+	e.deltas.mu.Lock()
+	deltaSeries := e.deltas.sums.len() + e.deltas.histograms.len()
+	e.deltas.mu.Unlock()
+
+	e.sampleOrder.mu.Lock()
+	strictSeries := e.sampleOrder.times.len()
+	e.sampleOrder.mu.Unlock()
+
+	e.ephemeral.mu.Lock()
+	ephemeralSeries := e.ephemeral.series.len()
+	e.ephemeral.mu.Unlock()
+
+	return State{DeltaSeries: deltaSeries, StrictSeries: strictSeries, EphemeralSeries: ephemeralSeries}
+}
+
+// ResetState clears the per-series state, e.g. to bound memory after the set of exported series changed.
+// Delta series start over from their next datapoint, so their cumulative values restart from zero, and the series of
+// short-lived jobs exported so far are not marked stale on shutdown.
+func (e *Exporter) ResetState() {
+	e.deltas.mu.Lock()
+	e.deltas.sums.clear()
+	e.deltas.histograms.clear()
+	e.deltas.mu.Unlock()
+
+	e.sampleOrder.mu.Lock()
+	e.sampleOrder.times.clear()
+	e.sampleOrder.mu.Unlock()
+
+	e.ephemeral.mu.Lock()
+	e.ephemeral.series.clear()
+	e.ephemeral.mu.Unlock()
+}
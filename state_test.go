@@ -0,0 +1,74 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestResetState(t *testing.T) {
+	exporter := Exporter{config: Config{Strict: true, EphemeralJob: true}}
+	rm := getSumMetric(5)
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	sum.Temporality = metricdata.DeltaTemporality
+	rm.ScopeMetrics[0].Metrics[0].Data = sum
+
+	_, err := exporter.convertToTimeSeries(rm, conversionExport)
+	require.NoError(t, err)
+	got, err := exporter.convertToTimeSeries(rm, conversionExport)
+	require.NoError(t, err)
+	assert.Equal(t, float64(10), got[0].Samples[0].Value)
+	exporter.trackEphemeral(rm.Resource, got)
+	assert.Equal(t, State{DeltaSeries: 1, StrictSeries: 1, EphemeralSeries: 1}, exporter.State())
+
+	exporter.ResetState()
+	assert.Equal(t, State{}, exporter.State())
+
+	got, err = exporter.convertToTimeSeries(rm, conversionExport)
+	require.NoError(t, err)
+	assert.Equal(t, float64(5), got[0].Samples[0].Value)
+}
+
+func TestConvertToTimeSeriesUpdatesNoState(t *testing.T) {
+	exporter := Exporter{config: Config{
+		Strict:  true,
+		Budgets: []Budget{{Name: "sums", Labels: map[string]string{"__name__": "metric_sum"}, MaxSamples: 1, Interval: time.Hour}},
+	}}
+	rm := getSumMetric(5)
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	sum.Temporality = metricdata.DeltaTemporality
+	rm.ScopeMetrics[0].Metrics[0].Data = sum
+
+	for i := 0; i < 2; i++ {
+		got, err := exporter.ConvertToTimeSeries(rm)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, float64(5), got[0].Samples[0].Value)
+	}
+	assert.Equal(t, State{}, exporter.State())
+	assert.Empty(t, exporter.BudgetDrops())
+
+	// The metrics converted by ConvertToTimeSeries are still exported once.
+	got, err := exporter.convertToTimeSeries(rm, conversionExport)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, float64(5), got[0].Samples[0].Value)
+	assert.Equal(t, State{DeltaSeries: 1, StrictSeries: 1}, exporter.State())
+}
@@ -0,0 +1,137 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// statsTopMetrics is the number of largest metrics reported in the Stats.
+const statsTopMetrics = 10
+
+// MetricSize is the encoded size of all the series of a metric in a message.
+type MetricSize struct {
+	Name   string
+	Series int
+	Bytes  int
+}
+
+// Stats describes the size and compression of the last message built by the exporter, and the utilization
+// of the async send queue and of the spool.
+type Stats struct {
+	Series            int
+	UncompressedBytes int
+	CompressedBytes   int
+	// CompressionRatio is UncompressedBytes divided by CompressedBytes.
+	CompressionRatio float64
+	// TopMetrics are the metrics with the largest encoded size, largest first.
+	TopMetrics []MetricSize
+	// QueuedMessages is the number of messages waiting in the async send queue.
+	QueuedMessages int
+	// QueueCapacity is the AsyncQueueSize, or 0 in synchronous mode.
+	QueueCapacity int
+	// SpooledMessages is the number of messages in the Spool.
+	SpooledMessages int
+	// SpoolBytes is the size of the files of the messages in the Spool.
+	SpoolBytes int64
+	// SpoolMaxBytes is the MaxBytes of the Spool, or 0 when its size is not limited.
+	SpoolMaxBytes int64
+	// SpoolRetention is the Retention of the Spool, or 0 when its messages are kept until they are sent.
+	SpoolRetention time.Duration
+}
+
+// statsRecorder holds the Stats of the last message.
+type statsRecorder struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Stats returns the size and compression statistics of the last message built by the exporter, which help
+// identify the instruments that dominate the payloads, and the utilization of the async send queue and of the
+// spool, which helps alert before messages are dropped with ErrQueueFull or evicted from the spool.
+func (e *Exporter) Stats() Stats {
+	// The spool is not locked, so that Stats is not blocked while the spool is drained. A spool that cannot be
+	// read is reported as empty.
+	var spooled []spooledMessage
+	if e.config.Spool.Dir != "" {
+		spooled, _ = e.spooledMessages()
+	}
+
+	e.stats.mu.Lock()
+	defer e.stats.mu.Unlock()
+
+	stats := e.stats.stats
+	stats.TopMetrics = slices.Clone(stats.TopMetrics)
+	stats.QueuedMessages = len(e.queue)
+	stats.QueueCapacity = cap(e.queue)
+	stats.SpooledMessages = len(spooled)
+	for _, m := range spooled {
+		stats.SpoolBytes += m.size
+	}
+	stats.SpoolMaxBytes = e.config.Spool.MaxBytes
+	stats.SpoolRetention = e.config.Spool.Retention
+	return stats
+}
+
+// recordStats computes the Stats of a message built from timeseries.
+func (e *Exporter) recordStats(timeseries []prompb.TimeSeries, compressedBytes int) {
+	sizes := map[string]*MetricSize{}
+	uncompressed := 0
+	for i := range timeseries {
+		ts := &timeseries[i]
+		name := seriesName(ts.Labels)
+		size, ok := sizes[name]
+		if !ok {
+			size = &MetricSize{Name: name}
+			sizes[name] = size
+		}
+		size.Series++
+		size.Bytes += ts.Size()
+		uncompressed += ts.Size()
+	}
+
+	top := make([]MetricSize, 0, len(sizes))
+	for _, size := range sizes {
+		top = append(top, *size)
+	}
+	slices.SortFunc(top, func(a, b MetricSize) int {
+		if c := cmp.Compare(b.Bytes, a.Bytes); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	if len(top) > statsTopMetrics {
+		top = top[:statsTopMetrics]
+	}
+
+	stats := Stats{
+		Series:            len(timeseries),
+		UncompressedBytes: uncompressed,
+		CompressedBytes:   compressedBytes,
+		TopMetrics:        top,
+	}
+	if compressedBytes > 0 {
+		stats.CompressionRatio = float64(uncompressed) / float64(compressedBytes)
+	}
+
+	e.stats.mu.Lock()
+	e.stats.stats = stats
+	e.stats.mu.Unlock()
+}
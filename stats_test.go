@@ -0,0 +1,83 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordStats(t *testing.T) {
+	var timeseries []prompb.TimeSeries
+	var largestBytes int
+	for i := 0; i < statsTopMetrics+2; i++ {
+		// Metric i has i+1 series, so the last metrics are the largest.
+		for j := 0; j <= i; j++ {
+			ts := prompb.TimeSeries{
+				Labels:  []prompb.Label{{Name: "__name__", Value: fmt.Sprintf("metric_%02d", i)}},
+				Samples: []prompb.Sample{{Value: float64(j), Timestamp: 1}},
+			}
+			timeseries = append(timeseries, ts)
+			// The size of a series depends on its sample value, since zero values are not encoded.
+			if i == statsTopMetrics+1 {
+				largestBytes += ts.Size()
+			}
+		}
+	}
+
+	exporter := Exporter{}
+	exporter.recordStats(timeseries, 100)
+	stats := exporter.Stats()
+
+	assert.Equal(t, len(timeseries), stats.Series)
+	assert.Equal(t, 100, stats.CompressedBytes)
+	assert.Equal(t, float64(stats.UncompressedBytes)/100, stats.CompressionRatio)
+	assert.Len(t, stats.TopMetrics, statsTopMetrics)
+	assert.Equal(t, "metric_11", stats.TopMetrics[0].Name)
+	assert.Equal(t, 12, stats.TopMetrics[0].Series)
+	assert.Equal(t, largestBytes, stats.TopMetrics[0].Bytes)
+}
+
+func TestStatsQueue(t *testing.T) {
+	exporter := Exporter{}
+	stats := exporter.Stats()
+	assert.Zero(t, stats.QueuedMessages)
+	assert.Zero(t, stats.QueueCapacity)
+
+	exporter.queue = make(chan queuedMessage, 4)
+	exporter.queue <- queuedMessage{message: []byte("message"), series: 1}
+	stats = exporter.Stats()
+	assert.Equal(t, 1, stats.QueuedMessages)
+	assert.Equal(t, 4, stats.QueueCapacity)
+}
+
+func TestStatsSpool(t *testing.T) {
+	exporter := Exporter{config: Config{Spool: SpoolConfig{Dir: t.TempDir(), MaxBytes: 1 << 20, Retention: time.Hour}}}
+	stats := exporter.Stats()
+	assert.Zero(t, stats.SpooledMessages)
+	assert.Zero(t, stats.SpoolBytes)
+
+	require.NoError(t, exporter.spoolMessage([]byte("message"), time.Now()))
+	stats = exporter.Stats()
+	assert.Equal(t, 1, stats.SpooledMessages)
+	assert.Equal(t, int64(spoolHeaderSize+len("message")), stats.SpoolBytes)
+	assert.Equal(t, int64(1<<20), stats.SpoolMaxBytes)
+	assert.Equal(t, time.Hour, stats.SpoolRetention)
+}
@@ -0,0 +1,168 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// sampleOrderTracker keeps the timestamp of the last sample of every series exported in strict mode.
+type sampleOrderTracker struct {
+	mu    sync.Mutex
+	times seriesCache[string, int64]
+}
+
+// checkStrict returns an error wrapping ErrSpecViolation when a metric has an invalid name, attributes that
+// collide with each other or with labels once sanitized, or NaN values. With utf8Names, any UTF-8 metric name
+// is valid.
+func checkStrict(metricName string, labels map[string]string, data metricdata.Aggregation, utf8Names bool) error {
+	if !isValidName(metricName, utf8Names) {
+		return fmt.Errorf("%w: metric %q has an invalid name", ErrSpecViolation, metricName)
+	}
+
+	switch data := data.(type) {
+	case metricdata.Sum[int64]:
+		return checkStrictDataPoints(metricName, labels, data.DataPoints, utf8Names)
+	case metricdata.Sum[float64]:
+		return checkStrictDataPoints(metricName, labels, data.DataPoints, utf8Names)
+	case metricdata.Gauge[int64]:
+		return checkStrictDataPoints(metricName, labels, data.DataPoints, utf8Names)
+	case metricdata.Gauge[float64]:
+		return checkStrictDataPoints(metricName, labels, data.DataPoints, utf8Names)
+	case metricdata.Histogram[int64]:
+		return checkStrictHistogramDataPoints(metricName, labels, data.DataPoints, utf8Names)
+	case metricdata.Histogram[float64]:
+		return checkStrictHistogramDataPoints(metricName, labels, data.DataPoints, utf8Names)
+	case metricdata.ExponentialHistogram[int64]:
+		return checkStrictExponentialHistogramDataPoints(metricName, labels, data.DataPoints, utf8Names)
+	case metricdata.ExponentialHistogram[float64]:
+		return checkStrictExponentialHistogramDataPoints(metricName, labels, data.DataPoints, utf8Names)
+	}
+	return nil
+}
+
+// checkStrictDataPoints checks the attributes and values of sum and gauge data points.
+func checkStrictDataPoints[N int64 | float64](metricName string, labels map[string]string, dps []metricdata.DataPoint[N], utf8Names bool) error {
+	for _, dp := range dps {
+		if err := checkLabelCollisions(metricName, labels, dp.Attributes, utf8Names); err != nil {
+			return err
+		}
+		if math.IsNaN(float64(dp.Value)) {
+			return fmt.Errorf("%w: metric %q has a NaN value for attributes %s", ErrSpecViolation, metricName, dp.Attributes.Encoded(attribute.DefaultEncoder()))
+		}
+	}
+	return nil
+}
+
+// checkStrictHistogramDataPoints checks the attributes and sums of histogram data points.
+func checkStrictHistogramDataPoints[N int64 | float64](metricName string, labels map[string]string, dps []metricdata.HistogramDataPoint[N], utf8Names bool) error {
+	for _, dp := range dps {
+		if err := checkLabelCollisions(metricName, labels, dp.Attributes, utf8Names); err != nil {
+			return err
+		}
+		if math.IsNaN(float64(dp.Sum)) {
+			return fmt.Errorf("%w: metric %q has a NaN sum for attributes %s", ErrSpecViolation, metricName, dp.Attributes.Encoded(attribute.DefaultEncoder()))
+		}
+	}
+	return nil
+}
+
+// checkStrictExponentialHistogramDataPoints checks the attributes and sums of exponential histogram data points.
+func checkStrictExponentialHistogramDataPoints[N int64 | float64](metricName string, labels map[string]string, dps []metricdata.ExponentialHistogramDataPoint[N], utf8Names bool) error {
+	for _, dp := range dps {
+		if err := checkLabelCollisions(metricName, labels, dp.Attributes, utf8Names); err != nil {
+			return err
+		}
+		if math.IsNaN(float64(dp.Sum)) {
+			return fmt.Errorf("%w: metric %q has a NaN sum for attributes %s", ErrSpecViolation, metricName, dp.Attributes.Encoded(attribute.DefaultEncoder()))
+		}
+	}
+	return nil
+}
+
+// checkLabelCollisions returns an error when different label or attribute keys are sanitized to the same label name,
+// which createLabelSet would otherwise merge.
+func checkLabelCollisions(metricName string, labels map[string]string, attributes attribute.Set, utf8Names bool) error {
+	keys := make(map[string]string, len(labels)+attributes.Len())
+	add := func(key string) error {
+		name := labelName(key, utf8Names)
+		if other, ok := keys[name]; ok && other != key {
+			return fmt.Errorf("%w: metric %q has keys %q and %q that collide as label %q", ErrSpecViolation, metricName, other, key, name)
+		}
+		keys[name] = key
+		return nil
+	}
+
+	for key := range labels {
+		if err := add(key); err != nil {
+			return err
+		}
+	}
+	for _, attr := range attributes.ToSlice() {
+		if err := add(string(attr.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setMaxSeries bounds the number of series whose last sample time is kept.
+func (t *sampleOrderTracker) setMaxSeries(maxSeries int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.times.max = maxSeries
+}
+
+// check returns an error when a series has a sample older than the last sample exported for it. Otherwise it
+// records the timestamps of the series.
+func (t *sampleOrderTracker) check(metricName string, timeseries []prompb.TimeSeries) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	latest := make(map[string]int64, len(timeseries))
+	for _, ts := range timeseries {
+		key := labelsKey(ts.Labels)
+		for _, sample := range ts.Samples {
+			last, ok := latest[key]
+			if !ok {
+				last, ok = t.times.get(key)
+			}
+			if ok && sample.Timestamp < last {
+				return fmt.Errorf("%w: metric %q has an out-of-order sample at %d for series %s", ErrSpecViolation, metricName, sample.Timestamp, key)
+			}
+			latest[key] = sample.Timestamp
+		}
+	}
+	for key, timestamp := range latest {
+		t.times.put(key, timestamp)
+	}
+	return nil
+}
+
+// labelsKey returns a string identifying the series with the given sorted labels.
+func labelsKey(labels []prompb.Label) string {
+	pairs := make([]string, 0, len(labels))
+	for _, label := range labels {
+		pairs = append(pairs, label.Name+"="+label.Value)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,83 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestCheckStrict(t *testing.T) {
+	labels := map[string]string{"service.name": "test"}
+	gauge := func(value float64, attrs ...attribute.KeyValue) metricdata.Gauge[float64] {
+		return metricdata.Gauge[float64]{DataPoints: []metricdata.DataPoint[float64]{
+			{Attributes: attribute.NewSet(attrs...), Time: time.Now(), Value: value},
+		}}
+	}
+
+	tests := []struct {
+		name       string
+		metricName string
+		data       metricdata.Aggregation
+		utf8Names  bool
+		wantErr    bool
+	}{
+		{name: "valid", metricName: "requests", data: gauge(1, attribute.String("method", "GET"))},
+		{name: "invalid name", metricName: "http.requests", data: gauge(1), wantErr: true},
+		{name: "UTF-8 name", metricName: "http.requests", data: gauge(1), utf8Names: true},
+		{name: "invalid UTF-8 name", metricName: "http.\xff", data: gauge(1), utf8Names: true, wantErr: true},
+		{name: "no collision between UTF-8 names", metricName: "requests", data: gauge(1, attribute.String("service_name", "x")), utf8Names: true},
+		{name: "NaN value", metricName: "requests", data: gauge(math.NaN()), wantErr: true},
+		{name: "collision with a label", metricName: "requests", data: gauge(1, attribute.String("service_name", "x")), wantErr: true},
+		{name: "collision between attributes", metricName: "requests", data: gauge(1, attribute.String("a.b", "x"), attribute.String("a-b", "y")), wantErr: true},
+		{name: "NaN histogram sum", metricName: "latency", data: metricdata.Histogram[float64]{DataPoints: []metricdata.HistogramDataPoint[float64]{{Sum: math.NaN()}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStrict(tt.metricName, labels, tt.data, tt.utf8Names)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrSpecViolation)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConvertToTimeSeriesStrictSampleOrder tests that in strict mode a sample older than the last exported sample
+// of its series is reported and the metric is dropped.
+func TestConvertToTimeSeriesStrictSampleOrder(t *testing.T) {
+	exporter := Exporter{config: Config{Strict: true}}
+
+	rm := getSumMetric(5)
+	got, err := exporter.convertToTimeSeries(rm, conversionExport)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+
+	older := getSumMetric(6)
+	sum := older.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	sum.DataPoints[0].Time = time.Now().Add(-time.Hour)
+	older.ScopeMetrics[0].Metrics[0].Data = sum
+
+	got, err = exporter.convertToTimeSeries(older, conversionExport)
+	assert.ErrorIs(t, err, ErrSpecViolation)
+	assert.Empty(t, got)
+}
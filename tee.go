@@ -0,0 +1,78 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-multierror"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// teeExporter is a metric.Exporter that fans out every call to a primary exporter and secondary exporters.
+type teeExporter struct {
+	primary     metric.Exporter
+	secondaries []metric.Exporter
+}
+
+// Tee returns a metric.Exporter that exports to the primary exporter and to every secondary exporter, e.g.
+// the Logz.io Exporter and a stdout or OTLP exporter during a migration. The temporality and aggregation of
+// the primary exporter are used for all of them. Errors of all the exporters are aggregated and returned.
+func Tee(primary metric.Exporter, secondaries ...metric.Exporter) metric.Exporter {
+	return &teeExporter{primary: primary, secondaries: secondaries}
+}
+
+// Temporality returns the temporality of the primary exporter.
+func (t *teeExporter) Temporality(k metric.InstrumentKind) metricdata.Temporality {
+	return t.primary.Temporality(k)
+}
+
+// Aggregation returns the aggregation of the primary exporter.
+func (t *teeExporter) Aggregation(k metric.InstrumentKind) metric.Aggregation {
+	return t.primary.Aggregation(k)
+}
+
+// Export exports the metrics to all the exporters.
+func (t *teeExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	return t.forEach(func(exporter metric.Exporter) error {
+		return exporter.Export(ctx, rm)
+	})
+}
+
+// ForceFlush flushes all the exporters.
+func (t *teeExporter) ForceFlush(ctx context.Context) error {
+	return t.forEach(func(exporter metric.Exporter) error {
+		return exporter.ForceFlush(ctx)
+	})
+}
+
+// Shutdown shuts down all the exporters.
+func (t *teeExporter) Shutdown(ctx context.Context) error {
+	return t.forEach(func(exporter metric.Exporter) error {
+		return exporter.Shutdown(ctx)
+	})
+}
+
+// forEach calls f for the primary exporter and every secondary exporter, and aggregates the errors.
+func (t *teeExporter) forEach(f func(metric.Exporter) error) error {
+	var result *multierror.Error
+	for _, exporter := range append([]metric.Exporter{t.primary}, t.secondaries...) {
+		if err := f(exporter); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result.ErrorOrNil()
+}
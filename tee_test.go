@@ -0,0 +1,63 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// recordingExporter is a metric.Exporter that records the exported metrics and returns err.
+type recordingExporter struct {
+	metric.Exporter
+	exported []*metricdata.ResourceMetrics
+	err      error
+}
+
+func (r *recordingExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	r.exported = append(r.exported, rm)
+	return r.err
+}
+
+func TestTee(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a"})
+	require.NoError(t, err)
+	ok := &recordingExporter{}
+	failing := &recordingExporter{err: fmt.Errorf("secondary failed")}
+
+	tee := Tee(exporter, ok, failing)
+	rm := getSumMetric(5)
+	err = tee.Export(context.Background(), rm)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+	assert.Contains(t, err.Error(), "secondary failed")
+	assert.Equal(t, []*metricdata.ResourceMetrics{rm}, ok.exported)
+	assert.Equal(t, []*metricdata.ResourceMetrics{rm}, failing.exported)
+	assert.Equal(t, metricdata.CumulativeTemporality, tee.Temporality(metric.InstrumentKindHistogram))
+}
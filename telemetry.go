@@ -0,0 +1,212 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	m "go.opentelemetry.io/otel/metric"
+)
+
+// telemetryMeterName is the name of the meter of the instruments registered on the TelemetryMeterProvider.
+const telemetryMeterName = "github.com/logzio/go-metrics-sdk"
+
+// Telemetry holds counters of the activity of the exporter since it was created.
+type Telemetry struct {
+	// SentSeries is the number of series of the messages delivered to Logz.io.
+	SentSeries uint64
+	// FailedSeries is the number of series of the messages that failed to be delivered.
+	FailedSeries uint64
+	// DroppedSeries is the number of series dropped by the SeriesSampler, the Budgets or the label limits before
+	// being sent.
+	DroppedSeries uint64
+	// TruncatedLabelValues is the number of label values truncated to MaxLabelValueLength.
+	TruncatedLabelValues uint64
+	// DroppedLabels is the number of labels dropped because their value was longer than MaxLabelValueLength.
+	DroppedLabels uint64
+	// SentBytes is the compressed size of the messages delivered to Logz.io.
+	SentBytes uint64
+	// Requests is the number of requests sent to Logz.io, including retries.
+	Requests uint64
+	// FailedRequests is the number of failed requests by response status code, with 0 for network errors.
+	FailedRequests map[int]uint64
+	// RequestDuration is the total duration of the requests.
+	RequestDuration time.Duration
+	// EvictedSpoolMessages is the number of spooled messages removed to keep the spool within its MaxBytes.
+	EvictedSpoolMessages uint64
+	// ExpiredSpoolMessages is the number of spooled messages removed because they were older than the Retention.
+	ExpiredSpoolMessages uint64
+	// DroppedSpoolMessages is the number of messages that were not spooled because they were larger than the
+	// MaxBytes of the spool, or were removed from the spool because they were corrupt.
+	DroppedSpoolMessages uint64
+}
+
+// telemetryRecorder holds the Telemetry of the exporter.
+type telemetryRecorder struct {
+	mu        sync.Mutex
+	telemetry Telemetry
+}
+
+// Telemetry returns the counters of the series, requests and bytes sent by the exporter since it was created,
+// e.g. to report them as metrics of the process.
+func (e *Exporter) Telemetry() Telemetry {
+	e.telemetry.mu.Lock()
+	defer e.telemetry.mu.Unlock()
+
+	telemetry := e.telemetry.telemetry
+	telemetry.FailedRequests = maps.Clone(telemetry.FailedRequests)
+	return telemetry
+}
+
+// registerTelemetryInstruments registers observable gauges of the depths of the send queue and of the spool on
+// a meter of the TelemetryMeterProvider, so that their saturation is visible before messages are dropped. The
+// gauges are only registered for the queue and spool that are set, and are unregistered by Shutdown.
+func (e *Exporter) registerTelemetryInstruments() error {
+	meter := e.config.TelemetryMeterProvider.Meter(telemetryMeterName, m.WithInstrumentationVersion(Version()))
+
+	var instruments []m.Observable
+	var queueDepth, queueCapacity, spoolMessages, spoolSize, spoolCapacity m.Int64ObservableGauge
+	var err error
+	if e.queue != nil {
+		if queueDepth, err = meter.Int64ObservableGauge("logzio_exporter_queue_depth",
+			m.WithDescription("Number of messages waiting in the send queue."), m.WithUnit("{message}")); err != nil {
+			return err
+		}
+		if queueCapacity, err = meter.Int64ObservableGauge("logzio_exporter_queue_capacity",
+			m.WithDescription("Maximum number of messages in the send queue."), m.WithUnit("{message}")); err != nil {
+			return err
+		}
+		instruments = append(instruments, queueDepth, queueCapacity)
+	}
+	if e.config.Spool.Dir != "" {
+		if spoolMessages, err = meter.Int64ObservableGauge("logzio_exporter_spool_messages",
+			m.WithDescription("Number of messages in the spool."), m.WithUnit("{message}")); err != nil {
+			return err
+		}
+		if spoolSize, err = meter.Int64ObservableGauge("logzio_exporter_spool_size",
+			m.WithDescription("Size of the messages in the spool."), m.WithUnit("By")); err != nil {
+			return err
+		}
+		instruments = append(instruments, spoolMessages, spoolSize)
+		if e.config.Spool.MaxBytes > 0 {
+			if spoolCapacity, err = meter.Int64ObservableGauge("logzio_exporter_spool_capacity",
+				m.WithDescription("Maximum size of the messages in the spool."), m.WithUnit("By")); err != nil {
+				return err
+			}
+			instruments = append(instruments, spoolCapacity)
+		}
+	}
+	if len(instruments) == 0 {
+		return nil
+	}
+
+	e.telemetryReg, err = meter.RegisterCallback(func(_ context.Context, o m.Observer) error {
+		if e.queue != nil {
+			o.ObserveInt64(queueDepth, int64(len(e.queue)))
+			o.ObserveInt64(queueCapacity, int64(cap(e.queue)))
+		}
+		if e.config.Spool.Dir != "" {
+			// The spool is not locked, so that collections are not blocked while the spool is drained.
+			messages, err := e.spooledMessages()
+			if err != nil {
+				return fmt.Errorf("failed to read spool: %w", err)
+			}
+			var size int64
+			for _, message := range messages {
+				size += message.size
+			}
+			o.ObserveInt64(spoolMessages, int64(len(messages)))
+			o.ObserveInt64(spoolSize, size)
+			if e.config.Spool.MaxBytes > 0 {
+				o.ObserveInt64(spoolCapacity, e.config.Spool.MaxBytes)
+			}
+		}
+		return nil
+	}, instruments...)
+	return err
+}
+
+// recordRequest records a request sent to Logz.io and its duration.
+func (r *telemetryRecorder) recordRequest(duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.telemetry.Requests++
+	r.telemetry.RequestDuration += duration
+}
+
+// recordFailedRequest records a request that failed with the response status code, or 0 for network errors.
+func (r *telemetryRecorder) recordFailedRequest(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.telemetry.FailedRequests == nil {
+		r.telemetry.FailedRequests = map[int]uint64{}
+	}
+	r.telemetry.FailedRequests[status]++
+}
+
+// recordDelivery records a message of a number of series that was delivered when err is nil.
+func (r *telemetryRecorder) recordDelivery(series, bytes int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.telemetry.FailedSeries += uint64(series)
+		return
+	}
+	r.telemetry.SentSeries += uint64(series)
+	r.telemetry.SentBytes += uint64(bytes)
+}
+
+// recordDropped records series dropped before being sent.
+func (r *telemetryRecorder) recordDropped(series int) {
+	if series == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.telemetry.DroppedSeries += uint64(series)
+}
+
+// recordSpoolRemovals records the messages evicted from the spool, expired in the spool, and dropped by the spool.
+func (r *telemetryRecorder) recordSpoolRemovals(evicted, expired, dropped int) {
+	if evicted == 0 && expired == 0 && dropped == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.telemetry.EvictedSpoolMessages += uint64(evicted)
+	r.telemetry.ExpiredSpoolMessages += uint64(expired)
+	r.telemetry.DroppedSpoolMessages += uint64(dropped)
+}
+
+// recordLabelLimits records label values truncated and labels dropped by the label limits.
+func (r *telemetryRecorder) recordLabelLimits(truncated, dropped int) {
+	if truncated == 0 && dropped == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.telemetry.TruncatedLabelValues += uint64(truncated)
+	r.telemetry.DroppedLabels += uint64(dropped)
+}
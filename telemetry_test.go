@@ -0,0 +1,138 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestTelemetry(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Config{LogzioMetricsListener: server.URL, LogzioMetricsToken: "123456789a"})
+	require.NoError(t, err)
+
+	require.Error(t, exporter.Export(context.Background(), getSumMetric(5)))
+	telemetry := exporter.Telemetry()
+	assert.Equal(t, uint64(1), telemetry.Requests)
+	assert.Equal(t, map[int]uint64{http.StatusServiceUnavailable: 1}, telemetry.FailedRequests)
+	assert.Equal(t, uint64(1), telemetry.FailedSeries)
+	assert.Zero(t, telemetry.SentSeries)
+
+	status = http.StatusNoContent
+	require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+	telemetry = exporter.Telemetry()
+	assert.Equal(t, uint64(2), telemetry.Requests)
+	assert.Equal(t, uint64(1), telemetry.SentSeries)
+	assert.NotZero(t, telemetry.SentBytes)
+	assert.NotZero(t, telemetry.RequestDuration)
+
+	telemetry.FailedRequests[0] = 1
+	assert.NotContains(t, exporter.Telemetry().FailedRequests, 0, "the snapshot is a copy")
+}
+
+func TestTelemetryDroppedSeries(t *testing.T) {
+	exporter := Exporter{config: Config{SeriesSampler: SeriesSamplerFunc(func(string, []prompb.Label) bool { return false })}}
+	_, err := exporter.convertToTimeSeries(getSumMetric(5), conversionExport)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), exporter.Telemetry().DroppedSeries)
+}
+
+// collectGauges returns the values of the int64 gauges collected by a reader, by name.
+func collectGauges(t *testing.T, reader metric.Reader) map[string]int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	gauges := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[int64]); ok && len(gauge.DataPoints) == 1 {
+				gauges[m.Name] = gauge.DataPoints[0].Value
+			}
+		}
+	}
+	return gauges
+}
+
+func TestTelemetryQueueGauges(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		<-release
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	reader := metric.NewManualReader()
+	exporter, err := New(Config{
+		LogzioMetricsListener:  server.URL,
+		LogzioMetricsToken:     "123456789a",
+		AsyncQueueSize:         4,
+		TelemetryMeterProvider: metric.NewMeterProvider(metric.WithReader(reader)),
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, exporter.Export(context.Background(), getSumMetric(5)))
+	}
+	// The worker takes the first message and blocks on its request.
+	assert.Eventually(t, func() bool {
+		return collectGauges(t, reader)["logzio_exporter_queue_depth"] == 2
+	}, time.Second, 10*time.Millisecond)
+	gauges := collectGauges(t, reader)
+	assert.Equal(t, int64(4), gauges["logzio_exporter_queue_capacity"])
+	assert.NotContains(t, gauges, "logzio_exporter_spool_messages")
+
+	close(release)
+	require.NoError(t, exporter.Shutdown(context.Background()))
+	assert.Empty(t, collectGauges(t, reader), "the gauges are unregistered by Shutdown")
+}
+
+func TestTelemetrySpoolGauges(t *testing.T) {
+	reader := metric.NewManualReader()
+	exporter, err := New(Config{
+		LogzioMetricsToken:     "123456789a",
+		Spool:                  SpoolConfig{Dir: t.TempDir(), MaxBytes: 1 << 20},
+		TelemetryMeterProvider: metric.NewMeterProvider(metric.WithReader(reader)),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int64{
+		"logzio_exporter_spool_messages": 0,
+		"logzio_exporter_spool_size":     0,
+		"logzio_exporter_spool_capacity": 1 << 20,
+	}, collectGauges(t, reader))
+
+	now := time.Now()
+	require.NoError(t, exporter.spoolMessage([]byte("first"), now))
+	require.NoError(t, exporter.spoolMessage([]byte("second"), now.Add(time.Second)))
+	assert.Equal(t, map[string]int64{
+		"logzio_exporter_spool_messages": 2,
+		"logzio_exporter_spool_size":     int64(2*spoolHeaderSize + 11),
+		"logzio_exporter_spool_capacity": 1 << 20,
+	}, collectGauges(t, reader))
+}
@@ -0,0 +1,153 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"slices"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// Temporality returns the temporality configured for the instrument kind in Config.Temporality, and
+// CumulativeTemporality otherwise. Delta data is converted to cumulative data before it is sent, since
+// Logz.io expects cumulative data.
+func (e *Exporter) Temporality(k metric.InstrumentKind) metricdata.Temporality {
+	if temporality, ok := e.config.Temporality[k]; ok {
+		return temporality
+	}
+	return metricdata.CumulativeTemporality
+}
+
+// seriesKey identifies the datapoints of a series across exports.
+type seriesKey struct {
+	scopeName       string
+	scopeVersion    string
+	scopeAttributes attribute.Distinct
+	metricName      string
+	attributes      attribute.Distinct
+}
+
+// newSeriesKey returns the key of the series of a datapoint.
+func newSeriesKey(scope instrumentation.Scope, metricName string, attributes attribute.Set) seriesKey {
+	return seriesKey{
+		scopeName:       scope.Name,
+		scopeVersion:    scope.Version,
+		scopeAttributes: scope.Attributes.Equivalent(),
+		metricName:      metricName,
+		attributes:      attributes.Equivalent(),
+	}
+}
+
+// deltaAccumulator converts delta data to cumulative data by keeping the running totals of every series.
+type deltaAccumulator struct {
+	mu         sync.Mutex
+	sums       seriesCache[seriesKey, any]
+	histograms seriesCache[seriesKey, any]
+}
+
+// setMaxSeries bounds the number of sum and histogram series whose running totals are kept.
+func (a *deltaAccumulator) setMaxSeries(maxSeries int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sums.max = maxSeries
+	a.histograms.max = maxSeries
+}
+
+// sumToCumulative returns the sum with the values of its datapoints added to the running totals. The running
+// totals are only updated when update is true.
+func sumToCumulative[N int64 | float64](a *deltaAccumulator, scope instrumentation.Scope, metricName string, sum metricdata.Sum[N], update bool) metricdata.Sum[N] {
+	if sum.Temporality != metricdata.DeltaTemporality {
+		return sum
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	dataPoints := make([]metricdata.DataPoint[N], len(sum.DataPoints))
+	for i, dp := range sum.DataPoints {
+		key := newSeriesKey(scope, metricName, dp.Attributes)
+		value, _ := a.sums.get(key)
+		if total, ok := value.(metricdata.DataPoint[N]); ok {
+			dp.StartTime = total.StartTime
+			dp.Value += total.Value
+		}
+		dataPoints[i] = dp
+		if !update {
+			continue
+		}
+		// The running totals do not keep the exemplars, which would stay in memory as long as the series is tracked.
+		dp.Exemplars = nil
+		a.sums.put(key, dp)
+	}
+
+	sum.DataPoints = dataPoints
+	sum.Temporality = metricdata.CumulativeTemporality
+	return sum
+}
+
+// histogramToCumulative returns the histogram with its datapoints merged into the running totals. A series
+// whose bucket boundaries change starts over. The running totals are only updated when update is true.
+func histogramToCumulative[N int64 | float64](a *deltaAccumulator, scope instrumentation.Scope, metricName string, histogram metricdata.Histogram[N], update bool) metricdata.Histogram[N] {
+	if histogram.Temporality != metricdata.DeltaTemporality {
+		return histogram
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	dataPoints := make([]metricdata.HistogramDataPoint[N], len(histogram.DataPoints))
+	for i, dp := range histogram.DataPoints {
+		key := newSeriesKey(scope, metricName, dp.Attributes)
+		dp.BucketCounts = slices.Clone(dp.BucketCounts)
+		value, _ := a.histograms.get(key)
+		if total, ok := value.(metricdata.HistogramDataPoint[N]); ok && slices.Equal(total.Bounds, dp.Bounds) {
+			dp.StartTime = total.StartTime
+			dp.Count += total.Count
+			dp.Sum += total.Sum
+			for j := range dp.BucketCounts {
+				if j < len(total.BucketCounts) {
+					dp.BucketCounts[j] += total.BucketCounts[j]
+				}
+			}
+			dp.Min = mergeExtrema(total.Min, dp.Min, func(a, b N) bool { return a < b })
+			dp.Max = mergeExtrema(total.Max, dp.Max, func(a, b N) bool { return a > b })
+		}
+		dataPoints[i] = dp
+		if !update {
+			continue
+		}
+		// The running totals do not keep the exemplars, which would stay in memory as long as the series is tracked.
+		dp.Exemplars = nil
+		a.histograms.put(key, dp)
+	}
+
+	histogram.DataPoints = dataPoints
+	histogram.Temporality = metricdata.CumulativeTemporality
+	return histogram
+}
+
+// mergeExtrema returns the extremum of a and b, where better reports whether its first value is more extreme.
+func mergeExtrema[N int64 | float64](a, b metricdata.Extrema[N], better func(N, N) bool) metricdata.Extrema[N] {
+	aValue, aDefined := a.Value()
+	bValue, bDefined := b.Value()
+	if !aDefined || (bDefined && better(bValue, aValue)) {
+		return b
+	}
+	return a
+}
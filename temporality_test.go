@@ -0,0 +1,126 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestSelectiveTemporality(t *testing.T) {
+	exporter := Exporter{config: Config{
+		Temporality: map[metric.InstrumentKind]metricdata.Temporality{
+			metric.InstrumentKindHistogram: metricdata.DeltaTemporality,
+		},
+	}}
+
+	assert.Equal(t, metricdata.DeltaTemporality, exporter.Temporality(metric.InstrumentKindHistogram))
+	assert.Equal(t, metricdata.CumulativeTemporality, exporter.Temporality(metric.InstrumentKindCounter))
+}
+
+func TestSumToCumulative(t *testing.T) {
+	var accumulator deltaAccumulator
+	start := time.Now()
+	delta := func(value int64, attrs attribute.Set, startTime time.Time) metricdata.Sum[int64] {
+		return metricdata.Sum[int64]{
+			Temporality: metricdata.DeltaTemporality,
+			IsMonotonic: true,
+			DataPoints:  []metricdata.DataPoint[int64]{{Attributes: attrs, StartTime: startTime, Value: value}},
+		}
+	}
+	a := attribute.NewSet(attribute.String("a", "1"))
+	b := attribute.NewSet(attribute.String("a", "2"))
+
+	got := sumToCumulative(&accumulator, getScope(), "requests", delta(2, a, start), true)
+	assert.Equal(t, int64(2), got.DataPoints[0].Value)
+	assert.Equal(t, metricdata.CumulativeTemporality, got.Temporality)
+
+	got = sumToCumulative(&accumulator, getScope(), "requests", delta(3, a, start.Add(time.Second)), true)
+	assert.Equal(t, int64(5), got.DataPoints[0].Value)
+	assert.Equal(t, start, got.DataPoints[0].StartTime)
+
+	got = sumToCumulative(&accumulator, getScope(), "requests", delta(7, b, start), true)
+	assert.Equal(t, int64(7), got.DataPoints[0].Value)
+
+	cumulative := delta(1, a, start)
+	cumulative.Temporality = metricdata.CumulativeTemporality
+	assert.Equal(t, cumulative, sumToCumulative(&accumulator, getScope(), "requests", cumulative, true))
+}
+
+func TestHistogramToCumulative(t *testing.T) {
+	var accumulator deltaAccumulator
+	delta := func(counts []uint64, sum float64, min, max float64) metricdata.Histogram[float64] {
+		var count uint64
+		for _, c := range counts {
+			count += c
+		}
+		return metricdata.Histogram[float64]{
+			Temporality: metricdata.DeltaTemporality,
+			DataPoints: []metricdata.HistogramDataPoint[float64]{{
+				Bounds:       []float64{1, 10},
+				BucketCounts: counts,
+				Count:        count,
+				Sum:          sum,
+				Min:          metricdata.NewExtrema(min),
+				Max:          metricdata.NewExtrema(max),
+			}},
+		}
+	}
+
+	histogramToCumulative(&accumulator, getScope(), "latency", delta([]uint64{1, 0, 1}, 20.5, 0.5, 20), true)
+	got := histogramToCumulative(&accumulator, getScope(), "latency", delta([]uint64{0, 2, 0}, 10, 4, 6), true)
+
+	dp := got.DataPoints[0]
+	assert.Equal(t, []uint64{1, 2, 1}, dp.BucketCounts)
+	assert.Equal(t, uint64(4), dp.Count)
+	assert.Equal(t, 30.5, dp.Sum)
+	assert.Equal(t, metricdata.NewExtrema(0.5), dp.Min)
+	assert.Equal(t, metricdata.NewExtrema(20.0), dp.Max)
+}
+
+func TestToCumulativeDropsExemplars(t *testing.T) {
+	var accumulator deltaAccumulator
+	exemplars := []metricdata.Exemplar[int64]{{Value: 1, TraceID: []byte{1}}}
+
+	got := sumToCumulative(&accumulator, getScope(), "requests", metricdata.Sum[int64]{
+		Temporality: metricdata.DeltaTemporality,
+		DataPoints:  []metricdata.DataPoint[int64]{{Value: 1, Exemplars: exemplars}},
+	}, true)
+	assert.Equal(t, exemplars, got.DataPoints[0].Exemplars, "the exported datapoint keeps its exemplars")
+
+	histogram := histogramToCumulative(&accumulator, getScope(), "latency", metricdata.Histogram[int64]{
+		Temporality: metricdata.DeltaTemporality,
+		DataPoints: []metricdata.HistogramDataPoint[int64]{{
+			Count: 1, Bounds: []float64{1}, BucketCounts: []uint64{1, 0}, Exemplars: exemplars,
+		}},
+	}, true)
+	assert.Equal(t, exemplars, histogram.DataPoints[0].Exemplars)
+
+	for _, value := range []any{accumulator.sums.order.Front().Value, accumulator.histograms.order.Front().Value} {
+		switch total := value.(*seriesCacheEntry[seriesKey, any]).value.(type) {
+		case metricdata.DataPoint[int64]:
+			assert.Nil(t, total.Exemplars)
+		case metricdata.HistogramDataPoint[int64]:
+			assert.Nil(t, total.Exemplars)
+		default:
+			t.Fatalf("unexpected running total %T", total)
+		}
+	}
+}
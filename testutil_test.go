@@ -64,6 +64,20 @@ func getSumMetric(value int64) *metricdata.ResourceMetrics {
 	}
 }
 
+// getSumMetricWithSeries returns a resource metric with a sum aggregation record of the given number of series
+func getSumMetricWithSeries(series int) *metricdata.ResourceMetrics {
+	rm := getSumMetric(5)
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	dp := sum.DataPoints[0]
+	sum.DataPoints = nil
+	for i := 0; i < series; i++ {
+		dp.Attributes = attribute.NewSet(attribute.Int("index", i), attribute.String("method", "GET"))
+		sum.DataPoints = append(sum.DataPoints, dp)
+	}
+	rm.ScopeMetrics[0].Metrics[0].Data = sum
+	return rm
+}
+
 // getGaugeMetric returns a resource metric with a gauge aggregation record
 func getGaugeMetric(value int64) *metricdata.ResourceMetrics {
 	return &metricdata.ResourceMetrics{
@@ -0,0 +1,72 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// timestampMillis returns the Unix time of ts in milliseconds, or 0 for the zero time, whose Unix time in
+// milliseconds is a large negative number.
+func timestampMillis(ts time.Time) int64 {
+	if ts.IsZero() {
+		return 0
+	}
+	return ts.UnixMilli()
+}
+
+// ZeroTimestamps returns the number of samples with a zero or Unix epoch time handled by the ZeroTimestampPolicy.
+func (e *Exporter) ZeroTimestamps() uint64 {
+	return e.zeroTimes.Load()
+}
+
+// guardTimestamps applies the ZeroTimestampPolicy to the samples and native histograms without a timestamp and
+// counts them. Series left without samples or native histograms are removed.
+func (e *Exporter) guardTimestamps(timeseries []prompb.TimeSeries, exportTime time.Time) []prompb.TimeSeries {
+	guarded := timeseries[:0]
+	for _, ts := range timeseries {
+		samples := ts.Samples[:0]
+		for _, sample := range ts.Samples {
+			if sample.Timestamp <= 0 {
+				e.zeroTimes.Add(1)
+				if e.config.ZeroTimestampPolicy == ZeroTimestampDrop {
+					continue
+				}
+				sample.Timestamp = exportTime.UnixMilli()
+			}
+			samples = append(samples, sample)
+		}
+		histograms := ts.Histograms[:0]
+		for _, histogram := range ts.Histograms {
+			if histogram.Timestamp <= 0 {
+				e.zeroTimes.Add(1)
+				if e.config.ZeroTimestampPolicy == ZeroTimestampDrop {
+					continue
+				}
+				histogram.Timestamp = exportTime.UnixMilli()
+			}
+			histograms = append(histograms, histogram)
+		}
+		if len(samples) == 0 && len(histograms) == 0 {
+			continue
+		}
+		ts.Samples = samples
+		ts.Histograms = histograms
+		guarded = append(guarded, ts)
+	}
+	return guarded
+}
@@ -0,0 +1,88 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestTimestampMillis(t *testing.T) {
+	assert.Equal(t, int64(0), timestampMillis(time.Time{}))
+	assert.Equal(t, int64(0), timestampMillis(time.Unix(0, 0)))
+	assert.Equal(t, int64(1500), timestampMillis(time.Unix(1, int64(500*time.Millisecond))))
+}
+
+func TestGuardTimestamps(t *testing.T) {
+	exportTime := time.Unix(100, 0)
+	newTimeSeries := func() []prompb.TimeSeries {
+		return []prompb.TimeSeries{
+			{Samples: []prompb.Sample{{Value: 1, Timestamp: 0}}},
+			{Samples: []prompb.Sample{{Value: 2, Timestamp: 5000}}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		policy ZeroTimestampPolicy
+		want   []prompb.TimeSeries
+	}{
+		{
+			name:   "export time",
+			policy: ZeroTimestampExportTime,
+			want: []prompb.TimeSeries{
+				{Samples: []prompb.Sample{{Value: 1, Timestamp: 100000}}},
+				{Samples: []prompb.Sample{{Value: 2, Timestamp: 5000}}},
+			},
+		},
+		{
+			name:   "drop",
+			policy: ZeroTimestampDrop,
+			want: []prompb.TimeSeries{
+				{Samples: []prompb.Sample{{Value: 2, Timestamp: 5000}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := Exporter{config: Config{ZeroTimestampPolicy: tt.policy}}
+
+			got := exporter.guardTimestamps(newTimeSeries(), exportTime)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, uint64(1), exporter.ZeroTimestamps())
+		})
+	}
+}
+
+// TestConvertToTimeSeriesZeroTimestamp tests that datapoints with a zero or epoch time are counted and dropped
+// with ZeroTimestampDrop.
+func TestConvertToTimeSeriesZeroTimestamp(t *testing.T) {
+	for _, zero := range []time.Time{{}, time.Unix(0, 0)} {
+		exporter := Exporter{config: Config{ZeroTimestampPolicy: ZeroTimestampDrop}}
+		rm := getSumMetric(5)
+		rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64]).DataPoints[0].Time = zero
+
+		got, err := exporter.ConvertToTimeSeries(rm)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+		assert.Equal(t, uint64(1), exporter.ZeroTimestamps())
+	}
+}
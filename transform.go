@@ -0,0 +1,33 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+// payloadTransformHeader is set to the encoding returned by a PayloadTransformer, so a proxy can restore the body.
+const payloadTransformHeader = "X-Payload-Transform"
+
+// PayloadTransformer transforms the compressed body of every request, e.g. to encrypt it for a decrypting
+// proxy in front of Logz.io. It returns the transformed body and the name of its encoding, which is sent in the
+// X-Payload-Transform header. It is called before every send attempt, and must not modify the payload.
+type PayloadTransformer interface {
+	TransformPayload(payload []byte) (transformed []byte, encoding string, err error)
+}
+
+// PayloadTransformerFunc is an adapter to use a function as a PayloadTransformer.
+type PayloadTransformerFunc func(payload []byte) ([]byte, string, error)
+
+// TransformPayload calls f(payload).
+func (f PayloadTransformerFunc) TransformPayload(payload []byte) ([]byte, string, error) {
+	return f(payload)
+}
@@ -0,0 +1,64 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadTransformer(t *testing.T) {
+	exporter := Exporter{config: Config{
+		LogzioMetricsListener: "https://listener.logz.io:8053",
+		PayloadTransformer: PayloadTransformerFunc(func(payload []byte) ([]byte, string, error) {
+			return append([]byte("enc:"), payload...), "test-v1", nil
+		}),
+		RequestSigner: RequestSignerFunc(func(req *http.Request, body []byte) error {
+			req.Header.Set("X-Signed-Body", string(body))
+			return nil
+		}),
+	}}
+	message := []byte("message")
+
+	req, err := exporter.buildRequest(context.Background(), message, RemoteWrite1)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "enc:message", string(body))
+	assert.Equal(t, "test-v1", req.Header.Get("X-Payload-Transform"))
+	assert.Equal(t, "snappy", req.Header.Get("Content-Encoding"))
+	assert.Equal(t, "enc:message", req.Header.Get("X-Signed-Body"), "the transformed body is signed")
+	assert.Equal(t, []byte("message"), message, "the message is not modified")
+}
+
+func TestPayloadTransformerError(t *testing.T) {
+	transformErr := errors.New("no key")
+	exporter := Exporter{config: Config{
+		LogzioMetricsListener: "https://listener.logz.io:8053",
+		PayloadTransformer: PayloadTransformerFunc(func([]byte) ([]byte, string, error) {
+			return nil, "", transformErr
+		}),
+	}}
+
+	_, err := exporter.buildRequest(context.Background(), []byte("message"), RemoteWrite1)
+	assert.ErrorIs(t, err, transformErr)
+}
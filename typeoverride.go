@@ -0,0 +1,67 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// MetricTypeOverride is the type a metric is exported as, regardless of the kind of its instrument.
+type MetricTypeOverride int
+
+const (
+	// MetricTypeCounter exports gauges and sums as monotonic cumulative counters.
+	MetricTypeCounter MetricTypeOverride = iota + 1
+	// MetricTypeGauge exports sums as gauges.
+	MetricTypeGauge
+)
+
+// overrideMetricType returns the data of a metric coerced to the type configured for its name in
+// MetricTypeOverrides. Histograms and metrics without an override are returned unchanged.
+func (e *Exporter) overrideMetricType(name string, data metricdata.Aggregation) metricdata.Aggregation {
+	override, ok := e.config.MetricTypeOverrides[name]
+	if !ok {
+		return data
+	}
+
+	switch data := data.(type) {
+	case metricdata.Sum[int64]:
+		return overrideSumType(data, override)
+	case metricdata.Sum[float64]:
+		return overrideSumType(data, override)
+	case metricdata.Gauge[int64]:
+		return overrideGaugeType(data, override)
+	case metricdata.Gauge[float64]:
+		return overrideGaugeType(data, override)
+	}
+	return data
+}
+
+// overrideSumType returns a sum as a monotonic sum for counters, or as a gauge with the values of its datapoints.
+func overrideSumType[N int64 | float64](sum metricdata.Sum[N], override MetricTypeOverride) metricdata.Aggregation {
+	if override == MetricTypeGauge {
+		return metricdata.Gauge[N]{DataPoints: sum.DataPoints}
+	}
+	sum.IsMonotonic = true
+	return sum
+}
+
+// overrideGaugeType returns a gauge as a monotonic cumulative sum for counters.
+func overrideGaugeType[N int64 | float64](gauge metricdata.Gauge[N], override MetricTypeOverride) metricdata.Aggregation {
+	if override == MetricTypeCounter {
+		return metricdata.Sum[N]{DataPoints: gauge.DataPoints, Temporality: metricdata.CumulativeTemporality, IsMonotonic: true}
+	}
+	return gauge
+}
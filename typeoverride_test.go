@@ -0,0 +1,59 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricTypeOverrides(t *testing.T) {
+	now := time.Now()
+	rm := &metricdata.ResourceMetrics{
+		Resource: getResource(),
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Scope: getScope(),
+			Metrics: []metricdata.Metrics{
+				{Name: "requests", Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{{Time: now, Value: 3}}}},
+				{Name: "queue_size", Data: metricdata.Sum[float64]{
+					Temporality: metricdata.CumulativeTemporality,
+					IsMonotonic: true,
+					DataPoints:  []metricdata.DataPoint[float64]{{Time: now, Value: 7}},
+				}},
+				{Name: "other", Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{{Time: now, Value: 1}}}},
+			},
+		}},
+	}
+	exporter := Exporter{config: Config{
+		RemoteWriteProtocol: RemoteWrite2,
+		MetricTypeOverrides: map[string]MetricTypeOverride{"requests": MetricTypeCounter, "queue_size": MetricTypeGauge},
+	}}
+
+	// The metadata is only recorded by the conversion of an export.
+	timeseries, err := exporter.convertToTimeSeries(rm, conversionExport)
+	require.NoError(t, err)
+	require.Len(t, timeseries, 3)
+
+	types := map[string]string{}
+	exporter.metadata.Range(func(name, metadata any) bool {
+		types[name.(string)] = metadata.(metricMetadata).dataType
+		return true
+	})
+	assert.Equal(t, map[string]string{"requests": "counter", "queue_size": "gauge", "other": "gauge"}, types)
+}
@@ -0,0 +1,55 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"strings"
+)
+
+// unitSuffixes maps common metric name suffixes to the unit they denote.
+var unitSuffixes = map[string]string{
+	"ns":           "ns",
+	"nanoseconds":  "ns",
+	"us":           "us",
+	"microseconds": "us",
+	"ms":           "ms",
+	"milliseconds": "ms",
+	"s":            "s",
+	"seconds":      "s",
+	"bytes":        "By",
+	"By":           "By",
+}
+
+// inferUnit returns the unit denoted by the suffix of a metric name, or an empty string when the suffix is
+// not a known unit.
+func inferUnit(metricName string) string {
+	i := strings.LastIndexByte(metricName, '_')
+	if i < 0 {
+		return ""
+	}
+	return unitSuffixes[metricName[i+1:]]
+}
+
+// metricNameWithUnit appends the unit to the metric name. When inferUnits is set, the unit is not appended if
+// the name already ends with it, e.g. latency_ms or latency_milliseconds with the ms unit.
+func metricNameWithUnit(metricName, unit string, inferUnits bool) string {
+	if unit == "" {
+		return metricName
+	}
+	if inferUnits && (strings.HasSuffix(metricName, "_"+unit) || inferUnit(metricName) == unit) {
+		return metricName
+	}
+	return metricName + "_" + unit
+}
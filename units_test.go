@@ -0,0 +1,52 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricNameWithUnit(t *testing.T) {
+	tests := []struct {
+		name       string
+		metricName string
+		unit       string
+		inferUnits bool
+		want       string
+	}{
+		{name: "no unit", metricName: "requests", want: "requests"},
+		{name: "unit", metricName: "latency", unit: "ms", want: "latency_ms"},
+		{name: "double suffix without inference", metricName: "latency_ms", unit: "ms", want: "latency_ms_ms"},
+		{name: "same suffix", metricName: "latency_ms", unit: "ms", inferUnits: true, want: "latency_ms"},
+		{name: "equivalent suffix", metricName: "latency_milliseconds", unit: "ms", inferUnits: true, want: "latency_milliseconds"},
+		{name: "bytes suffix", metricName: "payload_bytes", unit: "By", inferUnits: true, want: "payload_bytes"},
+		{name: "different suffix", metricName: "latency_seconds", unit: "ms", inferUnits: true, want: "latency_seconds_ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, metricNameWithUnit(tt.metricName, tt.unit, tt.inferUnits))
+		})
+	}
+}
+
+func TestInferUnit(t *testing.T) {
+	assert.Equal(t, "ms", inferUnit("latency_ms"))
+	assert.Equal(t, "s", inferUnit("duration_seconds"))
+	assert.Equal(t, "By", inferUnit("payload_bytes"))
+	assert.Equal(t, "", inferUnit("requests_total"))
+	assert.Equal(t, "", inferUnit("requests"))
+}
@@ -0,0 +1,72 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"log"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// configWarnings tracks how the exporter is wired, to warn once about options that have no effect.
+type configWarnings struct {
+	// pushIntervalSet is true when PushInterval was set rather than defaulted.
+	pushIntervalSet bool
+	readerCreated   atomic.Bool
+	aggregationUsed atomic.Bool
+
+	pushIntervalWarned        atomic.Bool
+	histogramBoundariesWarned atomic.Bool
+}
+
+// logger returns the Logger, or the standard logger when it is not set.
+func (e *Exporter) logger() *log.Logger {
+	if e.config.Logger != nil {
+		return e.config.Logger
+	}
+	return log.Default()
+}
+
+// unusedConfigWarnings returns the warnings about options of the config that have no effect with the other
+// options. It must be called before the config is validated, which sets the defaults.
+func unusedConfigWarnings(config Config) []string {
+	var warnings []string
+	if config.Quantiles != nil && config.HistogramQuantiles == HistogramQuantilesDisabled {
+		warnings = append(warnings, "Quantiles has no effect without HistogramQuantiles")
+	}
+	return warnings
+}
+
+// warnUnusedConfig logs a warning, once, when the exported metrics show that the PushInterval or
+// HistogramBoundaries have no effect, because the exporter is not read by Exporter.NewPeriodicReader or its
+// Aggregation is not used by the reader, e.g. when it is wrapped.
+func (e *Exporter) warnUnusedConfig(rm *metricdata.ResourceMetrics) {
+	if e.warnings.pushIntervalSet && !e.warnings.readerCreated.Load() && e.warnings.pushIntervalWarned.CompareAndSwap(false, true) {
+		e.logger().Printf("Logz.io metrics exporter: PushInterval has no effect, the exporter is not read by Exporter.NewPeriodicReader")
+	}
+
+	if len(e.config.HistogramBoundaries) == 0 || e.warnings.aggregationUsed.Load() || e.warnings.histogramBoundariesWarned.Load() || rm == nil {
+		return
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if isHistogram(m.Data) && e.warnings.histogramBoundariesWarned.CompareAndSwap(false, true) {
+				e.logger().Printf("Logz.io metrics exporter: HistogramBoundaries has no effect, the reader does not use Exporter.Aggregation")
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_exporter
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestNewWarnsAboutQuantilesWithoutHistogramQuantiles(t *testing.T) {
+	var logs bytes.Buffer
+	_, err := New(Config{LogzioMetricsToken: "123456789a", Quantiles: []float64{0.5}, Logger: log.New(&logs, "", 0)})
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "Quantiles has no effect without HistogramQuantiles")
+
+	logs.Reset()
+	_, err = New(Config{LogzioMetricsToken: "123456789a", Logger: log.New(&logs, "", 0)})
+	require.NoError(t, err)
+	assert.Empty(t, logs.String(), "the default quantiles are not reported")
+}
+
+func TestWarnUnusedConfig(t *testing.T) {
+	histogram := getHistogramMetric(1, metricdata.NewExtrema[int64](4), metricdata.NewExtrema[int64](4), 4)
+
+	t.Run("not wired", func(t *testing.T) {
+		var logs bytes.Buffer
+		exporter := Exporter{config: Config{HistogramBoundaries: []float64{1, 10}, Logger: log.New(&logs, "", 0)}}
+		exporter.warnings.pushIntervalSet = true
+
+		exporter.warnUnusedConfig(getSumMetric(1))
+		exporter.warnUnusedConfig(histogram)
+		exporter.warnUnusedConfig(histogram)
+
+		assert.Equal(t, 1, strings.Count(logs.String(), "PushInterval has no effect"))
+		assert.Equal(t, 1, strings.Count(logs.String(), "HistogramBoundaries has no effect"))
+	})
+
+	t.Run("wired", func(t *testing.T) {
+		var logs bytes.Buffer
+		exporter := Exporter{config: Config{HistogramBoundaries: []float64{1, 10}, Logger: log.New(&logs, "", 0)}}
+		exporter.warnings.pushIntervalSet = true
+		exporter.NewPeriodicReader()
+		exporter.Aggregation(metric.InstrumentKindHistogram)
+
+		exporter.warnUnusedConfig(histogram)
+		assert.Empty(t, logs.String())
+	})
+}